@@ -0,0 +1,13 @@
+package main
+
+// Exit codes returned by the non-interactive subcommands so scripts can branch on the outcome. 1 is
+// left to log.Fatalf and other unexpected failures, so a bad configuration can be told apart from a crash.
+const (
+	exitOK               = 0 // The transaction matched the filters and was decoded
+	exitUsage            = 2 // Invalid command line arguments
+	exitNoMatch          = 3 // The transaction would be filtered out
+	exitNotFound         = 4 // The requested transaction or chain could not be found
+	exitDecodeError      = 5 // The transaction matched but its calldata could not be decoded
+	exitConnectionFailed = 6 // --check or monitoring could not reach an endpoint or subscribe
+	exitConfigError      = 7 // The configuration could not be loaded
+)
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// defaultFeedHistory is how many entries each feed keeps for re-filtering
+const defaultFeedHistory = 500
+
+// defaultViewMaxLines is how many lines each scrolling view keeps unless TUI_MAX_LINES says otherwise
+const defaultViewMaxLines = 5000
+
+// feed appends entries to a scrolling text view. The most recent entries are kept so a filter can
+// be applied to what has already been received. While paused, new entries are held back so the
+// view can be scrolled freely, and are appended once the feed resumes. A pinned text, such as the
+// details of a selected transaction, is shown in place of the entries until it is unpinned. Its
+// methods must run on the UI goroutine.
+type feed struct {
+	view    *tview.TextView
+	history int
+	entries []string // The most recent entries, oldest first
+	paused  bool
+	pending []string // Entries received while paused, at most history of them
+	filter  string   // Lower-cased substring entries must contain to be shown
+	pinned  string   // Shown instead of the entries when set
+}
+
+func newFeed(view *tview.TextView, history int) *feed {
+	if history <= 0 {
+		history = defaultFeedHistory
+	}
+	return &feed{view: view, history: history}
+}
+
+// add appends an entry and scrolls to it, or holds it back while paused. Only the most recent
+// held back entries are kept, since older ones would be dropped from the history on resume anyway.
+func (f *feed) add(entry string) {
+	if f.paused {
+		f.pending = f.latest(append(f.pending, entry))
+		return
+	}
+
+	f.record(entry)
+	if f.pinned == "" && f.matches(entry) {
+		fmt.Fprint(f.view, entry+"\n") // Appended as is, keeping color tags such as the gas price coloring
+		f.scrollToEnd()
+	}
+}
+
+// record keeps an entry, dropping the oldest once the history is full
+func (f *feed) record(entry string) {
+	f.entries = f.latest(append(f.entries, entry))
+}
+
+// latest trims entries to the most recent history of them
+func (f *feed) latest(entries []string) []string {
+	if len(entries) > f.history {
+		return entries[len(entries)-f.history:]
+	}
+	return entries
+}
+
+// matches reports whether an entry passes the filter
+func (f *feed) matches(entry string) bool {
+	return f.filter == "" || strings.Contains(strings.ToLower(entry), f.filter)
+}
+
+// setPaused pauses or resumes the feed, appending the held back entries on resume
+func (f *feed) setPaused(paused bool) {
+	f.paused = paused
+	if paused || len(f.pending) == 0 {
+		return
+	}
+
+	for _, entry := range f.pending {
+		f.record(entry)
+	}
+	f.pending = nil
+	f.render()
+}
+
+// setFilter shows only the kept entries containing filter, case-insensitively; an empty filter
+// restores the full feed
+func (f *feed) setFilter(filter string) {
+	f.filter = strings.ToLower(strings.TrimSpace(filter))
+	f.render()
+}
+
+// pin shows text in place of the entries; an empty text shows the entries again
+func (f *feed) pin(text string) {
+	f.pinned = text
+	f.render()
+}
+
+// scrollToEnd follows new entries, unless an entry is highlighted so it stays in view
+func (f *feed) scrollToEnd() {
+	if len(f.view.GetHighlights()) == 0 {
+		f.view.ScrollToEnd()
+	}
+}
+
+// render redraws the view from the kept entries that match the filter, or shows the pinned text
+func (f *feed) render() {
+	if f.pinned != "" {
+		f.view.SetText(f.pinned)
+		f.view.ScrollToBeginning()
+		return
+	}
+
+	var text strings.Builder
+	for _, entry := range f.entries {
+		if f.matches(entry) {
+			text.WriteString(entry + "\n")
+		}
+	}
+	f.view.SetText(text.String())
+	f.scrollToEnd()
+}
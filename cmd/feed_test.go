@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// A paused feed holds back at most its history of entries, and shows the most recent on resume
+func TestPausedFeedKeepsLatestEntries(t *testing.T) {
+	view := tview.NewTextView()
+	f := newFeed(view, 3)
+	f.add("first")
+	f.setPaused(true)
+	for i := 1; i <= 10; i++ {
+		f.add(fmt.Sprintf("held %d", i))
+	}
+	if len(f.pending) != 3 {
+		t.Fatalf("%d entries held back, want 3", len(f.pending))
+	}
+
+	f.setPaused(false)
+	if want := "held 8\nheld 9\nheld 10\n"; view.GetText(false) != want {
+		t.Errorf("view shows %q, want %q", view.GetText(false), want)
+	}
+}
+
+// BenchmarkFeedAdd appends a 20-line entry to a feed already holding the given number of entries,
+// drawing to a 200x60 screen every 10 entries as the TUI does. The cost per entry should stay flat
+// as the history grows since entries are appended in place and the view is capped at its max lines.
+func BenchmarkFeedAdd(b *testing.B) {
+	entry := strings.TrimSuffix(strings.Repeat("[green]0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2[-] swapExactTokensForTokens\n", 20), "\n")
+
+	for _, preloaded := range []int{0, 2000, 6000} {
+		b.Run(fmt.Sprintf("history=%d", preloaded), func(b *testing.B) {
+			screen := tcell.NewSimulationScreen("")
+			if err := screen.Init(); err != nil {
+				b.Fatal(err)
+			}
+			defer screen.Fini()
+			screen.SetSize(200, 60)
+
+			view := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetRegions(true)
+			view.SetMaxLines(defaultViewMaxLines)
+			view.SetRect(0, 0, 200, 60)
+			f := newFeed(view, defaultFeedHistory)
+			for i := 0; i < preloaded; i++ {
+				f.add(entry)
+			}
+			view.Draw(screen)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f.add(entry)
+				if i%10 == 0 {
+					view.Draw(screen)
+					screen.Show()
+				}
+			}
+		})
+	}
+}
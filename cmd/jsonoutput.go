@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"eth-mempool-monitor/internal/logging"
+	"eth-mempool-monitor/internal/mempool"
+)
+
+// runJSONOutput monitors the mempool without the TUI, writing each matched and decoded transaction to
+// stdout as a JSON line. Logs go to stderr so stdout stays clean for piping. It returns the exit code:
+// exitOK after a clean shutdown, or exitConnectionFailed when monitoring couldn't start.
+func runJSONOutput() int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Stop on the usual termination signals; the monitor flushes pending output before returning
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	logBuffer := newLogBuffer()
+	log.SetOutput(io.MultiWriter(logBuffer, os.Stderr))
+	serveAPI(ctx, logBuffer)
+	serveMetrics(ctx)
+	serveStream(ctx)
+
+	// The TUI channels still receive updates, so drain them until monitoring returns
+	drained := make(chan struct{})
+	defer close(drained)
+	txChan := make(chan string, 10)
+	txDetailsChan := make(chan string, 10)
+	tpsChan := make(chan mempool.TPS, 10)
+	headsChan := make(chan mempool.BlockHead, 10)
+	minedChan := make(chan mempool.MinedTx, 10)
+	go func() {
+		for {
+			select {
+			case <-txChan:
+			case <-txDetailsChan:
+			case <-tpsChan:
+			case <-headsChan:
+			case <-minedChan:
+			case <-drained:
+				return
+			}
+		}
+	}()
+
+	mempool.SetJSONOutput(os.Stdout)
+	if err := mempool.MonitorMempool(ctx, tpsChan, txChan, txDetailsChan, headsChan, minedChan); err != nil {
+		logging.Errorf("Monitoring failed: %v", err)
+		return exitConnectionFailed
+	}
+	mempool.WriteSummary(os.Stderr)
+	return exitOK
+}
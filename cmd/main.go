@@ -2,27 +2,75 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
+	"eth-mempool-monitor/internal/api"
+	"eth-mempool-monitor/internal/logging"
 	"eth-mempool-monitor/internal/mempool"
+	"eth-mempool-monitor/internal/settings"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
 func main() {
+	// Non-interactive subcommands run without the TUI
+	if len(os.Args) > 1 && os.Args[1] == "test-filter" {
+		os.Exit(runTestFilter(os.Args[2:]))
+	}
+
+	// --output=json (or OUTPUT_FORMAT=json) replaces the TUI with JSON lines on stdout
+	output := flag.String("output", os.Getenv("OUTPUT_FORMAT"), "output mode: tui or json")
+	replay := flag.String("replay", "", "replay messages from a file instead of connecting to the WebSocket")
+	replaySpeed := flag.Float64("replay-speed", 0, "replay pace relative to the captured timestamps; 0 replays as fast as possible")
+	check := flag.Bool("check", false, "validate the configuration, contracts and endpoints, then exit")
+	flag.Parse()
+	switch *output {
+	case "", "tui", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output mode %q, expected tui or json\n", *output)
+		os.Exit(exitUsage)
+	}
+
+	// Catch missing or malformed endpoints up front rather than as a dial error later. A replay doesn't
+	// connect to the WebSocket, and only needs the HTTPS endpoint for hash-only messages.
+	if *replay != "" {
+		mempool.SetReplay(*replay, *replaySpeed)
+	}
+	if err := setup(*replay == "" || *check); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitConfigError)
+	}
+	if *check {
+		os.Exit(runCheck())
+	}
+	if *output == "json" {
+		os.Exit(runJSONOutput())
+	}
+
 	// Create a new context and cancel function
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Set up buffered channels for transaction updates, decoded transaction details, TPS, and logs
-	txChan := make(chan string, 10)
-	txDetailsChan := make(chan string, 10)
-	tpsChan := make(chan uint64, 10)
-	logChan := make(chan string, 10) // Channel for log messages
+	// Set up buffered channels for transaction updates, decoded transaction details, TPS, and logs.
+	// Updates that don't fit are dropped rather than stalling the monitor, so the feeds get room for bursts.
+	txChan := make(chan string, 100)
+	txDetailsChan := make(chan string, 100)
+	tpsChan := make(chan mempool.TPS, 10)
+	headsChan := make(chan mempool.BlockHead, 10)
+	minedChan := make(chan mempool.MinedTx, 10)
+	logChan := make(chan string, 100) // Channel for log messages
 
 	// Setup signal handling to exit gracefully
 	sigCh := make(chan os.Signal, 1)
@@ -61,62 +109,363 @@ func main() {
 			app.Draw()
 		})
 
-	// Create a grid layout with an additional row for logs
+	// Bound the scrolling views so a long session doesn't grow their text and redraw time without
+	// limit; the oldest lines are trimmed once a view holds more than TUI_MAX_LINES
+	for _, view := range []*tview.TextView{txView, txDetailsView, logView} {
+		view.SetMaxLines(viewMaxLines)
+	}
+
+	gasView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(false)
+
+	// Create a grid layout with an additional row for logs. LAYOUT=unified shows each transaction's
+	// summary and decoded details together in a single pane instead of two side-by-side panes.
+	// GAS_PERCENTILES adds a gas price percentile row above the logs.
 	grid := tview.NewGrid().
-		SetRows(3, 0, 5). // Three rows: TPS, transactions, and logs
-		SetColumns(0, 0). // Two columns: transactions and details
-		SetBorders(true).
-		AddItem(tpsView, 0, 0, 1, 2, 0, 0, false).      // TPS view at the top, spanning two columns
-		AddItem(txView, 1, 0, 1, 1, 0, 0, true).        // Transactions list on the left
-		AddItem(txDetailsView, 1, 1, 1, 1, 0, 0, true). // Transaction details on the right
-		AddItem(logView, 2, 0, 1, 2, 0, 0, false)       // Log view at the bottom, spanning two columns
+		SetRows(4, 0, 5). // Three rows: TPS and stats, transactions, and logs
+		SetBorders(true)
+	logRow := 2
+	if mempool.GasPercentilesEnabled() {
+		grid.SetRows(4, 0, 1, 5) // Gas price percentiles between the transactions and logs
+		logRow = 3
+	}
+	columns := 2
+	if os.Getenv("LAYOUT") == "unified" {
+		columns = 1
+		grid.SetColumns(0)                           // A single column holding the unified feed
+		grid.AddItem(txView, 1, 0, 1, 1, 0, 0, true) // Transactions, each followed by its decoded details
+	} else {
+		grid.SetColumns(0, 0)                               // Two columns: transactions and details
+		grid.AddItem(txView, 1, 0, 1, 1, 0, 0, true)        // Transactions list on the left
+		grid.AddItem(txDetailsView, 1, 1, 1, 1, 0, 0, true) // Transaction details on the right
+	}
+	grid.AddItem(tpsView, 0, 0, 1, columns, 0, 0, false) // TPS view at the top, spanning all columns
+	if mempool.GasPercentilesEnabled() {
+		grid.AddItem(gasView, 2, 0, 1, columns, 0, 0, false)
+	}
+	grid.AddItem(logView, logRow, 0, 1, columns, 0, 0, false) // Log view at the bottom, spanning all columns
+
+	// The feeds keep their last FEED_HISTORY entries for filtering. Pressing 'p' pauses them so they
+	// can be scrolled back; incoming transactions are held back until it is pressed again. The state
+	// is only touched on the UI goroutine.
+	feeds := []*feed{newFeed(txView, feedHistory), newFeed(txDetailsView, feedHistory)}
+	txFeed, detailsFeed := feeds[0], feeds[1]
+	paused := false
+	header := "Transactions Per Second (TPS): 0"
+	showHeader := func() {
+		if paused {
+			tpsView.SetText("[yellow]PAUSED[-] (press p to resume) | " + header)
+		} else {
+			tpsView.SetText(header)
+		}
+	}
+
+	// In the split layout, the up and down arrows in the transaction feed select a transaction, as does
+	// clicking it, and the details pane then shows only its decoded details. Enter selects the latest
+	// transaction and Escape returns the details pane to the live feed.
+	detailsStore := newTxDetailsStore(feedHistory)
+	selected := ""
+	showSelected := func() {
+		details, ok := detailsStore.get(selected)
+		if !ok {
+			details = "No decoded details kept for " + selected
+		}
+		detailsFeed.pin(details)
+	}
+	if columns > 1 {
+		txView.SetHighlightedFunc(func(added, removed, remaining []string) {
+			switch {
+			case len(added) > 0:
+				selected = added[0]
+				showSelected()
+			case len(remaining) == 0:
+				selected = ""
+				detailsFeed.pin("")
+			}
+		})
+		visible := func(hash string) bool {
+			return txView.GetRegionText(hash) != "" // Filtered out or held back while paused otherwise
+		}
+		txView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			var hash string
+			switch event.Key() {
+			case tcell.KeyUp:
+				hash = detailsStore.step(selected, -1, visible)
+			case tcell.KeyDown:
+				hash = detailsStore.step(selected, 1, visible)
+			case tcell.KeyEnter:
+				hash = detailsStore.step("", -1, visible)
+			case tcell.KeyEscape:
+				txView.Highlight()
+				return nil
+			default:
+				return event
+			}
+			if hash != "" {
+				txView.Highlight(hash).ScrollToHighlight()
+			}
+			return nil
+		})
+	}
+
+	// Pressing '/' opens a filter box below the panes; the feeds only show entries containing its
+	// text, including those received earlier. Enter keeps the filter and Escape clears it.
+	root := tview.NewFlex().SetDirection(tview.FlexRow).AddItem(grid, 0, 1, true)
+	filterInput := tview.NewInputField().SetLabel("Filter: ")
+	filterInput.SetChangedFunc(func(text string) {
+		for _, f := range feeds {
+			f.setFilter(text)
+		}
+	})
+	filterInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			filterInput.SetText("") // Clearing the text restores the full feed
+		}
+		root.RemoveItem(filterInput)
+		app.SetFocus(grid)
+	})
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune || app.GetFocus() == filterInput {
+			return event // Keys typed into the filter box are not shortcuts
+		}
+		switch event.Rune() {
+		case 'p':
+			paused = !paused
+			for _, f := range feeds {
+				f.setPaused(paused)
+			}
+			showHeader()
+			return nil
+		case 'r':
+			mempool.ResetContractMatches() // Shown as zero from the next TPS update
+			return nil
+		case '/':
+			root.RemoveItem(filterInput) // Don't add it twice
+			root.AddItem(filterInput, 1, 0, true)
+			app.SetFocus(filterInput)
+			return nil
+		}
+		return event
+	})
+
+	// Closed once mempool monitoring has shut down
+	monitorDone := make(chan struct{})
 
 	// Goroutine for handling transaction data and logs
 	go func() {
+		var latestHead *mempool.BlockHead // Most recent block, shown next to the TPS once NEW_HEADS delivers one
 		for {
 			select {
 			case <-sigCh:
-				cancel() // Signal to cancel the context and stop all goroutines
-				return
+				// Stop monitoring, but keep drawing the updates it still delivers until it has shut down
+				cancel()
+				go func() {
+					<-monitorDone
+					app.Stop()
+				}()
 			case tps := <-tpsChan:
+				stats := mempool.CurrentStats()
+				text := fmt.Sprintf("Transactions Per Second (TPS): %d (%ds avg: %.0f)%s\n%s", tps.Current, tps.Window, tps.Average, formatHead(latestHead), formatStats(stats))
 				app.QueueUpdateDraw(func() {
-					tpsView.SetText(fmt.Sprintf("Transactions Per Second (TPS): %d", tps))
+					header = text
+					showHeader()
+					if stats.GasPercentiles != nil {
+						gasView.SetText(mempool.FormatGasPercentiles(stats.GasPercentiles))
+					}
+				})
+			case head := <-headsChan:
+				latestHead = &head
+			case mined := <-minedChan:
+				line := fmt.Sprintf("[green]Mined:[-] %s in block #%d after %s in the mempool", mined.Hash, mined.BlockNumber, mined.Dwell.Round(time.Millisecond))
+				app.QueueUpdateDraw(func() {
+					txFeed.add(line)
 				})
 			case tx := <-txChan:
 				app.QueueUpdateDraw(func() {
-					currentTxText := txView.GetText(true)
-					newTxText := currentTxText + tx + "\n" // Append new transaction details
-					txView.SetText(newTxText)
-					txView.ScrollToEnd() // Scroll to end after updating
+					txFeed.add(tx) // Append new transaction details
 				})
 			case txDetails := <-txDetailsChan:
 				app.QueueUpdateDraw(func() {
-					currentDetailsText := txDetailsView.GetText(true)
-					newDetailsText := currentDetailsText + txDetails + "\n" // Append new decoded transaction details
-					txDetailsView.SetText(newDetailsText)
-					txDetailsView.ScrollToEnd() // Scroll to end after updating
+					if hash := entryHash(txDetails); hash != "" {
+						detailsStore.add(hash, txDetails)
+						if hash == selected {
+							showSelected() // Selected before its details arrived
+						}
+					}
+					detailsFeed.add(txDetails) // Append new decoded transaction details
 				})
 			case logMsg := <-logChan:
 				app.QueueUpdateDraw(func() {
-					currentLogText := logView.GetText(true)
-					newLogText := currentLogText + logMsg + "\n" // Append new log messages
-					logView.SetText(newLogText)
-					logView.ScrollToEnd() // Scroll to end after updating
+					fmt.Fprint(logView, logMsg+"\n") // Append new log messages
+					logView.ScrollToEnd()            // Scroll to end after updating
 				})
 			}
 		}
 	}()
 
-	// Redirect standard log output to the log channel
-	log.SetOutput(logWriter(logChan))
+	// Redirect standard log output to the log channel and the log buffer
+	logBuffer := newLogBuffer()
+	log.SetOutput(io.MultiWriter(logBuffer, logWriter(logChan)))
 
-	// Start the mempool monitoring
-	go mempool.MonitorMempool(ctx, tpsChan, txChan, txDetailsChan)
+	// Serve the HTTP API, metrics and transaction stream when their ports are configured
+	serveAPI(ctx, logBuffer)
+	serveMetrics(ctx)
+	serveStream(ctx)
+
+	// Start the mempool monitoring; monitorDone is closed once it has shut down. A failure to start is
+	// logged in the TUI, and the process exits with exitConnectionFailed once the TUI is closed.
+	var monitorErr error
+	go func() {
+		defer close(monitorDone)
+		if monitorErr = mempool.MonitorMempool(ctx, tpsChan, txChan, txDetailsChan, headsChan, minedChan); monitorErr != nil {
+			logging.Errorf("Monitoring failed: %v", monitorErr)
+		}
+	}()
 
 	// Run the application
-	if err := app.SetRoot(grid, true).Run(); err != nil {
+	if err := app.SetRoot(root, true).Run(); err != nil {
 		log.Fatalf("failed to run application: %v", err)
 	}
+
+	// The TUI no longer drains the log channel, so send logs back to stderr and wait for the
+	// monitor to flush and close its sinks before exiting
+	log.SetOutput(os.Stderr)
+	cancel()
+	<-monitorDone
+	if monitorErr != nil {
+		logging.Errorf("Monitoring failed: %v", monitorErr)
+		os.Exit(exitConnectionFailed)
+	}
+	mempool.WriteSummary(os.Stderr)
+}
+
+// Sizes of the TUI views and feeds and of the log buffer, read in setup
+var (
+	viewMaxLines  = defaultViewMaxLines
+	feedHistory   = defaultFeedHistory
+	logBufferSize = api.DefaultLogBufferSize
+)
+
+// setup loads the configuration and prepares the mempool package. The endpoints are only checked
+// when the monitor is going to connect.
+func setup(requireEndpoints bool) error {
+	cfg, err := mempool.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	// The .env file is loaded by now, so the display settings can be read too
+	var problems settings.Problems
+	problems.Int("TUI_MAX_LINES", &viewMaxLines, 1)
+	problems.Int("FEED_HISTORY", &feedHistory, 1)
+	problems.Int("LOG_BUFFER_SIZE", &logBufferSize, 1)
+	if err := problems.Err(); err != nil {
+		return err
+	}
+
+	if requireEndpoints {
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+	}
+	return mempool.Setup(cfg)
+}
+
+// newLogBuffer keeps recent log lines for the API, sized by LOG_BUFFER_SIZE
+func newLogBuffer() *api.LogBuffer {
+	return api.NewLogBuffer(logBufferSize)
+}
+
+// defaultListenHost keeps the HTTP servers local unless API_HOST says otherwise, since /logs and
+// /stats are served without authentication
+const defaultListenHost = "127.0.0.1"
+
+// listenAddr returns the address to serve a port on, on API_HOST (e.g. 0.0.0.0 to listen on every
+// interface) or localhost
+func listenAddr(port string) string {
+	host := os.Getenv("API_HOST")
+	if host == "" {
+		host = defaultListenHost
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// serveAPI starts the HTTP API in the background when API_PORT is set
+func serveAPI(ctx context.Context, logBuffer *api.LogBuffer) {
+	apiPort := os.Getenv("API_PORT")
+	if apiPort == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/stats", mempool.StatsHandler())
+	mux.Handle("/healthz", mempool.HealthHandler())
+	mux.Handle("/readyz", mempool.ReadyHandler())
+	mux.Handle("/logs", logBuffer.Handler())
+	go api.Serve(ctx, listenAddr(apiPort), mux)
+}
+
+// serveMetrics starts the Prometheus metrics endpoint in the background when METRICS_PORT is set
+func serveMetrics(ctx context.Context) {
+	metricsPort := os.Getenv("METRICS_PORT")
+	if metricsPort == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", mempool.MetricsHandler())
+	go api.Serve(ctx, listenAddr(metricsPort), mux)
+}
+
+// serveStream streams matched transactions as Server-Sent Events at /events when STREAM_PORT is set,
+// for viewing the feed in a browser. It must be called before monitoring starts.
+func serveStream(ctx context.Context) {
+	streamPort := os.Getenv("STREAM_PORT")
+	if streamPort == "" {
+		return
+	}
+
+	stream := api.NewEventStream()
+	mempool.SetTransactionStream(stream)
+	mux := http.NewServeMux()
+	mux.Handle("/events", stream.Handler(ctx))
+	go api.Serve(ctx, listenAddr(streamPort), mux)
+}
+
+// formatHead renders the latest block for the header row; it is refreshed along with the TPS every second
+func formatHead(head *mempool.BlockHead) string {
+	if head == nil {
+		return ""
+	}
+	return fmt.Sprintf(" | Latest block: #%d (%s ago)", head.Number, time.Since(head.Timestamp).Round(time.Second))
+}
+
+// formatStats renders the pipeline saturation gauges as a status line, followed by the per-contract
+// match counts and the swap volume when enabled
+func formatStats(stats mempool.Stats) string {
+	line := fmt.Sprintf("Workers: %d/%d busy | Processing: %d | RPC in flight: %d", stats.Workers.Busy, stats.Workers.Total, stats.InFlightTransactions, stats.InFlightRPC)
+	if limiter := stats.RateLimiter; limiter != nil {
+		line += fmt.Sprintf(" | RPC tokens: %.0f/%.0f", limiter.Tokens, limiter.Burst)
+	}
+	line += " | Queues:"
+	for _, name := range []string{"messages", "tx", "txDetails", "tps"} {
+		if queue, ok := stats.Queues[name]; ok {
+			line += fmt.Sprintf(" %s %d/%d", name, queue.Depth, queue.Capacity)
+			if queue.Dropped > 0 {
+				line += fmt.Sprintf(" (%d dropped)", queue.Dropped)
+			}
+		}
+	}
+	line += fmt.Sprintf(" | Latency: avg %.0fms over %d", stats.Latency.MeanMs, stats.Latency.Count)
+	if len(stats.ContractMatches) > 0 {
+		line += "\n" + mempool.FormatContractMatches(stats.ContractMatches)
+	}
+	if stats.SwapVolume != nil {
+		line += "\n" + mempool.FormatSwapVolume(stats.SwapVolume)
+	}
+	return line
 }
 
 // logWriter is a custom log writer that sends log messages to the log channel
@@ -126,9 +475,37 @@ func logWriter(logChan chan<- string) *writerAdapter {
 
 type writerAdapter struct {
 	logChan chan<- string
+	dropped atomic.Uint64 // Lines dropped since the last one that got through
 }
 
+// Write queues a log line without waiting, so logging never stalls the monitor when the log pane
+// falls behind. Dropped lines are counted and noted before the next line that gets through.
 func (w *writerAdapter) Write(p []byte) (n int, err error) {
-	w.logChan <- string(p)
+	line := colorLevel(string(p))
+	dropped := w.dropped.Swap(0)
+	if dropped > 0 {
+		line = fmt.Sprintf("[yellow](%d log lines dropped)[-]\n", dropped) + line
+	}
+	select {
+	case w.logChan <- line:
+	default:
+		w.dropped.Add(dropped + 1) // Keep counting until a line gets through
+	}
 	return len(p), nil
 }
+
+// Colors of the level tags in the log pane; info is left uncolored
+var levelColors = map[string]string{"DEBUG": "gray", "WARN": "yellow", "ERROR": "red"}
+
+// colorLevel colors the level tag that follows the timestamp of a log line
+func colorLevel(line string) string {
+	const timestampLen = len("2006/01/02 15:04:05 ")
+	if len(line) < timestampLen {
+		return line
+	}
+	level, msg, ok := strings.Cut(line[timestampLen:], " ")
+	if color, known := levelColors[level]; ok && known {
+		return line[:timestampLen] + "[" + color + "]" + level + "[-] " + msg
+	}
+	return line
+}
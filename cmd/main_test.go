@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestListenAddr(t *testing.T) {
+	if got := listenAddr("8080"); got != "127.0.0.1:8080" {
+		t.Errorf("listenAddr without API_HOST = %q, want 127.0.0.1:8080", got)
+	}
+	t.Setenv("API_HOST", "0.0.0.0")
+	if got := listenAddr("8080"); got != "0.0.0.0:8080" {
+		t.Errorf("listenAddr with API_HOST=0.0.0.0 = %q, want 0.0.0.0:8080", got)
+	}
+	t.Setenv("API_HOST", "::1")
+	if got := listenAddr("8080"); got != "[::1]:8080" {
+		t.Errorf("listenAddr with API_HOST=::1 = %q, want [::1]:8080", got)
+	}
+}
+
+// Invalid display settings fail startup instead of silently falling back to the defaults
+func TestSetupRejectsInvalidDisplaySettings(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil { // No .env file
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	invalid := map[string]string{"TUI_MAX_LINES": "lots", "FEED_HISTORY": "0", "LOG_BUFFER_SIZE": "-5"}
+	for name, value := range invalid {
+		t.Setenv(name, value)
+	}
+	err = setup(false)
+	for name := range invalid {
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("setup doesn't report %s: %v", name, err)
+		}
+	}
+	if viewMaxLines != defaultViewMaxLines || feedHistory != defaultFeedHistory {
+		t.Errorf("invalid values replaced the defaults: %d lines, %d entries", viewMaxLines, feedHistory)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"regexp"
+)
+
+// txRegionPattern matches the region tag naming the transaction a feed entry belongs to
+var txRegionPattern = regexp.MustCompile(`^\["([0-9a-zA-Zx]+)"\]`)
+
+// entryHash returns the hash of the transaction a feed entry belongs to, or "" for entries such as
+// mined notices that aren't tagged with one
+func entryHash(entry string) string {
+	if m := txRegionPattern.FindStringSubmatch(entry); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// txDetailsStore keeps the decoded details of the most recent transactions by hash, so the details
+// pane can show the transaction selected in the feed. Its methods must run on the UI goroutine.
+type txDetailsStore struct {
+	max     int
+	hashes  []string // Oldest first
+	details map[string]string
+}
+
+func newTxDetailsStore(max int) *txDetailsStore {
+	if max <= 0 {
+		max = defaultFeedHistory
+	}
+	return &txDetailsStore{max: max, details: make(map[string]string)}
+}
+
+// add keeps the details of a transaction, dropping the oldest once full
+func (s *txDetailsStore) add(hash, details string) {
+	if _, ok := s.details[hash]; !ok {
+		s.hashes = append(s.hashes, hash)
+	}
+	s.details[hash] = details
+	for len(s.hashes) > s.max {
+		delete(s.details, s.hashes[0])
+		s.hashes = s.hashes[1:]
+	}
+}
+
+// get returns the details of a transaction, if they are still kept
+func (s *txDetailsStore) get(hash string) (string, bool) {
+	details, ok := s.details[hash]
+	return details, ok
+}
+
+// step returns the hash of the transaction before (step -1) or after (step 1) the given one for
+// which visible reports true, or the most recent visible one when hash is "". It returns "" when
+// there is none.
+func (s *txDetailsStore) step(hash string, step int, visible func(hash string) bool) string {
+	i := len(s.hashes)
+	if hash != "" {
+		for i = len(s.hashes) - 1; i >= 0 && s.hashes[i] != hash; i-- {
+		}
+		if i < 0 {
+			i = len(s.hashes) // No longer kept; start again from the most recent
+		}
+	}
+	if i == len(s.hashes) {
+		step = -1
+	}
+	for i += step; i >= 0 && i < len(s.hashes); i += step {
+		if visible(s.hashes[i]) {
+			return s.hashes[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"eth-mempool-monitor/internal/mempool"
+)
+
+// runTestFilter implements the test-filter subcommand, which reports whether a transaction would
+// pass the configured filters and why. It returns the process exit code.
+func runTestFilter(args []string) int {
+	fs := flag.NewFlagSet("test-filter", flag.ContinueOnError)
+	data := fs.String("data", "", "transaction calldata as hex")
+	value := fs.String("value", "", "transaction value in wei (decimal or 0x hex)")
+	to := fs.String("to", "", "recipient address")
+	from := fs.String("from", "", "sender address")
+	chain := fs.String("chain", "", "name of the chain whose contracts are matched (default the first chain)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: eth-mempool-monitor test-filter --to <address> --data <hex> [--value <wei>] [--from <address>] [--chain <name>]")
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nExit codes: %d shown and decoded, %d usage error, %d filtered out, %d chain not found, %d shown but not decoded, %d config error\n",
+			exitOK, exitUsage, exitNoMatch, exitNotFound, exitDecodeError, exitConfigError)
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	// The filters are checked offline, so the endpoints aren't needed
+	if err := setup(false); err != nil {
+		fmt.Fprintf(os.Stderr, "test-filter: %v\n", err)
+		return exitConfigError
+	}
+
+	result, err := mempool.TestFilter(mempool.FilterInput{
+		To:    *to,
+		From:  *from,
+		Data:  *data,
+		Value: *value,
+		Chain: *chain,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "test-filter: %v\n", err)
+		if errors.Is(err, mempool.ErrUnknownChain) {
+			return exitNotFound
+		}
+		return exitUsage
+	}
+
+	for _, check := range result.Checks {
+		status := "PASS"
+		if !check.Passed {
+			status = "FAIL"
+		}
+		fmt.Printf("[%s] %-8s %s\n", status, check.Name, check.Detail)
+	}
+
+	switch {
+	case !result.Passed:
+		fmt.Println("Result: transaction would be filtered out")
+		return exitNoMatch
+	case !result.Decoded:
+		fmt.Println("Result: transaction would be shown, but without decoded parameters")
+		return exitDecodeError
+	default:
+		fmt.Println("Result: transaction would be shown")
+		return exitOK
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Events queued per client before further events are dropped for that client
+const eventClientBuffer = 256
+
+// How often idle clients are sent a comment, so proxies don't close the connection
+const eventKeepAlive = 15 * time.Second
+
+// EventStream fans events out to any number of Server-Sent Events clients. Each client has its own
+// queue, so a slow browser misses events rather than holding up the publisher or other clients.
+type EventStream struct {
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+}
+
+// NewEventStream creates a stream without clients
+func NewEventStream() *EventStream {
+	return &EventStream{clients: make(map[chan []byte]struct{})}
+}
+
+// HasClients reports whether anyone is listening, so publishers can skip encoding events otherwise
+func (s *EventStream) HasClients() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients) > 0
+}
+
+// Publish sends an event with the given name and data, a single line such as a JSON document, to
+// every connected client
+func (s *EventStream) Publish(event string, data []byte) {
+	message := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		select {
+		case client <- message:
+		default:
+			logging.Debugf("Event stream client is falling behind, dropping a %s event", event)
+		}
+	}
+}
+
+// subscribe registers a client and returns its queue
+func (s *EventStream) subscribe() chan []byte {
+	client := make(chan []byte, eventClientBuffer)
+	s.mu.Lock()
+	s.clients[client] = struct{}{}
+	s.mu.Unlock()
+	return client
+}
+
+// unsubscribe removes a client
+func (s *EventStream) unsubscribe(client chan []byte) {
+	s.mu.Lock()
+	delete(s.clients, client)
+	s.mu.Unlock()
+}
+
+// Handler serves the stream as text/event-stream until the client disconnects or the context is
+// cancelled. Any origin may connect, so a web UI can be served from elsewhere.
+func (s *EventStream) Handler(ctx context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		client := s.subscribe()
+		defer s.unsubscribe(client)
+
+		keepAlive := time.NewTicker(eventKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case message := <-client:
+				if _, err := w.Write(message); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			case <-ctx.Done():
+				return
+			}
+			flusher.Flush()
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLogBufferSize is the number of recent log lines kept when no size is configured
+const DefaultLogBufferSize = 1000
+
+// LogBuffer keeps the most recent log lines in a circular buffer. It is an io.Writer so it can be
+// added alongside the TUI log pane, and is safe for concurrent use by many logging goroutines.
+type LogBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewLogBuffer creates a buffer holding up to size lines
+func NewLogBuffer(size int) *LogBuffer {
+	if size <= 0 {
+		size = DefaultLogBufferSize
+	}
+	return &LogBuffer{lines: make([]string, size)}
+}
+
+// Write records a log message, overwriting the oldest line once the buffer is full
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\n")
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines[b.next] = line
+	b.next++
+	if b.next == len(b.lines) {
+		b.next = 0
+		b.full = true
+	}
+	return len(p), nil
+}
+
+// Lines returns up to limit of the most recent lines, oldest first. A non-positive limit returns all of them.
+func (b *LogBuffer) Lines(limit int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Unroll the ring into chronological order
+	var ordered []string
+	if b.full {
+		ordered = append(ordered, b.lines[b.next:]...)
+	}
+	ordered = append(ordered, b.lines[:b.next]...)
+
+	if limit > 0 && limit < len(ordered) {
+		ordered = ordered[len(ordered)-limit:]
+	}
+	return ordered
+}
+
+// Handler serves the buffered lines as JSON; ?limit=N restricts the response to the N most recent lines
+func (b *LogBuffer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(b.Lines(limit))
+	})
+}
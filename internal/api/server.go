@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Serve runs the HTTP API on addr until the context is cancelled
+func Serve(ctx context.Context, addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Shut the server down once the context is cancelled
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logging.Infof("API listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.Errorf("API server failed: %v", err)
+	}
+}
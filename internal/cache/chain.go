@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Chain is the RPC client of one monitored chain along with the id its tokens are cached under. The
+// client is created by the caller from its endpoint configuration; the cache never dials on its own.
+type Chain struct {
+	ID     uint64
+	Client *rpc.Client // nil until the caller connects, e.g. when checking filters offline
+	Native string      // Symbol of the chain's native currency; empty means ETH
+}
+
+// DefaultNativeSymbol is the native currency of chains that don't configure their own
+const DefaultNativeSymbol = "ETH"
+
+// NativeSymbol returns the symbol native amounts on the chain are shown in, e.g. "ETH" or "POL"
+func (c *Chain) NativeSymbol() string {
+	if c.Native == "" {
+		return DefaultNativeSymbol
+	}
+	return c.Native
+}
+
+// DefaultChain serves lookups whose context carries no chain. It is set by the monitor to the first
+// configured chain.
+var DefaultChain = &Chain{}
+
+// errNoClient is returned by lookups on a chain whose RPC client hasn't been set up, so only
+// overrides and cached tokens can be served
+var errNoClient = errors.New("RPC client not initialized")
+
+// chainContextKey is the context key WithChain stores the chain under
+type chainContextKey struct{}
+
+// WithChain returns a copy of ctx whose token lookups and RPC calls go to chain
+func WithChain(ctx context.Context, chain *Chain) context.Context {
+	return context.WithValue(ctx, chainContextKey{}, chain)
+}
+
+// ChainFrom returns the chain set on ctx with WithChain, or DefaultChain when there is none
+func ChainFrom(ctx context.Context) *Chain {
+	if chain, ok := ctx.Value(chainContextKey{}).(*Chain); ok && chain != nil {
+		return chain
+	}
+	return DefaultChain
+}
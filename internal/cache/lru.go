@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// DefaultTokenCacheSize is the number of tokens kept in memory unless TOKEN_CACHE_MAX_ENTRIES says otherwise
+const DefaultTokenCacheSize = 10000
+
+// tokenLRU is a size-bounded token cache that evicts the least recently used token once full
+type tokenLRU struct {
+	mu        sync.Mutex
+	maxSize   int
+	order     *list.List               // Most recently used at the front
+	items     map[string]*list.Element // Keyed by tokenKey; elements hold a TokenInfo
+	evictions uint64
+}
+
+// newTokenLRU creates an empty cache holding at most maxSize tokens
+func newTokenLRU(maxSize int) *tokenLRU {
+	return &tokenLRU{
+		maxSize: maxSize,
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// Get looks up a token by its tokenKey and marks it as recently used
+func (c *tokenLRU) Get(key string) (TokenInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return TokenInfo{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(TokenInfo), true
+}
+
+// Set adds or replaces a token, evicting the least recently used ones if the cache is over its limit
+func (c *tokenLRU) Set(info TokenInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := tokenKey(info.ChainID, info.Address)
+	if elem, ok := c.items[key]; ok {
+		elem.Value = info
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(info)
+	c.evict()
+}
+
+// SetMaxSize changes the size limit, evicting tokens straight away if the cache is now over it
+func (c *tokenLRU) SetMaxSize(maxSize int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxSize = maxSize
+	c.evict()
+}
+
+// evict drops least recently used tokens until the cache fits. The caller holds c.mu.
+func (c *tokenLRU) evict() {
+	for c.maxSize > 0 && c.order.Len() > c.maxSize {
+		if c.evictions == 0 {
+			logging.Infof("Token cache reached %d entries, evicting least recently used tokens", c.maxSize)
+		}
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		info := oldest.Value.(TokenInfo)
+		delete(c.items, tokenKey(info.ChainID, info.Address))
+		c.evictions++
+	}
+}
+
+// Len returns the number of cached tokens
+func (c *tokenLRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Evictions returns how many tokens have been evicted to stay within the size limit
+func (c *tokenLRU) Evictions() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictions
+}
+
+// Snapshot copies the cached tokens into a map keyed by tokenKey
+func (c *tokenLRU) Snapshot() map[string]TokenInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens := make(map[string]TokenInfo, len(c.items))
+	for key, elem := range c.items {
+		tokens[key] = elem.Value.(TokenInfo)
+	}
+	return tokens
+}
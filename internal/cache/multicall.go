@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// MulticallAddress is the Multicall3 contract used to fetch the metadata of many tokens in one
+// eth_call. It is set by the monitor (MULTICALL3_ADDRESS); without it tokens are fetched one by one.
+var MulticallAddress *common.Address
+
+// Tokens per aggregate3 call, keeping each call well within node gas and response limits
+const multicallBatchSize = 100
+
+// multicall3ABI holds Multicall3's aggregate3
+var multicall3ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(`[{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`))
+	if err != nil {
+		log.Fatalf("Failed to parse Multicall3 ABI: %v", err)
+	}
+	return parsed
+}()
+
+// multicall3Call is an aggregate3 call; the field names match the ABI tuple's components
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicall3Result is the outcome of one call in an aggregate3
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// FetchTokenDetailsBatch retrieves the details of several tokens, using Multicall3 to fetch all
+// uncached tokens in a single eth_call when MulticallAddress is set, or FetchTokenDetails per token
+// otherwise. Tokens that could not be fetched are left out of the result and reported in the error.
+func FetchTokenDetailsBatch(ctx context.Context, addrs []common.Address) (map[common.Address]*TokenInfo, error) {
+	chain := ChainFrom(ctx)
+	tokens := make(map[common.Address]*TokenInfo, len(addrs))
+	var missing []common.Address
+	for _, addr := range addrs {
+		if _, seen := tokens[addr]; seen {
+			continue
+		}
+		if info, exists := overriddenToken(chain.ID, addr.Hex()); exists {
+			tokens[addr] = &info
+		} else if info, exists := cachedToken(chain.ID, addr.Hex()); exists {
+			tokens[addr] = &info
+		} else {
+			tokens[addr] = nil // Marks the address as seen; filled in or removed below
+			missing = append(missing, addr)
+		}
+	}
+
+	var errs []error
+	for start := 0; start < len(missing); start += multicallBatchSize {
+		chunk := missing[start:min(start+multicallBatchSize, len(missing))]
+		if MulticallAddress == nil {
+			for _, addr := range chunk {
+				info, err := FetchTokenDetails(ctx, addr)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				tokens[addr] = info
+			}
+			continue
+		}
+
+		if err := multicallTokenDetails(ctx, chain, chunk, tokens); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for addr, info := range tokens {
+		if info == nil {
+			delete(tokens, addr)
+		}
+	}
+	return tokens, errors.Join(errs...)
+}
+
+// multicallTokenDetails fetches name, symbol and decimals of each token through one aggregate3 call
+// and caches the tokens that answered all three
+func multicallTokenDetails(ctx context.Context, chain *Chain, chunk []common.Address, tokens map[common.Address]*TokenInfo) error {
+	if chain.Client == nil {
+		return fmt.Errorf("Multicall3 token fetch failed: %w", errNoClient)
+	}
+	getters := []string{"name", "symbol", "decimals"}
+	calls := make([]multicall3Call, 0, len(chunk)*len(getters))
+	for _, addr := range chunk {
+		for _, getter := range getters {
+			callData, _ := erc20ABI.Pack(getter)
+			calls = append(calls, multicall3Call{Target: addr, AllowFailure: true, CallData: callData})
+		}
+	}
+
+	input, err := multicall3ABI.Pack("aggregate3", calls)
+	if err != nil {
+		return fmt.Errorf("failed to encode Multicall3 call: %w", err)
+	}
+	var output hexutil.Bytes
+	err = chain.Client.CallContext(ctx, &output, "eth_call", map[string]interface{}{
+		"to":   MulticallAddress.Hex(),
+		"data": hexutil.Encode(input),
+	}, "latest")
+	if err != nil {
+		return fmt.Errorf("Multicall3 token fetch failed: %w", err)
+	}
+
+	values, err := multicall3ABI.Unpack("aggregate3", output)
+	if err != nil {
+		return fmt.Errorf("failed to decode Multicall3 result: %w", err)
+	}
+	results := *abi.ConvertType(values[0], new([]multicall3Result)).(*[]multicall3Result)
+	if len(results) != len(calls) {
+		return fmt.Errorf("Multicall3 returned %d results for %d calls", len(results), len(calls))
+	}
+
+	var failed []string
+	for i, addr := range chunk {
+		name, symbol, decimals := results[3*i], results[3*i+1], results[3*i+2]
+		info := TokenInfo{Address: addr.Hex(), ChainID: chain.ID}
+		if name.Success {
+			info.Name = DecodeHexStringIfNeeded(hexutil.Encode(name.ReturnData))
+		}
+		if symbol.Success {
+			info.Symbol = DecodeHexStringIfNeeded(hexutil.Encode(symbol.ReturnData))
+		}
+		if info.Name == "" || info.Symbol == "" || !decimals.Success {
+			failed = append(failed, addr.Hex())
+			continue
+		}
+		info.Decimals = parseDecimals(addr, hexutil.Encode(decimals.ReturnData))
+
+		storeToken(info)
+		tokens[addr] = &info
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to fetch token details for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
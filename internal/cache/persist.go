@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// tokenKey identifies a token in the cache and the cache file. Tokens fetched before chains were
+// configured are cached under chain id 0, which is also the id of a single chain without CHAIN_ID.
+func tokenKey(chainID uint64, address string) string {
+	return strconv.FormatUint(chainID, 10) + ":" + address
+}
+
+// cachedToken looks up a token of a chain in the cache. Entries older than TokenTTL count as missing so the
+// caller fetches them again, replacing the stale entry.
+func cachedToken(chainID uint64, address string) (TokenInfo, bool) {
+	info, exists := TokenCache.Get(tokenKey(chainID, address))
+	if exists && TokenTTL > 0 && time.Since(info.FetchedAt) > TokenTTL {
+		return TokenInfo{}, false
+	}
+	return info, exists
+}
+
+// storeToken adds a token to the cache, stamping it with the current time unless it already has a
+// fetch time. Entries from cache files written before timestamps existed start their TTL on load.
+func storeToken(info TokenInfo) {
+	if info.FetchedAt.IsZero() {
+		info.FetchedAt = time.Now()
+	}
+	TokenCache.Set(info)
+}
+
+// LoadTokenCache fills the token cache from a JSON file written by SaveTokenCache.
+// A missing file is not an error since the cache simply starts empty.
+func LoadTokenCache(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read token cache: %w", err)
+	}
+
+	var tokens map[string]TokenInfo
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return fmt.Errorf("failed to parse token cache: %w", err)
+	}
+
+	for key, info := range tokens {
+		// Files written before tokens were cached per chain are keyed by the bare address
+		addr := key
+		if _, after, found := strings.Cut(key, ":"); found {
+			addr = after
+		}
+		if !common.IsHexAddress(addr) {
+			continue
+		}
+		info.Address = common.HexToAddress(addr).Hex()
+		storeToken(info)
+	}
+
+	logging.Infof("Loaded %d cached tokens from %s", TokenCache.Len(), path)
+	return nil
+}
+
+// SaveTokenCache writes the token cache to a JSON file. The file is written to a temporary file and
+// renamed into place so a crash mid-save never leaves a truncated cache behind.
+func SaveTokenCache(path string) error {
+	data, err := json.MarshalIndent(TokenCache.Snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode token cache: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create token cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace token cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// NativeToken keys the price of the chain's native currency in the price feeds file and oracle URL
+const NativeToken = "ETH"
+
+// DefaultPriceTTL is how long a token's USD price is reused before it is looked up again
+const DefaultPriceTTL = time.Minute
+
+// Selectors of the Chainlink aggregator getters
+const (
+	latestRoundDataSelector = "0xfeaf968c" // latestRoundData()
+	feedDecimalsSelector    = "0x313ce567" // decimals()
+)
+
+// USD price sources. A token's Chainlink feed from the price feeds file is preferred, then
+// PriceOracleURL; tokens with neither aren't priced.
+var (
+	// PriceOracleURL is queried for prices with {address} replaced by the token address, or by
+	// NativeToken for the native currency. It must answer with a bare number or {"usd": n}.
+	PriceOracleURL string
+	PriceTTL       = DefaultPriceTTL
+
+	priceFeeds  = make(map[string]common.Address) // Token address or NativeToken -> aggregator
+	priceClient = &http.Client{Timeout: 5 * time.Second}
+	pricesMu    sync.Mutex
+	pricesCache = make(map[string]cachedPrice)
+)
+
+// cachedPrice is a looked up price; failed lookups are cached too so they aren't retried for every amount
+type cachedPrice struct {
+	usd       float64
+	ok        bool
+	fetchedAt time.Time
+}
+
+// LoadPriceFeeds loads a JSON file mapping token addresses, or NativeToken, to the address of the
+// Chainlink USD feed to price them with. A missing file is not an error since prices are optional.
+func LoadPriceFeeds(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read price feeds file: %w", err)
+	}
+
+	var feeds map[string]string
+	if err := json.Unmarshal(data, &feeds); err != nil {
+		return fmt.Errorf("failed to parse price feeds: %w", err)
+	}
+	for token, feed := range feeds {
+		if !common.IsHexAddress(feed) {
+			return fmt.Errorf("invalid feed address for %s in price feeds: %s", token, feed)
+		}
+		switch {
+		case strings.EqualFold(token, NativeToken):
+			priceFeeds[NativeToken] = common.HexToAddress(feed)
+		case common.IsHexAddress(token):
+			priceFeeds[common.HexToAddress(token).Hex()] = common.HexToAddress(feed)
+		default:
+			return fmt.Errorf("invalid token address in price feeds: %s", token)
+		}
+	}
+
+	logging.Infof("Loaded %d price feeds from %s", len(feeds), filename)
+	return nil
+}
+
+// PricingEnabled reports whether any price source is configured
+func PricingEnabled() bool {
+	return PriceOracleURL != "" || len(priceFeeds) > 0
+}
+
+// FetchTokenPrice returns the USD price of a token, given as an address or NativeToken, on the chain
+// carried by ctx. Prices are reused for PriceTTL. It returns false when the token can't be priced.
+func FetchTokenPrice(ctx context.Context, token string) (float64, bool) {
+	if !PricingEnabled() {
+		return 0, false
+	}
+	if token != NativeToken {
+		token = common.HexToAddress(token).Hex()
+	}
+
+	chain := ChainFrom(ctx)
+	key := tokenKey(chain.ID, token)
+	pricesMu.Lock()
+	cached, exists := pricesCache[key]
+	pricesMu.Unlock()
+	if exists && time.Since(cached.fetchedAt) < PriceTTL {
+		return cached.usd, cached.ok
+	}
+
+	usd, err := fetchPrice(ctx, chain, token)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, false // Shutting down; don't cache the failure
+		}
+		logging.Debugf("No USD price for %s: %v", token, err)
+	}
+	pricesMu.Lock()
+	pricesCache[key] = cachedPrice{usd: usd, ok: err == nil, fetchedAt: time.Now()}
+	pricesMu.Unlock()
+	return usd, err == nil
+}
+
+// fetchPrice looks a price up from the token's feed, or the oracle when it has none
+func fetchPrice(ctx context.Context, chain *Chain, token string) (float64, error) {
+	if feed, ok := priceFeeds[token]; ok {
+		return fetchFeedPrice(ctx, chain, feed)
+	}
+	if PriceOracleURL != "" {
+		return fetchOraclePrice(ctx, token)
+	}
+	return 0, errors.New("no price source for the token")
+}
+
+// fetchFeedPrice reads the latest answer of a Chainlink aggregator, scaled by its decimals
+func fetchFeedPrice(ctx context.Context, chain *Chain, feed common.Address) (float64, error) {
+	if chain.Client == nil {
+		return 0, errNoClient
+	}
+	var roundData, decimals hexutil.Bytes
+	call := func(selector string, result *hexutil.Bytes) rpc.BatchElem {
+		return rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{map[string]interface{}{"to": feed.Hex(), "data": selector}, "latest"},
+			Result: result,
+		}
+	}
+	batch := []rpc.BatchElem{call(latestRoundDataSelector, &roundData), call(feedDecimalsSelector, &decimals)}
+	if err := chain.Client.BatchCallContext(ctx, batch); err != nil {
+		return 0, fmt.Errorf("failed to call feed %s: %w", feed.Hex(), err)
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return 0, fmt.Errorf("failed to call feed %s: %w", feed.Hex(), elem.Error)
+		}
+	}
+	if len(roundData) < 64 || len(decimals) < 32 {
+		return 0, fmt.Errorf("no Chainlink feed at %s", feed.Hex())
+	}
+
+	// The answer is the second word of latestRoundData; prices are never negative
+	answer := new(big.Int).SetBytes(roundData[32:64])
+	if answer.Bit(255) == 1 || answer.Sign() == 0 {
+		return 0, fmt.Errorf("feed %s has no valid answer", feed.Hex())
+	}
+	scale := new(big.Float).SetFloat64(math.Pow10(int(new(big.Int).SetBytes(decimals[:32]).Uint64())))
+	usd, _ := new(big.Float).Quo(new(big.Float).SetInt(answer), scale).Float64()
+	return usd, nil
+}
+
+// fetchOraclePrice asks PriceOracleURL for a token's price
+func fetchOraclePrice(ctx context.Context, token string) (float64, error) {
+	url := strings.ReplaceAll(PriceOracleURL, "{address}", token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create price request: %w", err)
+	}
+	resp, err := priceClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("price request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("price oracle returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read price: %w", err)
+	}
+
+	// Accept a bare number or an object with a usd field
+	if usd, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64); err == nil {
+		return usd, nil
+	}
+	var quote struct {
+		USD *float64 `json:"usd"`
+	}
+	if err := json.Unmarshal(body, &quote); err != nil || quote.USD == nil {
+		return 0, fmt.Errorf("unexpected price response: %.40s", body)
+	}
+	return *quote.USD, nil
+}
@@ -1,13 +1,21 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -20,112 +28,262 @@ type TokenInfo struct {
 	Symbol   string
 	Name     string
 	Decimals uint8
+	// ChainID is the chain the token was fetched from; tokens are cached per chain
+	ChainID uint64 `json:",omitempty"`
+	// FetchedAt is when the details were read on-chain, used to expire entries after TokenTTL
+	FetchedAt time.Time
+}
+
+// TokenTTL is how long cached token details are trusted before being fetched again. Zero keeps them forever.
+var TokenTTL time.Duration
+
+// Known tokens keyed by chain id and token address (see tokenKey), bounded to the most recently used DefaultTokenCacheSize entries
+var TokenCache = newTokenLRU(DefaultTokenCacheSize)
+
+// Token metadata overrides loaded from config, keyed by chain id and token address (see tokenKey).
+// These take precedence over both the cache and on-chain data of their chain.
+var tokenOverrides = make(map[string]TokenInfo)
+
+// tokenOverride is the JSON shape of a single entry in the token overrides file
+type tokenOverride struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Decimals *uint8 `json:"decimals"` // Required, since 0 is a valid but rare value
 }
 
-// A map to store known tokens, with the token address as the key
-var TokenCache = make(map[string]TokenInfo)
+// stringArguments unpacks a single ABI-encoded string return value
+var stringArguments = func() abi.Arguments {
+	stringType, _ := abi.NewType("string", "", nil)
+	return abi.Arguments{{Type: stringType}}
+}()
+
+// DecodeHexStringIfNeeded decodes the hex return data of a name() or symbol() call. Most tokens return
+// an ABI-encoded string; older ones such as MKR return a bytes32 padded with zero bytes instead.
+// Anything else is returned as is.
+func DecodeHexStringIfNeeded(str string) string {
+	if !strings.HasPrefix(str, "0x") {
+		return str
+	}
+	data, err := hex.DecodeString(str[2:])
+	if err != nil {
+		return str
+	}
 
-// Global RPC client
-var RpcClient *rpc.Client
+	// Try the standard string return first
+	if values, err := stringArguments.Unpack(data); err == nil {
+		if s, ok := values[0].(string); ok {
+			return s
+		}
+	}
 
-// InitializeRPCClient initializes the RPC client using the provided HTTPS endpoint
-func InitializeRPCClient() error {
-	httpsEndpoint := os.Getenv("HTTPS_ENDPOINT")
-	if httpsEndpoint == "" {
-		return fmt.Errorf("HTTPS_ENDPOINT is not set in environment variables")
+	// Fall back to a bytes32, cut at the first zero byte. Contracts without the function return nothing.
+	if len(data) == 0 || len(data) == 32 {
+		if end := bytes.IndexByte(data, 0); end >= 0 {
+			data = data[:end]
+		}
+		return string(data)
 	}
+	return str
+}
+
+// Decimals assumed for tokens that don't report a usable value, as most ERC-20 tokens use 18
+const defaultTokenDecimals = 18
 
-	var err error
-	RpcClient, err = rpc.Dial(httpsEndpoint)
+// parseDecimals parses the return data of a decimals() call. Nodes usually return 0x-prefixed hex
+// but some omit the prefix. An empty, zero or out-of-range value falls back to 18 with a warning.
+func parseDecimals(tokenAddress common.Address, decimalsHex string) uint8 {
+	digits := strings.TrimPrefix(strings.TrimPrefix(decimalsHex, "0x"), "0X")
+	decimals, ok := new(big.Int).SetString(digits, 16)
+	if !ok || decimals.Sign() == 0 || decimals.Cmp(big.NewInt(255)) > 0 {
+		logging.Warnf("Token %s returned unusable decimals %q, assuming %d", tokenAddress.Hex(), decimalsHex, defaultTokenDecimals)
+		return defaultTokenDecimals
+	}
+	return uint8(decimals.Uint64())
+}
+
+// LoadTokenOverrides loads token metadata overrides from a JSON file mapping token addresses to
+// {symbol, name, decimals}. Those apply to DefaultChain, so it must be set first; with several chains
+// the tokens of each can instead be grouped under its chain id. A missing file is not an error since
+// overrides are optional.
+func LoadTokenOverrides(filename string) error {
+	data, err := os.ReadFile(filename)
 	if err != nil {
-		return err
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read token overrides file: %w", err)
 	}
+
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse token overrides: %w", err)
+	}
+
+	count := 0
+	for key, entry := range entries {
+		// Token addresses at the top level belong to the default chain
+		if strings.HasPrefix(key, "0x") {
+			if err := addTokenOverride(DefaultChain.ID, key, entry); err != nil {
+				return err
+			}
+			count++
+			continue
+		}
+
+		chainID, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid chain id or token address %q in token overrides", key)
+		}
+		var overrides map[string]json.RawMessage
+		if err := json.Unmarshal(entry, &overrides); err != nil {
+			return fmt.Errorf("failed to parse token overrides of chain %d, expected token addresses: %w", chainID, err)
+		}
+		for addr, override := range overrides {
+			if err := addTokenOverride(chainID, addr, override); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+
+	logging.Infof("Loaded %d token overrides from %s", count, filename)
 	return nil
 }
 
-// DecodeHexStringIfNeeded checks if a string is hex-encoded and decodes it to ASCII if necessary
-func DecodeHexStringIfNeeded(str string) string {
-	// If the string starts with "0x", it may be hex-encoded
-	if strings.HasPrefix(str, "0x") {
-		decodedBytes, err := hex.DecodeString(str[2:]) // Remove "0x" prefix and decode
-		if err == nil {
-			return string(decodedBytes)
+// addTokenOverride parses the override of a token on a chain and adds it to tokenOverrides
+func addTokenOverride(chainID uint64, addr string, entry json.RawMessage) error {
+	if !common.IsHexAddress(addr) {
+		return fmt.Errorf("invalid token address in overrides: %s", addr)
+	}
+	var override tokenOverride
+	if err := json.Unmarshal(entry, &override); err != nil {
+		return fmt.Errorf("failed to parse token override for %s: %w", addr, err)
+	}
+	if override.Decimals == nil {
+		return fmt.Errorf("token override for %s on chain %d has no decimals", addr, chainID)
+	}
+	token := common.HexToAddress(addr)
+	tokenOverrides[tokenKey(chainID, token.Hex())] = TokenInfo{
+		Address:  token.Hex(),
+		Symbol:   override.Symbol,
+		Name:     override.Name,
+		Decimals: *override.Decimals,
+		ChainID:  chainID,
+	}
+	return nil
+}
+
+// OverrideChainIDs returns the ids of the chains that have token overrides
+func OverrideChainIDs() []uint64 {
+	seen := make(map[uint64]bool)
+	var ids []uint64
+	for _, info := range tokenOverrides {
+		if !seen[info.ChainID] {
+			seen[info.ChainID] = true
+			ids = append(ids, info.ChainID)
 		}
 	}
-	return str // Return as is if it's not hex or decoding fails
+	slices.Sort(ids)
+	return ids
+}
+
+// overriddenToken looks up the override of a token on a chain
+func overriddenToken(chainID uint64, address string) (TokenInfo, bool) {
+	info, exists := tokenOverrides[tokenKey(chainID, address)]
+	return info, exists
+}
+
+// erc20ABI holds the ERC-20 metadata getters
+var erc20ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(`[{"constant":true,"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"payable":false,"stateMutability":"view","type":"function"}]`))
+	if err != nil {
+		log.Fatalf("Failed to parse ERC-20 ABI: %v", err)
+	}
+	return parsed
+}()
+
+// erc20Call builds a batch element calling one of the ERC-20 metadata getters on a token
+func erc20Call(token common.Address, method string, result *string) rpc.BatchElem {
+	callData, _ := erc20ABI.Pack(method)
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args: []interface{}{map[string]interface{}{
+			"to":   token.Hex(),
+			"data": "0x" + hex.EncodeToString(callData),
+		}, "latest"},
+		Result: result,
+	}
 }
 
-// FetchTokenDetails retrieves the name, symbol, and decimals for a given token address
-func FetchTokenDetails(tokenAddress common.Address) (*TokenInfo, error) {
+// FetchTokenDetails retrieves the name, symbol, and decimals for a given token address on the chain
+// carried by ctx (see WithChain). The calls are abandoned when ctx is cancelled.
+func FetchTokenDetails(ctx context.Context, tokenAddress common.Address) (*TokenInfo, error) {
+	chain := ChainFrom(ctx)
+
+	// Overrides from config win over anything cached or fetched on-chain
+	if info, exists := overriddenToken(chain.ID, tokenAddress.Hex()); exists {
+		return &info, nil
+	}
+
 	// Check if the token details are already cached
-	if info, exists := TokenCache[tokenAddress.Hex()]; exists {
+	if info, exists := cachedToken(chain.ID, tokenAddress.Hex()); exists {
 		return &info, nil
 	}
 
-	// Define the ERC-20 ABI
-	erc20ABI, err := abi.JSON(strings.NewReader(`[{"constant":true,"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"payable":false,"stateMutability":"view","type":"function"}]`))
-	if err != nil {
-		log.Printf("Failed to parse ERC-20 ABI: %v", err)
-		return nil, err
+	// Without a client, such as before monitoring starts, only overrides and cached tokens are known
+	if chain.Client == nil {
+		return nil, fmt.Errorf("failed to fetch token details: %w", errNoClient)
 	}
 
-	// Define the token instance
+	// Call name, symbol and decimals in a single JSON-RPC batch to save two round-trips
 	token := common.HexToAddress(tokenAddress.String())
+	var name, symbol, decimalsHex string
+	batch := []rpc.BatchElem{
+		erc20Call(token, "name", &name),
+		erc20Call(token, "symbol", &symbol),
+		erc20Call(token, "decimals", &decimalsHex),
+	}
+	if err := chain.Client.BatchCallContext(ctx, batch); err != nil {
+		logging.Warnf("Failed to fetch details for token %s: %v", tokenAddress.Hex(), err)
+		return nil, fmt.Errorf("failed to fetch token details: %v", err)
+	}
 
-	// Call the token's name function
-	nameCallData, _ := erc20ABI.Pack("name")
-	var name string
-	err = RpcClient.CallContext(context.Background(), &name, "eth_call", map[string]interface{}{
-		"to":   token.Hex(),
-		"data": "0x" + hex.EncodeToString(nameCallData),
-	}, "latest")
-	if err != nil || name == "" {
-		log.Printf("Failed to fetch name for token %s: %v", tokenAddress.Hex(), err)
+	// Check the name, decoding it if necessary
+	if err := batch[0].Error; err != nil || name == "" {
+		logging.Warnf("Failed to fetch name for token %s: %v", tokenAddress.Hex(), err)
 		return nil, fmt.Errorf("failed to fetch token name: %v", err)
 	}
-
-	// Decode the name if necessary
 	name = DecodeHexStringIfNeeded(name)
+	if name == "" {
+		return nil, fmt.Errorf("token %s returned an empty name", tokenAddress.Hex())
+	}
 
-	// Call the token's symbol function
-	symbolCallData, _ := erc20ABI.Pack("symbol")
-	var symbol string
-	err = RpcClient.CallContext(context.Background(), &symbol, "eth_call", map[string]interface{}{
-		"to":   token.Hex(),
-		"data": "0x" + hex.EncodeToString(symbolCallData),
-	}, "latest")
-	if err != nil || symbol == "" {
-		log.Printf("Failed to fetch symbol for token %s: %v", tokenAddress.Hex(), err)
+	// Check the symbol, decoding it if necessary
+	if err := batch[1].Error; err != nil || symbol == "" {
+		logging.Warnf("Failed to fetch symbol for token %s: %v", tokenAddress.Hex(), err)
 		return nil, fmt.Errorf("failed to fetch token symbol: %v", err)
 	}
-
-	// Decode the symbol if necessary
 	symbol = DecodeHexStringIfNeeded(symbol)
-
-	// Call the token's decimals function
-	decimalsCallData, _ := erc20ABI.Pack("decimals")
-	var decimalsHex string
-	err = RpcClient.CallContext(context.Background(), &decimalsHex, "eth_call", map[string]interface{}{
-		"to":   token.Hex(),
-		"data": "0x" + hex.EncodeToString(decimalsCallData),
-	}, "latest")
-	if err != nil || decimalsHex == "" {
-		log.Printf("Failed to fetch decimals for token %s: %v", tokenAddress.Hex(), err)
-		return nil, fmt.Errorf("failed to fetch token decimals: %v", err)
+	if symbol == "" {
+		return nil, fmt.Errorf("token %s returned an empty symbol", tokenAddress.Hex())
 	}
 
 	// Convert the decimals from hex to uint8
-	decimals := new(big.Int)
-	decimals.SetString(decimalsHex[2:], 16)
+	if err := batch[2].Error; err != nil {
+		logging.Warnf("Failed to fetch decimals for token %s: %v", tokenAddress.Hex(), err)
+		return nil, fmt.Errorf("failed to fetch token decimals: %v", err)
+	}
+	decimals := parseDecimals(tokenAddress, decimalsHex)
 
 	// Store the fetched token details in cache
 	tokenInfo := TokenInfo{
 		Address:  token.Hex(),
 		Name:     name,
 		Symbol:   symbol,
-		Decimals: uint8(decimals.Uint64()),
+		Decimals: decimals,
+		ChainID:  chain.ID,
 	}
-	TokenCache[token.Hex()] = tokenInfo
+	storeToken(tokenInfo)
 
 	return &tokenInfo, nil
 }
@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var mkr = common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+
+// writeOverrides writes a token overrides file and resets the loaded overrides once the test ends
+func writeOverrides(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token_overrides.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tokenOverrides = make(map[string]TokenInfo) })
+	return path
+}
+
+func TestTokenOverridePrecedence(t *testing.T) {
+	path := writeOverrides(t, `{"1": {"0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2": {"symbol": "MKR", "name": "Maker", "decimals": 18}}}`)
+	if err := LoadTokenOverrides(path); err != nil {
+		t.Fatalf("LoadTokenOverrides: %v", err)
+	}
+
+	// A stale cached entry on the same chain, and the same address on another chain
+	TokenCache = newTokenLRU(DefaultTokenCacheSize)
+	t.Cleanup(func() { TokenCache = newTokenLRU(DefaultTokenCacheSize) })
+	storeToken(TokenInfo{Address: mkr.Hex(), Symbol: "0x4d4b52", Name: "bytes32", Decimals: 18, ChainID: 1})
+	storeToken(TokenInfo{Address: mkr.Hex(), Symbol: "OTHER", Name: "Other", Decimals: 6, ChainID: 10})
+
+	tests := []struct {
+		name    string
+		chainID uint64
+		symbol  string
+	}{
+		{"override wins over the cache", 1, "MKR"},
+		{"override doesn't apply on other chains", 10, "OTHER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithChain(context.Background(), &Chain{ID: tt.chainID})
+			info, err := FetchTokenDetails(ctx, mkr)
+			if err != nil {
+				t.Fatalf("FetchTokenDetails: %v", err)
+			}
+			if info.Symbol != tt.symbol {
+				t.Errorf("symbol = %q, want %q", info.Symbol, tt.symbol)
+			}
+		})
+	}
+
+	// The batch lookup honours the same precedence
+	tokens, err := FetchTokenDetailsBatch(WithChain(context.Background(), &Chain{ID: 1}), []common.Address{mkr})
+	if err != nil {
+		t.Fatalf("FetchTokenDetailsBatch: %v", err)
+	}
+	if got := tokens[mkr].Symbol; got != "MKR" {
+		t.Errorf("batch symbol = %q, want MKR", got)
+	}
+}
+
+func TestLoadTokenOverridesRejectsInvalidEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"missing decimals", `{"1": {"0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2": {"symbol": "MKR", "name": "Maker"}}}`},
+		{"invalid address", `{"1": {"0x1234": {"symbol": "MKR", "name": "Maker", "decimals": 18}}}`},
+		{"invalid chain id", `{"mainnet": {"0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2": {"symbol": "MKR", "name": "Maker", "decimals": 18}}}`},
+		{"missing decimals by address", `{"0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2": {"symbol": "MKR", "name": "Maker"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := LoadTokenOverrides(writeOverrides(t, tt.contents)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// Overrides listed by address apply to the default chain, whatever its id, and to no other chain
+func TestTokenOverridesByAddress(t *testing.T) {
+	DefaultChain = &Chain{ID: 8453}
+	t.Cleanup(func() { DefaultChain = &Chain{} })
+	path := writeOverrides(t, `{"0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2": {"symbol": "MKR", "name": "Maker", "decimals": 18}}`)
+	if err := LoadTokenOverrides(path); err != nil {
+		t.Fatalf("LoadTokenOverrides: %v", err)
+	}
+	TokenCache = newTokenLRU(DefaultTokenCacheSize)
+	t.Cleanup(func() { TokenCache = newTokenLRU(DefaultTokenCacheSize) })
+
+	info, err := FetchTokenDetails(context.Background(), mkr)
+	if err != nil {
+		t.Fatalf("FetchTokenDetails: %v", err)
+	}
+	if info.Symbol != "MKR" || info.ChainID != 8453 {
+		t.Errorf("token = %s on chain %d, want MKR on chain 8453", info.Symbol, info.ChainID)
+	}
+	if _, err := FetchTokenDetails(WithChain(context.Background(), &Chain{ID: 1}), mkr); !errors.Is(err, errNoClient) {
+		t.Errorf("FetchTokenDetails on another chain = %v, want %v", err, errNoClient)
+	}
+	if ids := OverrideChainIDs(); len(ids) != 1 || ids[0] != 8453 {
+		t.Errorf("OverrideChainIDs = %v, want [8453]", ids)
+	}
+}
+
+func TestLoadTokenOverridesMissingFile(t *testing.T) {
+	if err := LoadTokenOverrides(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("missing file: %v", err)
+	}
+}
+
+func TestDecodeHexStringIfNeeded(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			// MKR returns its symbol and name as bytes32
+			name:  "bytes32 symbol",
+			input: "0x4d4b520000000000000000000000000000000000000000000000000000000000",
+			want:  "MKR",
+		},
+		{
+			name:  "bytes32 name",
+			input: "0x4d616b6572000000000000000000000000000000000000000000000000000000",
+			want:  "Maker",
+		},
+		{
+			// USDC returns an ABI-encoded string like most tokens
+			name: "string symbol",
+			input: "0x0000000000000000000000000000000000000000000000000000000000000020" +
+				"0000000000000000000000000000000000000000000000000000000000000004" +
+				"5553444300000000000000000000000000000000000000000000000000000000",
+			want: "USDC",
+		},
+		{name: "no return data", input: "0x", want: ""},
+		{name: "not hex", input: "0xzz", want: "0xzz"},
+		{name: "plain text", input: "WETH", want: "WETH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeHexStringIfNeeded(tt.input); got != tt.want {
+				t.Errorf("DecodeHexStringIfNeeded(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDecimals(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	tests := []struct {
+		name  string
+		input string
+		want  uint8
+	}{
+		{name: "USDC", input: "0x0000000000000000000000000000000000000000000000000000000000000006", want: 6},
+		{name: "18 decimals", input: "0x0000000000000000000000000000000000000000000000000000000000000012", want: 18},
+		{name: "short", input: "0x6", want: 6},
+		{name: "without prefix", input: "12", want: 18},
+		{name: "upper-case prefix", input: "0X8", want: 8},
+		{name: "empty", input: "0x", want: defaultTokenDecimals},
+		{name: "zero", input: "0x0", want: defaultTokenDecimals},
+		{name: "out of range", input: "0x100", want: defaultTokenDecimals},
+		{name: "not hex", input: "0xzz", want: defaultTokenDecimals},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDecimals(usdc, tt.input); got != tt.want {
+				t.Errorf("parseDecimals(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// Before monitoring sets up the RPC clients only overrides and cached tokens resolve; other lookups
+// fail with errNoClient rather than panicking on the nil client
+func TestFetchTokenDetailsWithoutClient(t *testing.T) {
+	path := writeOverrides(t, `{"1": {"0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2": {"symbol": "MKR", "name": "Maker", "decimals": 18}}}`)
+	if err := LoadTokenOverrides(path); err != nil {
+		t.Fatalf("LoadTokenOverrides: %v", err)
+	}
+	TokenCache = newTokenLRU(DefaultTokenCacheSize)
+	t.Cleanup(func() { TokenCache = newTokenLRU(DefaultTokenCacheSize) })
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	dai := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	storeToken(TokenInfo{Address: usdc.Hex(), Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: 1})
+
+	ctx := WithChain(context.Background(), &Chain{ID: 1})
+	for _, token := range []common.Address{mkr, usdc} {
+		if _, err := FetchTokenDetails(ctx, token); err != nil {
+			t.Errorf("FetchTokenDetails(%s): %v", token.Hex(), err)
+		}
+	}
+	if _, err := FetchTokenDetails(ctx, dai); !errors.Is(err, errNoClient) {
+		t.Errorf("FetchTokenDetails of an unknown token = %v, want %v", err, errNoClient)
+	}
+	if _, err := FetchTokenDetailsBatch(ctx, []common.Address{dai}); !errors.Is(err, errNoClient) {
+		t.Errorf("FetchTokenDetailsBatch of an unknown token = %v, want %v", err, errNoClient)
+	}
+}
@@ -0,0 +1,126 @@
+package decoder
+
+import (
+	"context"
+	"eth-mempool-monitor/internal/cache"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// nativeDecimals is the number of decimals of ETH amounts
+const nativeDecimals = 18
+
+// amountToken is the token an amount parameter is denominated in
+type amountToken struct {
+	address common.Address
+	native  bool // The amount is in ETH rather than a token
+}
+
+// annotateAmounts pairs the amount parameters of common swap and liquidity signatures with the token
+// they are denominated in, following the Uniswap V2 router naming:
+//   - with a path, amountIn* is in path[0] and amountOut* in the last token of the path
+//   - with tokenA/tokenB, amountA* and amountB* are in those tokens
+//   - with token, amountToken* is in that token and amountETH* in ETH
+//
+// Amounts that can't be paired unambiguously are left unannotated and shown raw.
+func annotateAmounts(params []DecodedParam) {
+	addresses := make(map[string]common.Address)
+	var path []common.Address
+	for _, param := range params {
+		switch v := param.Value.(type) {
+		case common.Address:
+			addresses[param.Name] = v
+		case []common.Address:
+			if param.Name == "path" {
+				path = v
+			}
+		}
+	}
+
+	for i := range params {
+		if _, ok := params[i].Value.(*big.Int); !ok {
+			continue
+		}
+		if token, ok := pairAmount(params[i].Name, path, addresses); ok {
+			params[i].amountToken = &token
+		}
+	}
+}
+
+// pairAmount finds the token an amount parameter is denominated in from its name
+func pairAmount(name string, path []common.Address, addresses map[string]common.Address) (amountToken, bool) {
+	switch {
+	case len(path) >= 2 && strings.HasPrefix(name, "amountIn"):
+		return amountToken{address: path[0]}, true
+	case len(path) >= 2 && strings.HasPrefix(name, "amountOut"):
+		return amountToken{address: path[len(path)-1]}, true
+	case strings.HasPrefix(name, "amountA"):
+		token, ok := addresses["tokenA"]
+		return amountToken{address: token}, ok
+	case strings.HasPrefix(name, "amountB"):
+		token, ok := addresses["tokenB"]
+		return amountToken{address: token}, ok
+	case strings.HasPrefix(name, "amountToken"):
+		token, ok := addresses["token"]
+		return amountToken{address: token}, ok
+	case strings.HasPrefix(name, "amountETH"):
+		_, ok := addresses["token"] // Only the router's ETH liquidity methods name amounts this way
+		return amountToken{native: true}, ok
+	}
+	return amountToken{}, false
+}
+
+// formatAmount renders an amount scaled by its token's decimals, e.g. "1.5 USDC", or false when the
+// token details can't be fetched or aren't resolved. With a price source configured the amount's USD
+// value is added when the token can be priced, e.g. "1.5 USDC ≈ $1.50".
+func formatAmount(ctx context.Context, amount *big.Int, token amountToken) (string, bool) {
+	if token.native {
+		return FormatUnits(amount, nativeDecimals) + " " + cache.ChainFrom(ctx).NativeSymbol() + formatUSD(ctx, amount, nativeDecimals, cache.NativeToken), true
+	}
+	if !ResolveTokens {
+		return "", false
+	}
+
+	tokenInfo, err := cache.FetchTokenDetails(ctx, token.address)
+	if err != nil {
+		return "", false
+	}
+	decimals := int(tokenInfo.Decimals)
+	return FormatUnits(amount, decimals) + " " + tokenInfo.Symbol + formatUSD(ctx, amount, decimals, token.address.Hex()), true
+}
+
+// formatUSD renders the approximate USD value of an amount, or "" when the token has no price
+func formatUSD(ctx context.Context, amount *big.Int, decimals int, token string) string {
+	price, ok := cache.FetchTokenPrice(ctx, token)
+	if !ok {
+		return ""
+	}
+	units, _ := strconv.ParseFloat(FormatUnits(amount, decimals), 64)
+	usd := units * price
+	if usd > 0 && usd < 0.01 {
+		return " ≈ <$0.01"
+	}
+	return fmt.Sprintf(" ≈ $%.2f", usd)
+}
+
+// FormatUnits renders an amount scaled down by the given number of decimals, without trailing zeros,
+// e.g. 1500000 with 6 decimals as "1.5". It is the one place amounts are scaled, for wei as well as tokens.
+func FormatUnits(amount *big.Int, decimals int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(new(big.Int).Abs(amount), scale, new(big.Int))
+
+	out := whole.String()
+	if frac.Sign() != 0 {
+		fracStr := frac.String()
+		fracStr = strings.Repeat("0", decimals-len(fracStr)) + fracStr
+		out += "." + strings.TrimRight(fracStr, "0")
+	}
+	if amount.Sign() < 0 {
+		out = "-" + out
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package decoder
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"eth-mempool-monitor/internal/cache"
+)
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     string
+	}{
+		{amount: "1500000", decimals: 6, want: "1.5"},
+		{amount: "1000000000000000000", decimals: 18, want: "1"},
+		{amount: "1", decimals: 18, want: "0.000000000000000001"},
+		{amount: "25300000000", decimals: 9, want: "25.3"},
+		{amount: "0", decimals: 18, want: "0"},
+		{amount: "-2500000", decimals: 6, want: "-2.5"},
+		{amount: "42", decimals: 0, want: "42"},
+	}
+	for _, tt := range tests {
+		amount, _ := new(big.Int).SetString(tt.amount, 10)
+		if got := FormatUnits(amount, tt.decimals); got != tt.want {
+			t.Errorf("FormatUnits(%s, %d) = %q, want %q", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+// Native amounts are shown in the chain's own currency
+func TestFormatNativeAmount(t *testing.T) {
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+	tests := []struct {
+		chain *cache.Chain
+		want  string
+	}{
+		{chain: &cache.Chain{ID: 1}, want: "1.5 ETH"},
+		{chain: &cache.Chain{ID: 137, Native: "POL"}, want: "1.5 POL"},
+	}
+	for _, tt := range tests {
+		got, ok := formatAmount(cache.WithChain(context.Background(), tt.chain), amount, amountToken{native: true})
+		if !ok || got != tt.want {
+			t.Errorf("chain %d: formatAmount = %q, %t, want %q", tt.chain.ID, got, ok, tt.want)
+		}
+	}
+}
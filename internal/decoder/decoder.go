@@ -1,15 +1,19 @@
 package decoder
 
 import (
+	"context"
 	"encoding/hex"
+	"encoding/json"
 	"eth-mempool-monitor/internal/cache"
 	"fmt"
-	"log"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // Define the struct type for the transaction result
@@ -20,85 +24,309 @@ type TransactionResult struct {
 		From             string `json:"from"`
 		Gas              string `json:"gas"`
 		GasPrice         string `json:"gasPrice"`
+		MaxFeePerGas     string `json:"maxFeePerGas"`         // EIP-1559 transactions only
+		MaxPriorityFee   string `json:"maxPriorityFeePerGas"` // EIP-1559 transactions only
 		Hash             string `json:"hash"`
 		Input            string `json:"input"`
 		Nonce            string `json:"nonce"`
 		To               string `json:"to"`
+		Type             string `json:"type"` // Absent for legacy transactions on some nodes
 		TransactionIndex string `json:"transactionIndex"`
 		Value            string `json:"value"`
 		V                string `json:"v"`
 		R                string `json:"r"`
 		S                string `json:"s"`
+
+		// Signed fields of typed transactions, needed to recover the sender from the signature
+		ChainID             string          `json:"chainId"`
+		AccessList          json.RawMessage `json:"accessList"`
+		MaxFeePerBlobGas    string          `json:"maxFeePerBlobGas"`    // Blob transactions only
+		BlobVersionedHashes []string        `json:"blobVersionedHashes"` // Blob transactions only
 	} `json:"result"`
+
+	Chain *cache.Chain `json:"-"` // Chain the transaction was seen on; token lookups go to its RPC client
+}
+
+// Parsed ABIs keyed by their JSON, so each watched contract's ABI is only parsed once
+var parsedABIs sync.Map // string -> *abi.ABI
+
+// ParseABI parses a contract ABI, caching the result for later decodes
+func ParseABI(contractABI string) (*abi.ABI, error) {
+	if parsed, ok := parsedABIs.Load(contractABI); ok {
+		return parsed.(*abi.ABI), nil
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+	parsedABIs.Store(contractABI, &parsed)
+	return &parsed, nil
+}
+
+// RetainABIs drops the cached parsed ABIs other than the given ones, so ABIs replaced by a contracts
+// reload don't stay cached for the life of the process
+func RetainABIs(abis []string) {
+	keep := make(map[string]bool, len(abis))
+	for _, contractABI := range abis {
+		keep[contractABI] = true
+	}
+	parsedABIs.Range(func(key, _ any) bool {
+		if !keep[key.(string)] {
+			parsedABIs.Delete(key)
+		}
+		return true
+	})
+}
+
+// TxTypeLabel names a transaction type given as a hex string such as "0x2". A missing type is a
+// legacy transaction; unknown types are shown by number.
+func TxTypeLabel(typeHex string) string {
+	if typeHex == "" {
+		return "legacy"
+	}
+	txType, err := hexutil.DecodeUint64(typeHex)
+	if err != nil {
+		return typeHex
+	}
+	switch txType {
+	case 0:
+		return "legacy"
+	case 1:
+		return "EIP-2930"
+	case 2:
+		return "EIP-1559"
+	case 3:
+		return "EIP-4844"
+	default:
+		return fmt.Sprintf("type %d", txType)
+	}
+}
+
+// DecodedParam is a single decoded method argument
+type DecodedParam struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+
+	abiType     abi.Type     // Full ABI type, used to render tuples and other structured values
+	amountToken *amountToken // Token the amount is denominated in, when it can be paired with one
+	chain       *cache.Chain // Chain whose tokens the value refers to
 }
 
-// DecodeInputData decodes the input data of a transaction using the provided ABI
-func DecodeInputData(result TransactionResult, contractABI string, txDetailsChan chan string) {
+// DecodedTransaction is the structured form of a matched transaction, used by sinks and other consumers
+type DecodedTransaction struct {
+	Hash      string         `json:"hash"`
+	Timestamp time.Time      `json:"timestamp"`
+	Chain     string         `json:"chain,omitempty"` // Name of the chain when several are monitored
+	Contract  string         `json:"contract"`
+	From      string         `json:"from"`
+	FromLabel string         `json:"fromLabel,omitempty"` // Address book label of the sender, if any
+	To        string         `json:"to"`
+	ToLabel   string         `json:"toLabel,omitempty"` // Address book label of the recipient, if any
+	Value     string         `json:"value"`
+	Gas       string         `json:"gas"`
+	GasPrice  string         `json:"gasPrice"`
+	Type      string         `json:"type,omitempty"` // Transaction type label, e.g. "EIP-1559"; unset on inner calls
+	Method    string         `json:"method"`
+	Params    []DecodedParam `json:"params"`
+
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`         // EIP-1559 transactions only
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"` // EIP-1559 transactions only
+
+	RecoveredFrom string `json:"recoveredFrom,omitempty"` // Signer recovered from the signature, when it differs from From
+
+	Inner *DecodedTransaction   `json:"inner,omitempty"` // Call wrapped by a Safe execTransaction, if any
+	Calls []*DecodedTransaction `json:"calls,omitempty"` // Calls batched in a multicall, if any
+
+	CallsOmitted int `json:"callsOmitted,omitempty"` // Batched calls beyond MaxArrayItems, left undecoded
+
+	chain *cache.Chain // Chain the transaction was seen on, passed on to inner calls
+}
+
+// DecodeInputData decodes the input data of a transaction using the provided ABI. It returns the
+// structured decode, or an error if the input is malformed, the ABI can't be parsed, the method could
+// not be identified or its parameters could not be unpacked.
+func DecodeInputData(result TransactionResult, contractABI string) (*DecodedTransaction, error) {
+	return decodeInputData(result, contractABI, 0)
+}
+
+// decodeInputData decodes a call made depth levels deep inside wrapping Safe transactions
+func decodeInputData(result TransactionResult, contractABI string, depth int) (*DecodedTransaction, error) {
 	// Remove the "0x" prefix
 	inputData := strings.TrimPrefix(result.Result.Input, "0x")
 
+	// The input must at least hold the 4-byte method selector
+	if len(inputData) < 8 {
+		return nil, fmt.Errorf("input data %q is too short to contain a method selector", result.Result.Input)
+	}
+
 	// Decode the method selector (first 4 bytes)
 	methodSelector := inputData[:8]
 
 	// Decode the parameters (remaining bytes)
 	data, err := hex.DecodeString(inputData[8:])
 	if err != nil {
-		log.Fatalf("Failed to decode input data: %v", err)
+		return nil, fmt.Errorf("failed to decode input data: %w", err)
 	}
 
-	// Parse the provided ABI
-	parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+	// Parse the provided ABI, or reuse it when it has been parsed before
+	parsedABI, err := ParseABI(contractABI)
 	if err != nil {
-		log.Fatalf("Failed to parse ABI: %v", err)
+		return nil, err
 	}
 
 	// Use the ABI to decode the method and parameters
 	method, err := parsedABI.MethodById(common.FromHex("0x" + methodSelector))
+	if err != nil && methodSelector == hex.EncodeToString(safeExecTransaction.ID) {
+		// Safes are often watched without their full ABI
+		method, err = &safeExecTransaction, nil
+	}
 	if err != nil {
-		log.Printf("Failed to identify method: %v", err)
-		return
+		return nil, fmt.Errorf("failed to identify method: %w", err)
 	}
 
-	// Send the method name to txDetailsChan
-	txDetailsChan <- fmt.Sprintf("TxHash: %s\n", result.Result.Hash)
-	txDetailsChan <- fmt.Sprintf("Method Name: %s\n", method.Name)
-
 	// Decode the parameters
 	params, err := method.Inputs.Unpack(data)
 	if err != nil {
-		log.Printf("Failed to unpack parameters: %v", err)
-		return
+		return nil, fmt.Errorf("failed to unpack parameters of %s: %w", method.Name, err)
+	}
+
+	decoded := &DecodedTransaction{
+		Hash:     result.Result.Hash,
+		From:     result.Result.From,
+		To:       result.Result.To,
+		Value:    result.Result.Value,
+		Gas:      result.Result.Gas,
+		GasPrice: result.Result.GasPrice,
+		Method:   method.RawName, // Overloads such as ERC-721's two safeTransferFrom share their Solidity name
+		Params:   make([]DecodedParam, 0, len(params)),
+
+		MaxFeePerGas:         result.Result.MaxFeePerGas,
+		MaxPriorityFeePerGas: result.Result.MaxPriorityFee,
+
+		chain: result.Chain,
+	}
+
+	if depth == 0 {
+		decoded.Type = TxTypeLabel(result.Result.Type)
 	}
 
-	// Send the decoded parameters in a human-readable way to txDetailsChan
 	for i, param := range params {
-		var formattedParam string
-
-		switch v := param.(type) {
-		case *big.Int:
-			// Convert large numbers to decimal strings
-			formattedParam = fmt.Sprintf("  %s (%s): %s\n", method.Inputs[i].Name, method.Inputs[i].Type, v.String())
-		case common.Address:
-			// Format Ethereum addresses
-			formattedParam = fmt.Sprintf("  %s (%s): %s\n", method.Inputs[i].Name, method.Inputs[i].Type, v.Hex())
-		case []common.Address:
-			// Handle an array of Ethereum addresses and fetch token details
-			formattedParam = fmt.Sprintf("  %s (%s):\n", method.Inputs[i].Name, method.Inputs[i].Type)
-			for _, addr := range v {
-				// Fetch the token details
-				tokenInfo, err := cache.FetchTokenDetails(addr)
-				if err != nil {
-					formattedParam += fmt.Sprintf("    - %s (Token details fetch failed)\n", addr.Hex())
-				} else {
-					formattedParam += fmt.Sprintf("    - %s (%s: %s)\n", addr.Hex(), tokenInfo.Symbol, tokenInfo.Name)
-				}
+		decoded.Params = append(decoded.Params, DecodedParam{
+			Name:    method.Inputs[i].Name,
+			Type:    method.Inputs[i].Type.String(),
+			Value:   param,
+			abiType: method.Inputs[i].Type,
+			chain:   result.Chain,
+		})
+	}
+
+	// Pair amounts with their tokens so they can be shown scaled by the token's decimals
+	annotateAmounts(decoded.Params)
+
+	// Reveal what a Safe is actually doing by decoding the call it executes
+	if isSafeExecTransaction(method) && depth < maxInnerCallDepth {
+		decoded.Inner = decodeSafeInnerCall(decoded, params, depth)
+	}
+
+	// Show the calls a router multicall batches instead of opaque bytes
+	if isMulticall(method) && depth < maxInnerCallDepth {
+		decoded.Calls = decodeMulticallCalls(decoded, result, params, contractABI, depth)
+	}
+
+	return decoded, nil
+}
+
+// FormatDetails renders the decoded method and its parameters using the configured detail template
+func FormatDetails(decoded *DecodedTransaction) string {
+	return renderDetails(decoded)
+}
+
+// FormatParam renders a single decoded parameter in a human-readable way
+func FormatParam(param DecodedParam) string {
+	// Permit2 permits get a dedicated, labelled rendering of their nested structs
+	if isPermit2Struct(param.abiType) {
+		return formatPermit2(param)
+	}
+
+	// Structs and arrays of structs are rendered field by field
+	if containsTuple(param.abiType) {
+		return formatTupleParam(param)
+	}
+
+	switch v := param.Value.(type) {
+	case *big.Int:
+		// Scale amounts of a known token by its decimals, keeping the raw value. Other integers, such
+		// as NFT token ids, are shown as they are.
+		if param.amountToken != nil {
+			if amount, ok := formatAmount(lookupContext(param.chain), v, *param.amountToken); ok {
+				return fmt.Sprintf("  %s (%s): %s (%s)\n", param.Name, param.Type, amount, v.String())
+			}
+		}
+		// Convert large numbers to decimal strings
+		return fmt.Sprintf("  %s (%s): %s\n", param.Name, param.Type, v.String())
+	case common.Address:
+		// Format Ethereum addresses, prefixed with their label when known
+		if AddressLabel != nil {
+			if label, ok := AddressLabel(v); ok {
+				return fmt.Sprintf("  %s (%s): %s (%s)\n", param.Name, param.Type, label, v.Hex())
+			}
+		}
+		return fmt.Sprintf("  %s (%s): %s\n", param.Name, param.Type, v.Hex())
+	case []common.Address:
+		// Handle an array of Ethereum addresses and fetch token details, all at once where possible
+		formatted := fmt.Sprintf("  %s (%s):\n", param.Name, param.Type)
+		shown := shownItems(len(v))
+		if !ResolveTokens {
+			for _, addr := range v[:shown] {
+				formatted += fmt.Sprintf("    - %s\n", addr.Hex())
 			}
-		default:
-			// Print the value directly if no special formatting is needed
-			formattedParam = fmt.Sprintf("  %s (%s): %v\n", method.Inputs[i].Name, method.Inputs[i].Type, param)
+			return formatted + moreItems("    ", shown, len(v))
 		}
+		tokens, _ := cache.FetchTokenDetailsBatch(lookupContext(param.chain), v[:shown]) // Tokens that fail are left out and show as such below
+		for _, addr := range v[:shown] {
+			formatted += fmt.Sprintf("    - %s (%s)\n", addr.Hex(), formatToken(tokens[addr]))
+		}
+		return formatted + moreItems("    ", shown, len(v))
+	case []*big.Int:
+		// Lists of integers such as ERC-1155 token ids and values, one per line
+		formatted := fmt.Sprintf("  %s (%s):\n", param.Name, param.Type)
+		shown := shownItems(len(v))
+		for _, n := range v[:shown] {
+			formatted += fmt.Sprintf("    - %s\n", n.String())
+		}
+		return formatted + moreItems("    ", shown, len(v))
+	case []byte:
+		// Render dynamic bytes such as safeTransferFrom data as hex rather than a list of numbers
+		return fmt.Sprintf("  %s (%s): %s\n", param.Name, param.Type, formatBytes(v))
+	case [][]byte:
+		// Render byte arrays such as multicall data as hex rather than lists of numbers
+		formatted := fmt.Sprintf("  %s (%s):\n", param.Name, param.Type)
+		shown := shownItems(len(v))
+		for _, data := range v[:shown] {
+			formatted += fmt.Sprintf("    - %s\n", formatBytes(data))
+		}
+		return formatted + moreItems("    ", shown, len(v))
+	default:
+		// Print the value directly if no special formatting is needed, cutting long lists short
+		if list, ok := truncatedList(param.Value); ok {
+			return fmt.Sprintf("  %s (%s): %s\n", param.Name, param.Type, list)
+		}
+		return fmt.Sprintf("  %s (%s): %v\n", param.Name, param.Type, param.Value)
+	}
+}
+
+// describeToken fetches the token details for an address and renders them as "SYMBOL: Name"
+func describeToken(ctx context.Context, addr common.Address) string {
+	tokenInfo, _ := cache.FetchTokenDetails(ctx, addr)
+	return formatToken(tokenInfo)
+}
 
-		// Send the formatted string to txChan
-		txDetailsChan <- formattedParam
+// formatToken names a token by its symbol and name, or notes that its details couldn't be fetched
+func formatToken(tokenInfo *cache.TokenInfo) string {
+	if tokenInfo == nil {
+		return "Token details fetch failed"
 	}
+	return fmt.Sprintf("%s: %s", tokenInfo.Symbol, tokenInfo.Name)
 }
@@ -0,0 +1,163 @@
+package decoder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"eth-mempool-monitor/internal/cache"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const erc20ABI = `[{"name":"approve","type":"function","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},{"name":"totalSupply","type":"function","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`
+
+func TestDecodeInputDataLengths(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantMethod string // Empty when decoding must fail
+	}{
+		{name: "empty", input: ""},
+		{name: "prefix only", input: "0x"},
+		{name: "short", input: "0x123456"},
+		{name: "odd length", input: "0x18160ddd0"},
+		{name: "non-hex", input: "0x18160ddz"},
+		{name: "selector only", input: "0x18160ddd", wantMethod: "totalSupply"},
+		{name: "truncated parameters", input: "0x095ea7b3000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"},
+		{
+			name:       "full parameters",
+			input:      "0x095ea7b3000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			wantMethod: "approve",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result TransactionResult
+			result.Result.Input = tt.input
+			decoded, err := DecodeInputData(result, erc20ABI)
+			switch {
+			case tt.wantMethod == "" && err == nil:
+				t.Errorf("decoded %q as %s, want an error", tt.input, decoded.Method)
+			case tt.wantMethod != "" && err != nil:
+				t.Errorf("failed to decode %q: %v", tt.input, err)
+			case tt.wantMethod != "" && decoded.Method != tt.wantMethod:
+				t.Errorf("decoded %q as %s, want %s", tt.input, decoded.Method, tt.wantMethod)
+			}
+		})
+	}
+}
+
+// swapRouter02ABI holds the SwapRouter02 methods used by the multicall test
+const swapRouter02ABI = `[{"type":"function","name":"multicall","inputs":[{"name":"deadline","type":"uint256"},{"name":"data","type":"bytes[]"}],"outputs":[{"name":"","type":"bytes[]"}]},{"type":"function","name":"exactInputSingle","inputs":[{"name":"params","type":"tuple","internalType":"struct IV3SwapRouter.ExactInputSingleParams","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"fee","type":"uint24"},{"name":"recipient","type":"address"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"},{"name":"sqrtPriceLimitX96","type":"uint160"}]}],"outputs":[{"name":"amountOut","type":"uint256"}]},{"type":"function","name":"unwrapWETH9","inputs":[{"name":"amountMinimum","type":"uint256"},{"name":"recipient","type":"address"}],"outputs":[]}]`
+
+// A SwapRouter02 multicall(uint256 deadline, bytes[] data) swapping USDC for WETH and unwrapping it
+const swapRouter02Multicall = "0x5ae401dc" +
+	"0000000000000000000000000000000000000000000000000000000066669980" +
+	"0000000000000000000000000000000000000000000000000000000000000040" +
+	"0000000000000000000000000000000000000000000000000000000000000002" +
+	"0000000000000000000000000000000000000000000000000000000000000040" +
+	"0000000000000000000000000000000000000000000000000000000000000160" +
+	"00000000000000000000000000000000000000000000000000000000000000e4" +
+	"04e45aaf" +
+	"000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" +
+	"000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" +
+	"00000000000000000000000000000000000000000000000000000000000001f4" +
+	"0000000000000000000000000000000000000000000000000000000000000002" +
+	"000000000000000000000000000000000000000000000000000000009502f900" +
+	"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+	"0000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000" +
+	"0000000000000000000000000000000000000000000000000000000000000044" +
+	"49404b7c" +
+	"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+	"0000000000000000000000001111111111111111111111111111111111111111" +
+	"00000000000000000000000000000000000000000000000000000000"
+
+func TestDecodeMulticall(t *testing.T) {
+	var result TransactionResult
+	result.Result.Hash = "0xabc"
+	result.Result.From = "0x2222222222222222222222222222222222222222"
+	result.Result.To = "0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"
+	result.Result.Input = swapRouter02Multicall
+
+	decoded, err := DecodeInputData(result, swapRouter02ABI)
+	if err != nil {
+		t.Fatalf("failed to decode multicall: %v", err)
+	}
+	if decoded.Method != "multicall" {
+		t.Fatalf("decoded as %s, want multicall", decoded.Method)
+	}
+
+	wantCalls := []string{"exactInputSingle", "unwrapWETH9"}
+	if len(decoded.Calls) != len(wantCalls) {
+		t.Fatalf("decoded %d batched calls, want %d", len(decoded.Calls), len(wantCalls))
+	}
+	for i, call := range decoded.Calls {
+		if call.Method != wantCalls[i] {
+			t.Errorf("batched call %d decoded as %s, want %s", i, call.Method, wantCalls[i])
+		}
+		if call.From != result.Result.From || call.To != result.Result.To {
+			t.Errorf("batched call %d is from %s to %s, want the outer sender and router", i, call.From, call.To)
+		}
+	}
+
+	recipient, ok := decoded.Calls[1].Params[1].Value.(common.Address)
+	if !ok || recipient != common.HexToAddress("0x1111111111111111111111111111111111111111") {
+		t.Errorf("unwrapWETH9 recipient decoded as %v", decoded.Calls[1].Params[1].Value)
+	}
+}
+
+// A token path is resolved with one Multicall3 request, and tokens it couldn't fetch aren't looked
+// up again one by one
+func TestFormatTokenPathUsesOneBatch(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	multicall := common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+	cache.MulticallAddress = &multicall
+	t.Cleanup(func() { cache.MulticallAddress = nil })
+
+	path := []common.Address{
+		common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+		common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+	}
+	formatted := FormatParam(DecodedParam{Name: "path", Type: "address[]", Value: path, chain: &cache.Chain{ID: 1, Client: client}})
+
+	if got := strings.Count(formatted, "(Token details fetch failed)"); got != len(path) {
+		t.Errorf("%d tokens shown as failed, want %d:\n%s", got, len(path), formatted)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d RPC requests, want 1", got)
+	}
+}
+
+// ABIs no longer watched are dropped from the cache, and the ones still watched stay parsed
+func TestRetainABIs(t *testing.T) {
+	const replacedABI = `[{"name":"totalSupply","type":"function","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`
+	for _, contractABI := range []string{erc20ABI, replacedABI} {
+		if _, err := ParseABI(contractABI); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	RetainABIs([]string{erc20ABI})
+	if _, ok := parsedABIs.Load(replacedABI); ok {
+		t.Error("replaced ABI is still cached")
+	}
+	if _, ok := parsedABIs.Load(erc20ABI); !ok {
+		t.Error("retained ABI was dropped")
+	}
+}
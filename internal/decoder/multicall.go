@@ -0,0 +1,42 @@
+package decoder
+
+import (
+	"encoding/hex"
+
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// isMulticall reports whether a method batches calls to its own contract, as the Uniswap routers'
+// multicall(bytes[]), multicall(uint256 deadline, bytes[] data) and multicall(bytes32, bytes[]) do
+func isMulticall(method *abi.Method) bool {
+	inputs := method.Inputs
+	return method.RawName == "multicall" && len(inputs) > 0 && inputs[len(inputs)-1].Type.String() == "bytes[]"
+}
+
+// decodeMulticallCalls decodes each call batched in a multicall against the same ABI. The router
+// runs them with delegatecall, so each keeps the outer sender, target and value. Calls that can't
+// be decoded are reported by their selector. Only the first MaxArrayItems calls are decoded.
+func decodeMulticallCalls(outer *DecodedTransaction, result TransactionResult, params []interface{}, contractABI string, depth int) []*DecodedTransaction {
+	data, _ := params[len(params)-1].([][]byte)
+	shown := shownItems(len(data))
+	outer.CallsOmitted = len(data) - shown
+
+	calls := make([]*DecodedTransaction, 0, shown)
+	for _, callData := range data[:shown] {
+		inner := result
+		inner.Result.Input = "0x" + hex.EncodeToString(callData)
+
+		decoded, err := decodeInputData(inner, contractABI, depth+1)
+		if err != nil {
+			logging.Warnf("Failed to decode call batched in multicall %s: %v", outer.Hash, err)
+			decoded = &DecodedTransaction{Hash: outer.Hash, From: outer.From, To: outer.To, Value: outer.Value, Method: "(undecoded)"}
+			if len(callData) >= 4 {
+				decoded.Method = "0x" + hex.EncodeToString(callData[:4])
+			}
+		}
+		calls = append(calls, decoded)
+	}
+	return calls
+}
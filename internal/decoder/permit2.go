@@ -0,0 +1,95 @@
+package decoder
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"eth-mempool-monitor/internal/cache"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// isPermit2Struct reports whether an ABI type is a Permit2 PermitSingle or PermitBatch struct,
+// recognised by its field layout so ABIs without internalType names are handled too
+func isPermit2Struct(typ abi.Type) bool {
+	if typ.T != abi.TupleTy || len(typ.TupleRawNames) != 3 {
+		return false
+	}
+	return typ.TupleRawNames[0] == "details" &&
+		typ.TupleRawNames[1] == "spender" &&
+		typ.TupleRawNames[2] == "sigDeadline"
+}
+
+// formatPermit2 renders a PermitSingle/PermitBatch parameter with labelled, resolved fields
+func formatPermit2(param DecodedParam) string {
+	permit := reflect.ValueOf(param.Value)
+	if permit.Kind() != reflect.Struct {
+		return fmt.Sprintf("  %s (%s): %v\n", param.Name, param.Type, param.Value)
+	}
+
+	kind := "PermitSingle"
+	details := permit.FieldByName("Details")
+	if details.Kind() == reflect.Slice {
+		kind = "PermitBatch"
+	}
+
+	out := fmt.Sprintf("  %s (Permit2 %s):\n", param.Name, kind)
+	if spender, ok := permit.FieldByName("Spender").Interface().(common.Address); ok {
+		out += fmt.Sprintf("    spender: %s\n", spender.Hex())
+	}
+	if deadline, ok := permit.FieldByName("SigDeadline").Interface().(*big.Int); ok {
+		out += fmt.Sprintf("    sigDeadline: %s\n", formatUnixTime(deadline))
+	}
+
+	ctx := lookupContext(param.chain)
+	if kind == "PermitSingle" {
+		out += "    details:\n"
+		out += formatPermitDetails(ctx, details, "      ")
+		return out
+	}
+
+	out += fmt.Sprintf("    details (%d):\n", details.Len())
+	for i := 0; i < details.Len(); i++ {
+		out += fmt.Sprintf("      [%d]\n", i)
+		out += formatPermitDetails(ctx, details.Index(i), "        ")
+	}
+	return out
+}
+
+// formatPermitDetails renders a PermitDetails struct, resolving the token via the cache
+func formatPermitDetails(ctx context.Context, details reflect.Value, indent string) string {
+	var out string
+
+	if token, ok := details.FieldByName("Token").Interface().(common.Address); ok {
+		if !ResolveTokens {
+			out += fmt.Sprintf("%stoken: %s\n", indent, token.Hex())
+		} else if tokenInfo, err := cache.FetchTokenDetails(ctx, token); err != nil {
+			out += fmt.Sprintf("%stoken: %s (Token details fetch failed)\n", indent, token.Hex())
+		} else {
+			out += fmt.Sprintf("%stoken: %s (%s: %s)\n", indent, token.Hex(), tokenInfo.Symbol, tokenInfo.Name)
+		}
+	}
+	if amount, ok := details.FieldByName("Amount").Interface().(*big.Int); ok {
+		out += fmt.Sprintf("%samount: %s\n", indent, amount.String())
+	}
+	if expiration, ok := details.FieldByName("Expiration").Interface().(*big.Int); ok {
+		out += fmt.Sprintf("%sexpiration: %s\n", indent, formatUnixTime(expiration))
+	}
+	if nonce, ok := details.FieldByName("Nonce").Interface().(*big.Int); ok {
+		out += fmt.Sprintf("%snonce: %s\n", indent, nonce.String())
+	}
+
+	return out
+}
+
+// formatUnixTime renders a unix timestamp along with its UTC date
+func formatUnixTime(ts *big.Int) string {
+	if !ts.IsInt64() {
+		return ts.String()
+	}
+	return fmt.Sprintf("%s (%s)", ts.String(), time.Unix(ts.Int64(), 0).UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,110 @@
+package decoder
+
+import (
+	"context"
+	"encoding/hex"
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/logging"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// safeExecTransactionABI is the Gnosis Safe execTransaction method, used when a watched Safe's
+// configured ABI doesn't include it
+const safeExecTransactionABI = `[{"type":"function","name":"execTransaction","stateMutability":"payable","inputs":[
+	{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},
+	{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},
+	{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},
+	{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]}]`
+
+// safeExecTransaction is the parsed execTransaction method
+var safeExecTransaction = func() abi.Method {
+	parsed, err := abi.JSON(strings.NewReader(safeExecTransactionABI))
+	if err != nil {
+		log.Fatalf("Failed to parse Safe ABI: %v", err)
+	}
+	return parsed.Methods["execTransaction"]
+}()
+
+// Safes can execute calls on other Safes; stop unwrapping after this many levels
+const maxInnerCallDepth = 3
+
+// InnerCallABI looks up the ABI used to decode a call wrapped in a Safe transaction by the chain and
+// target address. It is set by the monitor; without it inner calls are shown undecoded.
+var InnerCallABI func(chain *cache.Chain, to string) (string, bool)
+
+// LookupContext bounds the token lookups made while formatting parameters. It is set by the monitor
+// so lookups are abandoned on shutdown.
+var LookupContext = context.Background()
+
+// ResolveTokens fetches token details while formatting parameters. The monitor clears it with
+// RESOLVE_TOKENS=false so decoding never waits on RPC calls; addresses and amounts are then shown raw.
+var ResolveTokens = true
+
+// AddressLabel names known addresses in decoded parameters, such as exchange wallets. It is set by
+// the monitor from its address book; without it addresses are shown bare.
+var AddressLabel func(addr common.Address) (string, bool)
+
+// lookupContext is LookupContext with token lookups sent to the given chain, or to the default
+// chain when it is unknown
+func lookupContext(chain *cache.Chain) context.Context {
+	if chain == nil {
+		return LookupContext
+	}
+	return cache.WithChain(LookupContext, chain)
+}
+
+// isSafeExecTransaction reports whether a method is Safe's execTransaction
+func isSafeExecTransaction(method *abi.Method) bool {
+	return method.Sig == safeExecTransaction.Sig
+}
+
+// decodeSafeInnerCall extracts the call wrapped by a Safe execTransaction and decodes it against the
+// ABI of its target when one is known. The Safe itself becomes the sender of the inner call.
+func decodeSafeInnerCall(outer *DecodedTransaction, params []interface{}, depth int) *DecodedTransaction {
+	to, _ := params[0].(common.Address)
+	value, _ := params[1].(*big.Int)
+	data, _ := params[2].([]byte)
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	var inner TransactionResult
+	inner.Chain = outer.chain
+	inner.Result.Hash = outer.Hash
+	inner.Result.From = outer.To
+	inner.Result.To = to.Hex()
+	inner.Result.Value = fmt.Sprintf("0x%x", value)
+	inner.Result.Input = "0x" + hex.EncodeToString(data)
+
+	// Plain value transfers and calls to unknown contracts are reported with what is known
+	undecoded := &DecodedTransaction{
+		Hash:   outer.Hash,
+		From:   inner.Result.From,
+		To:     inner.Result.To,
+		Value:  inner.Result.Value,
+		Method: "transfer (no calldata)",
+	}
+	if len(data) >= 4 {
+		undecoded.Method = "0x" + hex.EncodeToString(data[:4])
+	}
+	if len(data) < 4 || InnerCallABI == nil {
+		return undecoded
+	}
+
+	contractABI, ok := InnerCallABI(inner.Chain, inner.Result.To)
+	if !ok {
+		return undecoded
+	}
+	decoded, err := decodeInputData(inner, contractABI, depth+1)
+	if err != nil {
+		logging.Warnf("Failed to decode call wrapped in Safe transaction %s: %v", outer.Hash, err)
+		return undecoded
+	}
+	return decoded
+}
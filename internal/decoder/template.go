@@ -0,0 +1,101 @@
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultDetailTemplate reproduces the built-in details output
+const DefaultDetailTemplate = `TxHash: {{.Hash}}
+Method Name: {{.Method}}
+{{range .Params}}{{formatParam .}}{{end}}{{template "calls" .}}{{template "inner" .Inner}}
+{{- define "inner"}}{{if .}}Inner Call: {{.Method}} to {{.To}} (value {{.Value}})
+{{range .Params}}{{formatParam .}}{{end}}{{template "calls" .}}{{template "inner" .Inner}}{{end}}{{end}}
+{{- define "calls"}}{{range $i, $call := .Calls}}  Call {{$i}}: {{$call.Method}}
+{{range $call.Params}}{{indent (formatParam .)}}{{end}}{{end}}{{if .CallsOmitted}}  … and {{.CallsOmitted}} more calls
+{{end}}{{end}}`
+
+// Functions available to detail templates
+var templateFuncs = template.FuncMap{
+	"formatParam": FormatParam,
+	"indent": func(text string) string {
+		return "  " + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n  ") + "\n"
+	},
+	"token": func(addr common.Address) string {
+		if !ResolveTokens {
+			return addr.Hex()
+		}
+		return describeToken(LookupContext, addr) // Templates only see addresses, so this uses the first chain
+	},
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// The template used to render transaction details
+var (
+	detailTemplateMu sync.RWMutex
+	defaultTemplate  = template.Must(template.New("details").Funcs(templateFuncs).Parse(DefaultDetailTemplate))
+	detailTemplate   = defaultTemplate
+)
+
+// LoadDetailTemplate sets the details template from inline text, or from a file when text is empty
+// and filename is set. The template is validated against a sample transaction before it is used.
+func LoadDetailTemplate(text, filename string) error {
+	if text == "" && filename != "" {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read detail template: %w", err)
+		}
+		text = string(data)
+	}
+	if text == "" {
+		return nil
+	}
+
+	tmpl, err := template.New("details").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse detail template: %w", err)
+	}
+
+	// Execute against a sample so field typos are caught at startup rather than per transaction
+	sample := &DecodedTransaction{
+		Hash:   "0x0",
+		Method: "sample",
+		Params: []DecodedParam{{Name: "amount", Type: "uint256", Value: big.NewInt(0)}},
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return fmt.Errorf("detail template failed on a sample transaction: %w", err)
+	}
+
+	detailTemplateMu.Lock()
+	detailTemplate = tmpl
+	detailTemplateMu.Unlock()
+	return nil
+}
+
+// renderDetails executes the configured template, falling back to the default on error
+func renderDetails(decoded *DecodedTransaction) string {
+	detailTemplateMu.RLock()
+	tmpl := detailTemplate
+	detailTemplateMu.RUnlock()
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, decoded); err != nil {
+		logging.Warnf("Failed to render detail template, using default: %v", err)
+		out.Reset()
+		defaultTemplate.Execute(&out, decoded)
+	}
+	return out.String()
+}
@@ -0,0 +1,57 @@
+package decoder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// Defaults for the output limits below
+const (
+	DefaultMaxArrayItems = 20
+	DefaultMaxBytes      = 256
+)
+
+// Limits on how much of a large parameter is rendered, so transactions passing thousands of
+// addresses or huge calldata don't flood the details. Zero means no limit.
+var (
+	// MaxArrayItems is how many elements of an array, or calls of a multicall, are shown
+	MaxArrayItems = DefaultMaxArrayItems
+	// MaxBytes is how many bytes of a bytes value are shown as hex
+	MaxBytes = DefaultMaxBytes
+)
+
+// shownItems returns how many of n array elements to show
+func shownItems(n int) int {
+	if MaxArrayItems > 0 && n > MaxArrayItems {
+		return MaxArrayItems
+	}
+	return n
+}
+
+// moreItems renders the line noting the elements left out after the first shown, if any
+func moreItems(indent string, shown, n int) string {
+	if shown >= n {
+		return ""
+	}
+	return fmt.Sprintf("%s… and %d more\n", indent, n-shown)
+}
+
+// formatBytes renders bytes as hex, cut off after MaxBytes with a note of how many were left out
+func formatBytes(data []byte) string {
+	if MaxBytes <= 0 || len(data) <= MaxBytes {
+		return "0x" + hex.EncodeToString(data)
+	}
+	return fmt.Sprintf("0x%s… (%d more bytes)", hex.EncodeToString(data[:MaxBytes]), len(data)-MaxBytes)
+}
+
+// truncatedList renders a slice longer than MaxArrayItems, such as a bool[], as its first elements
+// and a note of how many were left out. It returns false for anything else.
+func truncatedList(value interface{}) (string, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice || shownItems(v.Len()) == v.Len() {
+		return "", false
+	}
+	shown := shownItems(v.Len())
+	return fmt.Sprintf("%v … and %d more", v.Slice(0, shown).Interface(), v.Len()-shown), true
+}
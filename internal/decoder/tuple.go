@@ -0,0 +1,96 @@
+package decoder
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// containsTuple reports whether an ABI type is a tuple or an array/slice (possibly nested) of tuples
+func containsTuple(typ abi.Type) bool {
+	switch typ.T {
+	case abi.TupleTy:
+		return true
+	case abi.SliceTy, abi.ArrayTy:
+		return containsTuple(*typ.Elem)
+	default:
+		return false
+	}
+}
+
+// formatTupleParam renders a struct parameter, or an array of structs, with one labelled line per field
+func formatTupleParam(param DecodedParam) string {
+	return formatField(param.Name, param.abiType, reflect.ValueOf(param.Value), "  ")
+}
+
+// formatField renders a named value of the given ABI type, recursing into tuples and arrays of tuples
+func formatField(name string, typ abi.Type, v reflect.Value, indent string) string {
+	switch {
+	case typ.T == abi.TupleTy && v.Kind() == reflect.Struct:
+		out := fmt.Sprintf("%s%s (%s):\n", indent, name, tupleLabel(typ))
+		for i, elem := range typ.TupleElems {
+			if i >= v.NumField() {
+				break
+			}
+			out += formatField(typ.TupleRawNames[i], *elem, v.Field(i), indent+"  ")
+		}
+		return out
+	case (typ.T == abi.SliceTy || typ.T == abi.ArrayTy) && containsTuple(*typ.Elem) &&
+		(v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+		out := fmt.Sprintf("%s%s (%s, %d items):\n", indent, name, tupleLabel(typ), v.Len())
+		shown := shownItems(v.Len())
+		for i := 0; i < shown; i++ {
+			out += formatField(fmt.Sprintf("[%d]", i), *typ.Elem, v.Index(i), indent+"  ")
+		}
+		return out + moreItems(indent+"  ", shown, v.Len())
+	default:
+		return fmt.Sprintf("%s%s (%s): %s\n", indent, name, typ.String(), formatScalar(v))
+	}
+}
+
+// tupleLabel names a struct type by its Solidity struct name when the ABI provides one, e.g.
+// "Order[]" or "ISwapRouterExactInputSingleParams" (go-ethereum joins the name of the interface
+// declaring the struct), rather than the full component signature
+func tupleLabel(typ abi.Type) string {
+	switch typ.T {
+	case abi.TupleTy:
+		if typ.TupleRawName != "" {
+			return typ.TupleRawName
+		}
+		return "tuple"
+	case abi.SliceTy:
+		return tupleLabel(*typ.Elem) + "[]"
+	case abi.ArrayTy:
+		return fmt.Sprintf("%s[%d]", tupleLabel(*typ.Elem), typ.Size)
+	default:
+		return typ.String()
+	}
+}
+
+// formatScalar renders a single non-tuple value inside a struct
+func formatScalar(v reflect.Value) string {
+	if !v.IsValid() || !v.CanInterface() {
+		return "<invalid>"
+	}
+
+	switch value := v.Interface().(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case []byte:
+		return formatBytes(value)
+	}
+
+	// Fixed-size byte arrays such as bytes32
+	if v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
@@ -0,0 +1,48 @@
+package decoder
+
+import (
+	"strings"
+	"testing"
+)
+
+// Uniswap V3 SwapRouter's exactInputSingle, which takes its parameters as one struct
+const swapRouterABI = `[{"name":"exactInputSingle","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","internalType":"struct ISwapRouter.ExactInputSingleParams","components":[{"name":"tokenIn","type":"address","internalType":"address"},{"name":"tokenOut","type":"address","internalType":"address"},{"name":"fee","type":"uint24","internalType":"uint24"},{"name":"recipient","type":"address","internalType":"address"},{"name":"deadline","type":"uint256","internalType":"uint256"},{"name":"amountIn","type":"uint256","internalType":"uint256"},{"name":"amountOutMinimum","type":"uint256","internalType":"uint256"},{"name":"sqrtPriceLimitX96","type":"uint160","internalType":"uint160"}]}],"outputs":[{"name":"amountOut","type":"uint256","internalType":"uint256"}]}]`
+
+// A swap of 1 WETH for at least 1,800 USDC through the 0.05% pool
+const exactInputSingleInput = "0x414bf389" +
+	"000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" +
+	"000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" +
+	"00000000000000000000000000000000000000000000000000000000000001f4" +
+	"000000000000000000000000742d35cc6634c0532925a3b844bc454e4438f44e" +
+	"000000000000000000000000000000000000000000000000000000006553f100" +
+	"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+	"000000000000000000000000000000000000000000000000000000006b49d200" +
+	"0000000000000000000000000000000000000000000000000000000000000000"
+
+func TestDecodeExactInputSingle(t *testing.T) {
+	var result TransactionResult
+	result.Result.Input = exactInputSingleInput
+
+	decoded, err := DecodeInputData(result, swapRouterABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Method != "exactInputSingle" || len(decoded.Params) != 1 {
+		t.Fatalf("decoded %s with %d params, want exactInputSingle with 1", decoded.Method, len(decoded.Params))
+	}
+
+	want := strings.Join([]string{
+		"  params (ISwapRouterExactInputSingleParams):",
+		"    tokenIn (address): 0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		"    tokenOut (address): 0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		"    fee (uint24): 500",
+		"    recipient (address): 0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		"    deadline (uint256): 1700000000",
+		"    amountIn (uint256): 1000000000000000000",
+		"    amountOutMinimum (uint256): 1800000000",
+		"    sqrtPriceLimitX96 (uint160): 0",
+	}, "\n") + "\n"
+	if got := FormatParam(decoded.Params[0]); got != want {
+		t.Errorf("FormatParam =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+// Package logging provides leveled, printf-style logging on top of log/slog. Messages go through the
+// default slog handler, which writes them to the standard logger's output tagged with their level,
+// e.g. "2024/01/02 15:04:05 WARN Failed to fetch transaction ...", so redirecting the standard
+// logger redirects them too.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Secrets scrubbed from every logged message, such as API keys in endpoint URLs, since logs are also
+// served over HTTP. Secrets shorter than minSecretLength aren't registered so that common words
+// and path segments such as "ws" aren't mangled.
+const minSecretLength = 8
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecret registers a value that must never appear in the logs
+func AddSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, existing := range secrets {
+		if existing == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// Redact replaces the registered secrets in a message with "<redacted>"
+func Redact(message string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		message = strings.ReplaceAll(message, secret, "<redacted>")
+	}
+	return message
+}
+
+// SetLevel sets the minimum level logged from a LOG_LEVEL value: debug, info, warn or error. An
+// empty value keeps the default, info.
+func SetLevel(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL %q, expected debug, info, warn or error", value)
+	}
+	slog.SetLogLoggerLevel(level)
+	return nil
+}
+
+// Enabled reports whether messages at the level are logged
+func Enabled(level slog.Level) bool {
+	return slog.Default().Enabled(context.Background(), level)
+}
+
+// Logf logs a formatted message at the level, with secrets redacted. The message is only formatted
+// when the level is enabled.
+func Logf(level slog.Level, format string, args ...interface{}) {
+	if !Enabled(level) {
+		return
+	}
+	slog.Default().Log(context.Background(), level, Redact(fmt.Sprintf(format, args...)))
+}
+
+// Debugf logs a formatted message at the debug level, such as per-request details
+func Debugf(format string, args ...interface{}) {
+	Logf(slog.LevelDebug, format, args...)
+}
+
+// Infof logs a formatted message at the info level
+func Infof(format string, args ...interface{}) {
+	Logf(slog.LevelInfo, format, args...)
+}
+
+// Warnf logs a formatted message at the warn level, for failures that are recovered from
+func Warnf(format string, args ...interface{}) {
+	Logf(slog.LevelWarn, format, args...)
+}
+
+// Errorf logs a formatted message at the error level, for failures that stop a feature from working
+func Errorf(format string, args ...interface{}) {
+	Logf(slog.LevelError, format, args...)
+}
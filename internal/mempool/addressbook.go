@@ -0,0 +1,104 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Default location of the address book (ADDRESS_BOOK_PATH)
+const defaultAddressBookPath = "configs/addressbook.json"
+
+// Labels of known addresses, such as exchange wallets, loaded from the address book. Addresses are
+// matched case-insensitively like watched contracts. The book is replaced wholesale when its file
+// changes or on SIGHUP.
+var (
+	addressBookMu   sync.RWMutex
+	addressBook     map[common.Address]string
+	addressBookPath = defaultAddressBookPath
+)
+
+// LoadAddressBook loads a JSON object mapping addresses to labels. A missing file means no labels.
+func LoadAddressBook(filename string) (map[common.Address]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read address book: %w", err)
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse address book: %w", err)
+	}
+
+	book := make(map[common.Address]string, len(labels))
+	for addr, label := range labels {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid address %q in address book", addr)
+		}
+		book[common.HexToAddress(addr)] = label
+	}
+
+	logging.Infof("Loaded %d address labels from %s", len(book), filename)
+	return book, nil
+}
+
+// reloadAddressBook re-reads the address book, keeping the current labels if the file is broken
+func reloadAddressBook() {
+	book, err := LoadAddressBook(addressBookPath)
+	if err != nil {
+		logging.Warnf("Error reloading address book, keeping the previous labels: %v", err)
+		return
+	}
+
+	addressBookMu.Lock()
+	addressBook = book
+	addressBookMu.Unlock()
+}
+
+// watchAddressBook reloads the address book whenever its file changes, like the contracts files
+func watchAddressBook(ctx context.Context) {
+	if contractsReloadInterval <= 0 {
+		return
+	}
+	if err := watchFile(ctx, addressBookPath, reloadAddressBook); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logging.Warnf("Not watching %s for changes: %v", addressBookPath, err)
+	}
+}
+
+// addressLabel returns the address book label of an address, if it has one
+func addressLabel(addr common.Address) (string, bool) {
+	addressBookMu.RLock()
+	defer addressBookMu.RUnlock()
+	label, ok := addressBook[addr]
+	return label, ok
+}
+
+// hexAddressLabel returns the address book label of a hex address, or "" when it has none
+func hexAddressLabel(addr string) string {
+	if !common.IsHexAddress(addr) {
+		return ""
+	}
+	label, _ := addressLabel(common.HexToAddress(addr))
+	return label
+}
+
+// labelAddress renders a known address as its label with the address shortened, e.g.
+// "Binance Hot Wallet (0x28C6…1d60)", leaving other addresses as they are
+func labelAddress(addr string) string {
+	label := hexAddressLabel(addr)
+	if label == "" {
+		return addr
+	}
+	hex := common.HexToAddress(addr).Hex()
+	return fmt.Sprintf("%s (%s…%s)", label, hex[:6], hex[len(hex)-4:])
+}
@@ -0,0 +1,172 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+)
+
+// Default batching of eth_getTransactionByHash calls
+const (
+	defaultRPCBatchSize   = 100
+	defaultRPCBatchWindow = 50 * time.Millisecond
+)
+
+// batchReply is the outcome of a single lookup within a batch
+type batchReply struct {
+	result decoder.TransactionResult
+	err    error
+}
+
+// batchRequest is a hash waiting to be sent in the next batch
+type batchRequest struct {
+	ctx   context.Context
+	hash  string
+	reply chan batchReply
+}
+
+// rpcBatcher collects transaction hashes for a short window, or until the batch is full, and looks
+// them up on its chain with a single JSON-RPC batch request
+type rpcBatcher struct {
+	monitor *Monitor
+	size    int
+	window  time.Duration
+
+	mu      sync.Mutex
+	pending []batchRequest
+	timer   *time.Timer
+}
+
+func newRPCBatcher(monitor *Monitor, size int, window time.Duration) *rpcBatcher {
+	if size <= 0 {
+		size = defaultRPCBatchSize
+	}
+	if window <= 0 {
+		window = defaultRPCBatchWindow
+	}
+	return &rpcBatcher{monitor: monitor, size: size, window: window}
+}
+
+// fetch queues a hash for the next batch and waits for its transaction, or until ctx is cancelled
+func (b *rpcBatcher) fetch(ctx context.Context, hash string) (decoder.TransactionResult, error) {
+	reply := make(chan batchReply, 1)
+
+	b.mu.Lock()
+	b.pending = append(b.pending, batchRequest{ctx: ctx, hash: hash, reply: reply})
+	if len(b.pending) >= b.size {
+		// Full batch: send it now rather than waiting for the window to close
+		batch := b.take()
+		b.mu.Unlock()
+		go b.send(batch)
+	} else {
+		if len(b.pending) == 1 {
+			b.timer = time.AfterFunc(b.window, b.flush)
+		}
+		b.mu.Unlock()
+	}
+
+	select {
+	case r := <-reply:
+		return r.result, r.err
+	case <-ctx.Done():
+		return decoder.TransactionResult{}, ctx.Err()
+	}
+}
+
+// take removes the pending batch; the caller must hold the lock
+func (b *rpcBatcher) take() []batchRequest {
+	batch := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch
+}
+
+// flush sends whatever has accumulated once the window closes
+func (b *rpcBatcher) flush() {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		b.send(batch)
+	}
+}
+
+// send looks up a batch of hashes in one request and replies to each waiting caller
+func (b *rpcBatcher) send(batch []batchRequest) {
+	type call struct {
+		Jsonrpc string   `json:"jsonrpc"`
+		Method  string   `json:"method"`
+		Params  []string `json:"params"`
+		ID      int      `json:"id"`
+	}
+	calls := make([]call, len(batch))
+	for i, req := range batch {
+		calls[i] = call{Jsonrpc: "2.0", Method: "eth_getTransactionByHash", Params: []string{req.hash}, ID: i}
+	}
+
+	// Every caller shares the monitor's context, so the first one's bounds the whole batch
+	results, err := b.post(batch[0].ctx, calls)
+	for i, req := range batch {
+		reply := batchReply{err: err}
+		if err == nil {
+			if r, ok := results[i]; ok {
+				reply = r
+			} else {
+				reply.err = fmt.Errorf("no response for transaction %s in batch", req.hash)
+			}
+		}
+		req.reply <- reply
+	}
+}
+
+// post sends the batch request and returns the replies indexed by call id
+func (b *rpcBatcher) post(ctx context.Context, calls interface{}) (map[int]batchReply, error) {
+	payload, err := json.Marshal(calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch request: %w", err)
+	}
+
+	req, err := b.monitor.newRPCRequest(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := waitForRPC(ctx); err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&inFlightRPC, 1)
+	resp, err := b.monitor.httpClient.Do(req)
+	atomic.AddInt64(&inFlightRPC, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send batch request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var responses []struct {
+		ID    int           `json:"id"`
+		Error *jsonRPCError `json:"error"`
+		decoder.TransactionResult
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("failed to decode batch response (does the endpoint support batching? set RPC_BATCH_SIZE=1 to disable): %w", err)
+	}
+
+	results := make(map[int]batchReply, len(responses))
+	for _, r := range responses {
+		if r.Error != nil {
+			results[r.ID] = batchReply{err: fmt.Errorf("RPC error: %s", r.Error.Message)}
+			continue
+		}
+		results[r.ID] = batchReply{result: r.TransactionResult}
+	}
+	return results, nil
+}
@@ -0,0 +1,178 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Default block state location and how many missed blocks are scanned when resuming
+const (
+	defaultBlockStatePath    = "block_state.json"
+	defaultBackfillMaxBlocks = 100
+)
+
+// Block tracking persistence settings, from BLOCK_STATE_PATH and BACKFILL_MAX_BLOCKS. The state is
+// only kept with NEW_HEADS enabled, since blocks are processed as their heads arrive.
+var (
+	blockStatePath    = defaultBlockStatePath
+	backfillMaxBlocks = defaultBackfillMaxBlocks
+	blockStateMu      sync.Mutex // Serializes saves from the chains' heads
+)
+
+// chainBlockState is what a chain's block tracking needs to carry on after a restart: the last block
+// processed and the matched transactions still waiting to be mined
+type chainBlockState struct {
+	LastBlock uint64               `json:"lastBlock"`
+	Pending   map[string]time.Time `json:"pending,omitempty"`
+}
+
+// stateKey names the chain in the block state file, by chain id when it is known
+func (m *Monitor) stateKey() string {
+	switch {
+	case m.chainID != 0:
+		return strconv.FormatUint(m.chainID, 10)
+	case m.name != "":
+		return m.name
+	default:
+		return "default"
+	}
+}
+
+// loadBlockState restores each chain's last processed block and pending transactions from a file
+// written by saveBlockState. A missing file is not an error since tracking simply starts fresh.
+func loadBlockState(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read block state: %w", err)
+	}
+
+	var states map[string]chainBlockState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("failed to parse block state: %w", err)
+	}
+
+	for _, m := range monitors {
+		state, exists := states[m.stateKey()]
+		if !exists {
+			continue
+		}
+		m.resumeFrom = state.LastBlock
+		m.lastBlock.Store(state.LastBlock)
+		m.pendingMu.Lock()
+		m.watchedPending = make(map[string]time.Time, len(state.Pending))
+		for hash, firstSeen := range state.Pending {
+			if time.Since(firstSeen) <= pendingTrackTTL {
+				m.watchedPending[hash] = firstSeen
+			}
+		}
+		m.pendingMu.Unlock()
+		m.logf(slog.LevelInfo, "Resuming block tracking after block %d with %d pending transactions", state.LastBlock, len(m.watchedPending))
+	}
+	return nil
+}
+
+// saveBlockState writes every chain's last processed block and pending transactions, logging rather
+// than failing on errors. The file is replaced atomically so a crash never leaves it truncated.
+func saveBlockState() {
+	states := make(map[string]chainBlockState, len(monitors))
+	for _, m := range monitors {
+		state := chainBlockState{LastBlock: m.lastBlock.Load()}
+		if state.LastBlock == 0 {
+			continue // No block seen yet
+		}
+		m.pendingMu.Lock()
+		if len(m.watchedPending) > 0 {
+			state.Pending = make(map[string]time.Time, len(m.watchedPending))
+			for hash, firstSeen := range m.watchedPending {
+				state.Pending[hash] = firstSeen
+			}
+		}
+		m.pendingMu.Unlock()
+		states[m.stateKey()] = state
+	}
+	if len(states) == 0 {
+		return
+	}
+
+	blockStateMu.Lock()
+	defer blockStateMu.Unlock()
+	if err := writeFileAtomically(blockStatePath, states); err != nil {
+		logging.Errorf("Failed to save block state: %v", err)
+	}
+}
+
+// writeFileAtomically writes v as JSON to a temporary file and renames it into place
+func writeFileAtomically(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// recordBlock notes a processed block, keeping the highest since heads are handled concurrently
+func (m *Monitor) recordBlock(number uint64) {
+	for {
+		last := m.lastBlock.Load()
+		if number <= last || m.lastBlock.CompareAndSwap(last, number) {
+			return
+		}
+	}
+}
+
+// backfill scans the blocks missed since the previous run, up to the first live head, for pending
+// transactions mined meanwhile. At most BACKFILL_MAX_BLOCKS of the most recent missed blocks are
+// scanned; larger gaps are reported since transactions mined earlier go unnoticed.
+func (m *Monitor) backfill(ctx context.Context, head uint64) {
+	if m.resumeFrom == 0 || head <= m.resumeFrom+1 {
+		return
+	}
+
+	from, to := m.resumeFrom+1, head-1
+	if missed := to - from + 1; missed > uint64(backfillMaxBlocks) {
+		m.logf(slog.LevelWarn, "%d blocks were missed since the last run, only scanning the last %d for mined transactions (BACKFILL_MAX_BLOCKS)", missed, backfillMaxBlocks)
+		from = to - uint64(backfillMaxBlocks) + 1
+		if backfillMaxBlocks == 0 {
+			return
+		}
+	}
+
+	m.logf(slog.LevelInfo, "Backfilling blocks %d to %d missed since the last run", from, to)
+	for number := from; number <= to; number++ {
+		if ctx.Err() != nil {
+			return
+		}
+		m.checkMined(ctx, BlockHead{Number: number}) // Dwell times run to the block's own timestamp
+		m.recordBlock(number)
+	}
+}
@@ -0,0 +1,86 @@
+package mempool
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"eth-mempool-monitor/internal/cache"
+)
+
+func TestBlockStateResumesTracking(t *testing.T) {
+	subscribeHeads = true
+	blockStatePath = filepath.Join(t.TempDir(), "block_state.json")
+	pendingTrackTTL = 100 * 365 * 24 * time.Hour // The test block's timestamp is long past
+	t.Cleanup(func() {
+		subscribeHeads = false
+		blockStatePath = defaultBlockStatePath
+		pendingTrackTTL = defaultPendingTrackTTL
+		monitors = nil
+		minedChan = nil
+	})
+
+	// The previous run stopped at block 100 while watching a transaction
+	const minedHash = "0xaaaa"
+	previous := &Monitor{chainID: 1, chain: &cache.Chain{ID: 1}}
+	previous.trackPending(minedHash, time.Unix(0x6553f100-30, 0))
+	previous.recordBlock(100)
+	monitors = []*Monitor{previous}
+	saveBlockState()
+
+	m := &Monitor{chainID: 1, chain: &cache.Chain{ID: 1, Client: newBlockServer(t, minedHash)}}
+	monitors = []*Monitor{m}
+	if err := loadBlockState(blockStatePath); err != nil {
+		t.Fatal(err)
+	}
+	if m.resumeFrom != 100 {
+		t.Fatalf("resuming from block %d, want 100", m.resumeFrom)
+	}
+
+	// The first live head backfills the missed blocks, timing the dwell to the block's timestamp
+	minedChan = make(chan MinedTx, 1)
+	m.backfill(context.Background(), 104)
+	select {
+	case tx := <-minedChan:
+		if tx.BlockNumber != 101 || tx.Dwell != 30*time.Second {
+			t.Errorf("mined in block %d after %s, want block 101 after 30s", tx.BlockNumber, tx.Dwell)
+		}
+	default:
+		t.Fatal("transaction pending before the restart wasn't found in the backfilled blocks")
+	}
+	if got := m.lastBlock.Load(); got != 103 {
+		t.Errorf("last block %d after backfilling, want 103", got)
+	}
+}
+
+func TestBackfillIsCapped(t *testing.T) {
+	t.Cleanup(func() { backfillMaxBlocks = defaultBackfillMaxBlocks })
+
+	tests := []struct {
+		name      string
+		max       int
+		head      uint64
+		wantFirst uint64 // 0 when nothing is scanned
+	}{
+		{name: "within the cap", max: 10, head: 105, wantFirst: 101},
+		{name: "gap over the cap", max: 2, head: 110, wantFirst: 108},
+		{name: "disabled", max: 0, head: 110},
+		{name: "no gap", max: 10, head: 101},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backfillMaxBlocks = tt.max
+			m := &Monitor{chain: &cache.Chain{}, resumeFrom: 100} // Nothing watched, so no block is fetched
+			m.backfill(context.Background(), tt.head)
+
+			want := tt.head - 1
+			if tt.wantFirst == 0 {
+				want = 0
+			}
+			if got := m.lastBlock.Load(); got != want {
+				t.Errorf("last block %d, want %d", got, want)
+			}
+		})
+	}
+}
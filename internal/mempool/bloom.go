@@ -0,0 +1,107 @@
+package mempool
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Default false-positive rate of the watched address filter (BLOOM_FP_RATE)
+const defaultBloomFPRate = 0.01
+
+// With BLOOM_FILTER, a filter of the watched addresses rules out recipients before the address index
+// is consulted, and transactions found by polling txpool_content are skipped before they are fetched
+// when it rules out their recipient. Read in Setup.
+var (
+	bloomPrefilter bool
+	bloomFPRate    = defaultBloomFPRate
+)
+
+// mayWatch reports whether a polled transaction to the address may match and so must be fetched. Only
+// the chain's Bloom filter is consulted; the address index still decides once the transaction is
+// fetched. Every transaction is fetched when transactions to unwatched contracts can match too, or
+// when the gas price statistics sample all of them.
+func (m *Monitor) mayWatch(to string) bool {
+	m.contractsMu.RLock()
+	filter := m.watchedFilter
+	m.contractsMu.RUnlock()
+
+	switch {
+	case filter == nil, genericDecode, watchFrom != nil:
+		return true
+	case pendingGasPrices != nil, gasHistory != nil && gasHistory.scope == gasScopeAll:
+		return true
+	case !common.IsHexAddress(to):
+		return false // Contract creations are never sent to a watched contract
+	default:
+		return filter.mayContain(common.HexToAddress(to).Bytes())
+	}
+}
+
+// bloomFilter is a fixed-size Bloom filter used for fast negative membership checks
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // Number of bits
+	k    uint64 // Number of hash functions
+}
+
+// newBloomFilter sizes a filter for n items at the given false-positive rate
+func newBloomFilter(n int, fpRate float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	// Standard sizing: m = -n*ln(p)/ln(2)^2 bits and k = m/n*ln(2) hash functions
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// add inserts an item into the filter
+func (b *bloomFilter) add(item []byte) {
+	h1, h2 := bloomHashes(item)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// mayContain reports false only if the item was definitely never added
+func (b *bloomFilter) mayContain(item []byte) bool {
+	h1, h2 := bloomHashes(item)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes derives the two base hashes used for double hashing
+func bloomHashes(item []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(item)
+	h1 := h.Sum64()
+
+	h = fnv.New64()
+	h.Write(item)
+	h2 := h.Sum64() | 1 // Odd step so every probe sequence covers distinct bits
+
+	return h1, h2
+}
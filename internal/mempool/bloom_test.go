@@ -0,0 +1,118 @@
+package mempool
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"eth-mempool-monitor/internal/cache"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestBloomFilterMembership(t *testing.T) {
+	const n = 1000
+	filter := newBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		filter.add(common.BigToAddress(big.NewInt(int64(i))).Bytes())
+	}
+
+	// Added addresses are always reported, and others only at about the configured rate
+	for i := 0; i < n; i++ {
+		if addr := common.BigToAddress(big.NewInt(int64(i))); !filter.mayContain(addr.Bytes()) {
+			t.Fatalf("%s was added but isn't reported", addr.Hex())
+		}
+	}
+	falsePositives := 0
+	for i := n; i < 11*n; i++ {
+		if filter.mayContain(common.BigToAddress(big.NewInt(int64(i))).Bytes()) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / (10 * n); rate > 0.03 {
+		t.Errorf("false-positive rate %.3f, want about 0.01", rate)
+	}
+}
+
+// newTxpoolServer serves a txpool_content result with a transaction to the Uniswap V2 router, one to
+// another address and a contract creation
+func newTxpoolServer(t *testing.T) *rpc.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"pending":{"0x1111111111111111111111111111111111111111":{
+			"0":{"hash":"0xaa","to":"0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"},
+			"1":{"hash":"0xbb","to":"0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+			"2":{"hash":"0xcc","to":null}}},"queued":{}}}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// With BLOOM_FILTER, polled transactions the filter rules out are counted but never fetched
+func TestPollTxpoolSkipsUnwatchedRecipients(t *testing.T) {
+	bloomPrefilter = true
+	t.Cleanup(func() { bloomPrefilter = false; genericDecode = false })
+
+	m := &Monitor{chain: &cache.Chain{ID: 1, Client: newTxpoolServer(t)}}
+	m.indexContracts([]Contract{{Name: "UniswapV2Router", Address: "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"}})
+
+	tests := []struct {
+		name          string
+		genericDecode bool
+		want          []string
+	}{
+		{"only the watched contract is fetched", false, []string{"0xaa"}},
+		{"everything is fetched with GENERIC_DECODE", true, []string{"0xaa", "0xbb", "0xcc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			genericDecode = tt.genericDecode
+			counted := atomic.LoadUint64(&txCount)
+
+			fresh, current, err := m.pollTxpool(context.Background(), map[string]bool{})
+			if err != nil {
+				t.Fatalf("pollTxpool: %v", err)
+			}
+			slices.Sort(fresh)
+			if !slices.Equal(fresh, tt.want) {
+				t.Errorf("fetched %v, want %v", fresh, tt.want)
+			}
+			if len(current) != 3 {
+				t.Errorf("%d pending hashes recorded, want 3", len(current))
+			}
+			if skipped := atomic.LoadUint64(&txCount) - counted; skipped != uint64(3-len(tt.want)) {
+				t.Errorf("%d skipped transactions counted, want %d", skipped, 3-len(tt.want))
+			}
+		})
+	}
+}
+
+// With BLOOM_FILTER, matching a recipient consults the filter before the address index
+func TestMatchContractConsultsFilter(t *testing.T) {
+	bloomPrefilter = true
+	t.Cleanup(func() { bloomPrefilter = false })
+
+	router := "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+	m := &Monitor{}
+	m.indexContracts([]Contract{{Name: "UniswapV2Router", Address: router}})
+	if _, ok := m.matchContract(router); !ok {
+		t.Fatal("watched contract isn't matched")
+	}
+
+	// A filter that rules the address out is trusted without looking it up
+	m.watchedFilter = newBloomFilter(1, defaultBloomFPRate)
+	if _, ok := m.matchContract(router); ok {
+		t.Error("address ruled out by the filter was still matched")
+	}
+}
@@ -0,0 +1,76 @@
+package mempool
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+	"eth-mempool-monitor/internal/sink"
+)
+
+// Capture files are capped by default so a long-running capture doesn't fill the disk
+const (
+	defaultCaptureRotateMB = 100
+	defaultCaptureMaxFiles = 10
+)
+
+// Raw WebSocket messages are appended here when CAPTURE_FILE is set, for later replay
+var captureSink *sink.Buffered[capturedMessage]
+
+// Buffering, rotation and syncing of the capture file, read in Setup
+var (
+	captureBuffer   sink.BufferConfig
+	captureRotation sink.RotationPolicy
+	captureFsync    bool
+)
+
+// openCapture starts capturing raw messages to CAPTURE_FILE. The file is rotated at CAPTURE_ROTATE_MB
+// (default 100) and at most CAPTURE_MAX_FILES (default 10) rotated files are kept.
+func openCapture() {
+	path := os.Getenv("CAPTURE_FILE")
+	if path == "" {
+		return
+	}
+
+	policy := captureRotation
+	if policy.MaxBytes == 0 {
+		policy.MaxBytes = defaultCaptureRotateMB << 20
+	}
+	if policy.MaxFiles == 0 {
+		policy.MaxFiles = defaultCaptureMaxFiles
+	}
+	captureFile, err := sink.NewJSONLSink[capturedMessage](path, policy, captureFsync)
+	if err != nil {
+		logging.Errorf("Failed to open capture file: %v", err)
+		return
+	}
+	captureSink = sink.NewBuffered[capturedMessage](captureFile, captureBuffer)
+	logging.Infof("Capturing raw messages to %s", path)
+}
+
+// capture appends a raw message with its receive time to the capture file, if capturing
+func capture(message []byte, received time.Time) {
+	if captureSink == nil {
+		return
+	}
+
+	// A message that isn't valid JSON would make the whole batch fail to encode
+	if !json.Valid(message) {
+		return
+	}
+	if err := captureSink.Write(capturedMessage{Received: received, Message: json.RawMessage(message)}); err != nil && err != sink.ErrClosed {
+		logging.Warnf("Failed to capture message: %v", err)
+	}
+}
+
+// closeCapture flushes any buffered messages and closes the capture file
+func closeCapture() {
+	if captureSink == nil {
+		return
+	}
+	if err := captureSink.Close(); err != nil {
+		logging.Errorf("Failed to close capture file: %v", err)
+	}
+	captureSink = nil
+}
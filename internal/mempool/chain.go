@@ -0,0 +1,265 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+)
+
+// ChainConfig holds the endpoints and watched contracts of one chain
+type ChainConfig struct {
+	Name          string `json:"name"`    // Labels the chain's transactions; may be empty with a single chain
+	ChainID       uint64 `json:"chainId"` // Keys the chain's tokens in the token cache
+	WSEndpoint    string `json:"wsEndpoint"`
+	HTTPSEndpoint string `json:"httpsEndpoint"` // May list several endpoints separated by commas
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	ContractsPath string `json:"contractsPath"`
+	Transport     string `json:"transport"`    // "ws" (the default) or "poll"
+	NativeSymbol  string `json:"nativeSymbol"` // Native currency amounts are shown in; defaults to ETH
+
+	// eth_subscribe params for pending transactions, e.g. ["alchemy_pendingTransactions",{"toAddress":["0x..."]}];
+	// defaults to ["newPendingTransactions"]
+	Subscription json.RawMessage `json:"subscription,omitempty"`
+}
+
+// LoadChains loads the chains to monitor from a JSON file (CHAINS_PATH). Every chain needs a unique
+// name and chain id, so their transactions can be told apart and their tokens cached separately.
+func LoadChains(filename string) ([]ChainConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chains file: %w", err)
+	}
+
+	var chains []ChainConfig
+	if err := json.Unmarshal(data, &chains); err != nil {
+		return nil, fmt.Errorf("failed to parse chains file: %w", err)
+	}
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("no chains configured in %s", filename)
+	}
+
+	names := make(map[string]bool, len(chains))
+	ids := make(map[uint64]bool, len(chains))
+	for i, chain := range chains {
+		switch {
+		case chain.Name == "":
+			return nil, fmt.Errorf("chain %d in %s has no name", i, filename)
+		case names[chain.Name]:
+			return nil, fmt.Errorf("chain name %q is used more than once in %s", chain.Name, filename)
+		case chain.ChainID == 0:
+			return nil, fmt.Errorf("chain %q in %s has no chainId", chain.Name, filename)
+		case ids[chain.ChainID]:
+			return nil, fmt.Errorf("chain id %d is used more than once in %s", chain.ChainID, filename)
+		}
+		names[chain.Name], ids[chain.ChainID] = true, true
+		if chain.ContractsPath == "" {
+			chains[i].ContractsPath = defaultContractsPath
+		}
+	}
+	return chains, nil
+}
+
+// Monitor follows the mempool of one chain. It holds the chain's endpoints, RPC clients and watched
+// contracts; the processing workers, filters, sinks and stats are shared by the monitors of all chains.
+type Monitor struct {
+	name          string // Empty for a single chain configured without CHAIN_NAME
+	chainID       uint64
+	wsEndpoint    string
+	httpsEndpoint string
+	username      string
+	password      string
+	transport     string
+	subscription  json.RawMessage // Custom eth_subscribe params; nil for newPendingTransactions
+
+	httpClient *http.Client // Transaction lookups, failing over between the HTTPS endpoints
+	batcher    *rpcBatcher  // nil when every hash is fetched on its own
+	chain      *cache.Chain // Other RPC calls and token lookups; its client is set once monitoring starts
+	signerID   *big.Int     // Chain id transactions are signed for, from eth_chainId; nil unless verifying senders
+
+	// Watched contracts indexed by address. The set is replaced wholesale when the contracts file
+	// changes.
+	contractsMu        sync.RWMutex
+	contracts          []Contract
+	contractsByAddress map[common.Address]Contract
+	watchedFilter      *bloomFilter // Rules out recipients before the address lookup and polled transactions before they are fetched; nil without BLOOM_FILTER
+	contractsPath      string
+
+	// Matched transactions awaiting inclusion in the chain's blocks, keyed by lowercase hash with
+	// their first-seen time
+	pendingMu      sync.Mutex
+	watchedPending map[string]time.Time
+
+	lastBlock  atomic.Uint64 // Highest block processed, persisted to resume after a restart
+	resumeFrom uint64        // Last block processed by the previous run; 0 when starting fresh
+	resumeOnce sync.Once     // Backfills the blocks missed since the previous run on the first head
+
+	connectedAt       atomic.Int64                   // When the subscription came up (unix nanoseconds); 0 while down
+	firstMessageAt    atomic.Int64                   // When the first message arrived (unix nanoseconds); 0 before
+	headsSubscription atomic.Value                   // Id of the newHeads subscription on the current connection (string)
+	conn              atomic.Pointer[websocket.Conn] // Current WebSocket connection; nil while reconnecting
+	filterUnsupported atomic.Bool                    // The provider rejected alchemy_pendingTransactions
+	resubscribing     atomic.Bool                    // The connection is being closed to change its filter
+	latestBaseFee     atomic.Pointer[big.Int]
+	suggestedGasPrice atomic.Pointer[big.Int] // Latest eth_gasPrice; nil until the first successful poll
+}
+
+// Monitors of the configured chains, in configuration order; set by Setup
+var monitors []*Monitor
+
+// newMonitor sets up the monitor of a chain and loads its watched contracts
+func newMonitor(cfg ChainConfig) (*Monitor, error) {
+	m := &Monitor{
+		name:          cfg.Name,
+		chainID:       cfg.ChainID,
+		wsEndpoint:    cfg.WSEndpoint,
+		httpsEndpoint: cfg.HTTPSEndpoint,
+		username:      cfg.Username,
+		password:      cfg.Password,
+		transport:     cfg.Transport,
+		httpClient:    &http.Client{Timeout: rpcTimeout, Transport: rpcTransport},
+		contractsPath: cfg.ContractsPath,
+		chain:         &cache.Chain{ID: cfg.ChainID, Native: cfg.NativeSymbol}, // The client is added once monitoring starts
+	}
+	if m.transport == "" {
+		m.transport = transportWS
+	}
+	if len(cfg.Subscription) > 0 {
+		params, err := parseSubscription(cfg.Subscription)
+		if err != nil {
+			return nil, m.errorf("invalid subscription params: %w", err)
+		}
+		m.subscription = params
+		if fullPendingTransactions {
+			m.logf(slog.LevelWarn, "PENDING_FULL_TX is ignored with custom subscription params")
+		}
+		if serverFilter {
+			m.logf(slog.LevelWarn, "ALCHEMY_FILTER is ignored with custom subscription params")
+		}
+	}
+
+	// The HTTPS endpoint may list several endpoints; requests fail over between them
+	if pool, err := newEndpointPool(m.httpsEndpoint, endpointCooldown); err == nil {
+		m.httpClient.Transport = &failoverTransport{pool: pool, base: rpcTransport}
+		m.httpsEndpoint = pool.endpoints[0].url.String()
+		if len(pool.endpoints) > 1 {
+			m.logf(slog.LevelInfo, "Using %d HTTPS endpoints with failover", len(pool.endpoints))
+		}
+	}
+
+	if rpcBatchSize > 1 {
+		m.batcher = newRPCBatcher(m, rpcBatchSize, rpcBatchWindow)
+	}
+
+	loaded, err := LoadContracts(m.contractsPath)
+	if err != nil {
+		return nil, m.errorf("error loading contracts: %w", err)
+	}
+	m.indexContracts(loaded)
+	return m, nil
+}
+
+// logf logs a message about this chain at the level, naming the chain when it has a name
+func (m *Monitor) logf(level slog.Level, format string, args ...interface{}) {
+	if m.name != "" {
+		format = m.name + ": " + format
+	}
+	logging.Logf(level, format, args...)
+}
+
+// errorf builds an error about this chain, naming the chain when it has a name
+func (m *Monitor) errorf(format string, args ...interface{}) error {
+	if m.name != "" {
+		format = "chain " + m.name + ": " + format
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// dial creates the chain's RPC client, authenticated like the transaction lookups
+func (m *Monitor) dial() error {
+	rpcOptions := []rpc.ClientOption{rpc.WithHTTPClient(m.httpClient), rpc.WithHeader("User-Agent", userAgent)}
+	if m.hasBasicAuth() {
+		rpcOptions = append(rpcOptions, rpc.WithHeader("Authorization", "Basic "+basicAuth(m.username, m.password)))
+	}
+	client, err := rpc.DialOptions(context.Background(), m.httpsEndpoint, rpcOptions...)
+	if err != nil {
+		return m.errorf("failed to create RPC client: %w", err)
+	}
+	m.chain.Client = client
+	return nil
+}
+
+// receive feeds the chain's pending transactions to msgChan until the context is cancelled, over the
+// WebSocket subscription or by polling the HTTPS endpoint
+func (m *Monitor) receive(ctx context.Context, msgChan chan<- wsMessage) {
+	if m.transport == transportPoll {
+		m.pollPendingTransactions(ctx, msgChan)
+		return
+	}
+	dialer, header := m.wsDialer()
+	m.maintainConnection(ctx, dialer, header, msgChan)
+}
+
+// wsDialer sets up a dialer for the chain's WebSocket endpoint and the handshake headers, with basic
+// authentication when credentials are configured. Providers that take an API key in the endpoint URL
+// need no header. A handshake the provider never answers gives up after RPC_TIMEOUT.
+func (m *Monitor) wsDialer() (*websocket.Dialer, http.Header) {
+	dialer := &websocket.Dialer{
+		Proxy:            proxyFunc,
+		Subprotocols:     wsSubprotocols,
+		HandshakeTimeout: rpcTimeout,
+	}
+	header := http.Header{}
+	if m.hasBasicAuth() {
+		header.Set("Authorization", "Basic "+basicAuth(m.username, m.password))
+	}
+	header.Set("User-Agent", userAgent)
+	if wsOrigin != "" {
+		header.Set("Origin", wsOrigin)
+	}
+	return dialer, header
+}
+
+// hasBasicAuth reports whether basic authentication credentials are configured. Without them no
+// Authorization header is sent, for providers that reject an empty one or authenticate by URL.
+func (m *Monitor) hasBasicAuth() bool {
+	return m.username != "" && m.password != ""
+}
+
+// monitorForChain returns the monitor of a chain, or the first monitor when the chain is unknown
+func monitorForChain(chain *cache.Chain) *Monitor {
+	for _, m := range monitors {
+		if m.chain == chain {
+			return m
+		}
+	}
+	return monitors[0]
+}
+
+// innerCallABI looks up the ABI of a call wrapped in a Safe transaction among the contracts watched
+// on the chain the transaction was seen on
+func innerCallABI(chain *cache.Chain, to string) (string, bool) {
+	return monitorForChain(chain).contractABI(to)
+}
+
+// Endpoint failover and lookup batching settings shared by every chain, read in Setup
+var (
+	endpointCooldown = defaultEndpointCooldown
+	rpcTimeout       = defaultRPCTimeout
+	rpcBatchSize     = defaultRPCBatchSize
+	rpcBatchWindow   time.Duration // Zero uses defaultRPCBatchWindow
+)
@@ -0,0 +1,39 @@
+package mempool
+
+import (
+	"net/http"
+	"testing"
+)
+
+// The WebSocket and RPC headers must match what net/http sends for the same credentials
+func TestBasicAuthMatchesSetBasicAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+	}{
+		{name: "plain", username: "user", password: "secret"},
+		{name: "colon in password", username: "user", password: "se:cr:et"},
+		{name: "non-ascii", username: "usér", password: "pässwörd"},
+		{name: "padding", username: "a", password: "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+			want.SetBasicAuth(tt.username, tt.password)
+
+			m := &Monitor{username: tt.username, password: tt.password}
+			_, header := m.wsDialer()
+			if got := header.Get("Authorization"); got != want.Header.Get("Authorization") {
+				t.Errorf("Authorization = %q, want %q", got, want.Header.Get("Authorization"))
+			}
+		})
+	}
+
+	// Without both credentials no header is sent
+	m := &Monitor{username: "user"}
+	if _, header := m.wsDialer(); header.Get("Authorization") != "" {
+		t.Errorf("Authorization sent without a password: %q", header.Get("Authorization"))
+	}
+}
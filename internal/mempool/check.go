@@ -0,0 +1,146 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+)
+
+// errCheckFailed is returned by Check when any of its checks fails
+var errCheckFailed = errors.New("one or more checks failed")
+
+// Check verifies every chain's contracts and endpoints without monitoring: it calls eth_chainId and
+// eth_getTransactionByHash over HTTPS and subscribes to pending transactions over the WebSocket, or
+// fetches the txpool when polling. Each check is written to w as a line; the error reports whether
+// any failed. Setup must have succeeded, which already validated the settings and contract ABIs.
+func Check(ctx context.Context, w io.Writer) error {
+	failed := false
+	report := func(m *Monitor, name string, err error, detail string) {
+		if m.name != "" {
+			name = m.name + "/" + name
+		}
+		if err != nil {
+			failed = true
+			fmt.Fprintf(w, "[FAIL] %-20s %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(w, "[PASS] %-20s %s\n", name, detail)
+	}
+
+	for _, m := range monitors {
+		report(m, "contracts", nil, fmt.Sprintf("%d contracts with valid ABIs in %s", m.watchedContractCount(), m.contractsPath))
+
+		if err := m.dial(); err != nil {
+			report(m, "rpc", err, "")
+			continue
+		}
+		chainID, err := m.checkChainID(ctx)
+		report(m, "eth_chainId", err, fmt.Sprintf("chain id %s", chainID))
+		report(m, "lookup", m.checkLookup(ctx), "eth_getTransactionByHash answered")
+
+		if m.transport == transportPoll {
+			report(m, "txpool_content", m.checkTxpool(ctx), "txpool answered")
+		} else {
+			detail, err := m.checkSubscription(ctx)
+			report(m, "subscription", err, detail)
+		}
+		m.chain.Client.Close()
+	}
+
+	if failed {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// checkChainID fetches the endpoint's chain id, failing when it differs from the configured one
+func (m *Monitor) checkChainID(ctx context.Context) (*big.Int, error) {
+	callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	var id hexutil.Big
+	if err := m.chain.Client.CallContext(callCtx, &id, "eth_chainId"); err != nil {
+		return nil, fmt.Errorf("eth_chainId failed: %w", err)
+	}
+	chainID := (*big.Int)(&id)
+	if m.chainID != 0 && chainID.Cmp(new(big.Int).SetUint64(m.chainID)) != 0 {
+		return nil, fmt.Errorf("endpoint is on chain id %s, but chain id %d is configured", chainID, m.chainID)
+	}
+	return chainID, nil
+}
+
+// checkLookup looks up the zero hash, which no transaction has, to confirm transaction lookups are
+// answered
+func (m *Monitor) checkLookup(ctx context.Context) error {
+	callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	var tx json.RawMessage
+	if err := m.chain.Client.CallContext(callCtx, &tx, "eth_getTransactionByHash", common.Hash{}); err != nil {
+		return fmt.Errorf("eth_getTransactionByHash failed: %w", err)
+	}
+	return nil
+}
+
+// checkTxpool confirms the endpoint serves txpool_content, which polling relies on
+func (m *Monitor) checkTxpool(ctx context.Context) error {
+	callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	var content json.RawMessage
+	if err := m.chain.Client.CallContext(callCtx, &content, "txpool_content"); err != nil {
+		return fmt.Errorf("txpool_content failed: %w", err)
+	}
+	return nil
+}
+
+// checkSubscription dials the WebSocket endpoint and subscribes to pending transactions as
+// monitoring would, confirming the provider accepts the subscription. A rejected server-side filter
+// isn't a failure, since monitoring falls back to newPendingTransactions.
+func (m *Monitor) checkSubscription(ctx context.Context) (string, error) {
+	filtered, ok := m.filteredSubscription()
+	if !ok {
+		return m.trySubscription(ctx, m.pendingSubscription())
+	}
+	detail, err := m.trySubscription(ctx, filtered)
+	if err == nil {
+		return detail, nil
+	}
+	fallback, fallbackErr := m.trySubscription(ctx, m.pendingSubscription())
+	if fallbackErr != nil {
+		return "", fallbackErr
+	}
+	return fmt.Sprintf("%s; server-side filter unavailable (%v)", fallback, err), nil
+}
+
+// trySubscription dials the WebSocket endpoint and sends an eth_subscribe request with the params
+func (m *Monitor) trySubscription(ctx context.Context, params json.RawMessage) (string, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	dialer, header := m.wsDialer()
+	conn, resp, err := dialer.DialContext(dialCtx, m.wsEndpoint, header)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to WebSocket: %s", describeHandshakeFailure(err, resp))
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(subscribeRequest(params))); err != nil {
+		return "", fmt.Errorf("failed to subscribe: %w", err)
+	}
+	rpcErr, err := awaitSubscribeResponse(conn)
+	if err != nil {
+		return "", err
+	}
+	if rpcErr != nil {
+		return "", fmt.Errorf("provider rejected the %s subscription: %s", subscriptionName(params), rpcErr.Message)
+	}
+	return fmt.Sprintf("%s subscription accepted", subscriptionName(params)), nil
+}
@@ -2,11 +2,69 @@ package mempool
 
 import (
 	"encoding/json"
+	"errors"
+	"eth-mempool-monitor/internal/decoder"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
 )
 
+// Default location of the watched contracts
+const defaultContractsPath = "configs/contracts.json"
+
+// Config holds the settings passed to Setup. Optional settings are still read from the environment.
+type Config struct {
+	Chains     []ChainConfig // Chains to monitor, at least one
+	ChainsPath string        // File the chains were loaded from; empty when read from the environment
+}
+
+// ConfigFromEnv loads the .env file, when there is one, and reads the Config from the environment.
+// CHAINS_PATH names a file listing several chains; without it a single chain is read from
+// WS_ENDPOINT, HTTPS_ENDPOINT and the related variables.
+func ConfigFromEnv() (Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("error loading .env file: %w", err)
+	}
+
+	if path := os.Getenv("CHAINS_PATH"); path != "" {
+		chains, err := LoadChains(path)
+		if err != nil {
+			return Config{}, err
+		}
+		return Config{Chains: chains, ChainsPath: path}, nil
+	}
+
+	chain := ChainConfig{
+		Name:          os.Getenv("CHAIN_NAME"),
+		WSEndpoint:    os.Getenv("WS_ENDPOINT"),
+		HTTPSEndpoint: os.Getenv("HTTPS_ENDPOINT"),
+		Username:      os.Getenv("USERNAME"),
+		Password:      os.Getenv("PASSWORD"),
+		ContractsPath: os.Getenv("CONTRACTS_PATH"),
+		Transport:     os.Getenv("TRANSPORT"),
+		NativeSymbol:  os.Getenv("NATIVE_SYMBOL"),
+	}
+	if v := os.Getenv("SUBSCRIPTION_PARAMS"); v != "" {
+		chain.Subscription = json.RawMessage(v)
+	}
+	if v := os.Getenv("CHAIN_ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CHAIN_ID %q", v)
+		}
+		chain.ChainID = id
+	}
+	if chain.ContractsPath == "" {
+		chain.ContractsPath = defaultContractsPath
+	}
+	return Config{Chains: []ChainConfig{chain}}, nil
+}
+
 // Contract represents a contract's address and ABI
 type Contract struct {
 	Name    string          `json:"name"`
@@ -35,5 +93,22 @@ func LoadContracts(filename string) ([]Contract, error) {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
+	// Catch broken ABIs now rather than when the first transaction fails to decode. This also caches
+	// the parsed ABIs for the decoder.
+	for _, contract := range contracts {
+		if _, err := decoder.ParseABI(string(contract.ABI)); err != nil {
+			return nil, fmt.Errorf("contract %q (%s): %w", contract.Name, contract.Address, err)
+		}
+	}
+
 	return contracts, nil
 }
+
+// warnIfNoContracts makes an empty watchlist visible, since nothing will ever match without contracts.
+// This is deliberately not fatal so the monitor can still be run to observe TPS. With GENERIC_DECODE
+// transactions to any contract can still match.
+func (m *Monitor) warnIfNoContracts() {
+	if m.watchedContractCount() == 0 && !genericDecode {
+		m.logf(slog.LevelWarn, "No contracts are being watched, so no transactions will be matched or decoded. Add entries to %s, or set GENERIC_DECODE=true to decode relevant transactions to any contract.", m.contractsPath)
+	}
+}
@@ -0,0 +1,134 @@
+package mempool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"eth-mempool-monitor/internal/cache"
+)
+
+// inTempDir runs the test from an empty directory so Setup finds none of the repo's data files
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// Setup takes its chains from the Config, so tests and commands can configure them without the environment
+func TestSetupUsesConfigChains(t *testing.T) {
+	dir := inTempDir(t)
+	contracts := filepath.Join(dir, "contracts.json")
+	if err := os.WriteFile(contracts, []byte(`[]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WS_ENDPOINT", "wss://env.example.com") // Must not be used
+	t.Cleanup(func() { monitors = nil })
+
+	cfg := Config{
+		ChainsPath: "chains.json",
+		Chains: []ChainConfig{
+			{Name: "mainnet", ChainID: 1, WSEndpoint: "wss://mainnet.example.com", HTTPSEndpoint: "https://mainnet.example.com", ContractsPath: contracts},
+			{Name: "base", ChainID: 8453, WSEndpoint: "wss://base.example.com", HTTPSEndpoint: "https://base.example.com", ContractsPath: contracts},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Setup(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(monitors) != 2 {
+		t.Fatalf("Setup created %d monitors, want 2", len(monitors))
+	}
+	for i, chain := range cfg.Chains {
+		if m := monitors[i]; m.name != chain.Name || m.chainID != chain.ChainID || m.chain.ID != chain.ChainID {
+			t.Errorf("monitor %d is chain %q (%d), want %q (%d)", i, m.name, m.chainID, chain.Name, chain.ChainID)
+		}
+	}
+}
+
+func TestSetupRejectsConfigWithoutChains(t *testing.T) {
+	if err := Setup(Config{}); err == nil {
+		t.Error("Setup accepted a config without chains")
+	}
+}
+
+// Setup reports every invalid optional setting at once rather than ignoring some and stopping at others
+func TestSetupReportsEveryInvalidSetting(t *testing.T) {
+	inTempDir(t)
+	invalid := map[string]string{
+		"WORKER_COUNT":      "0",
+		"RPC_BATCH_SIZE":    "many",
+		"SLOW_TX_THRESHOLD": "2",
+		"NEW_HEADS":         "yes please",
+		"PENDING_FULL_TX":   "maybe",
+		"RPC_TIMEOUT":       "0s",
+		"DEDUP_WINDOW":      "-1m",
+		"MIN_VALUE_ETH":     "dust",
+		"BLOOM_FP_RATE":     "1.5",
+		"JSONL_FSYNC":       "sometimes",
+		"JSONL_BATCH_SIZE":  "0",
+	}
+	t.Setenv("JSONL_PATH", "matched.jsonl")
+	for name, value := range invalid {
+		t.Setenv(name, value)
+	}
+	t.Cleanup(func() { monitors = nil })
+
+	err := Setup(Config{Chains: []ChainConfig{{WSEndpoint: "wss://node.example.com", HTTPSEndpoint: "https://node.example.com"}}})
+	if err == nil {
+		t.Fatal("Setup accepted invalid settings")
+	}
+	for name, value := range invalid {
+		if want := fmt.Sprintf("%s %q", name, value); !strings.Contains(err.Error(), want) {
+			t.Errorf("Setup error doesn't report %s:\n%v", want, err)
+		}
+	}
+	if monitors != nil {
+		t.Error("Setup set up the chains despite invalid settings")
+	}
+}
+
+// Validate reports every problem at once, using the names the chains file gives the settings
+func TestValidateReportsEveryChain(t *testing.T) {
+	cfg := Config{
+		ChainsPath: "chains.json",
+		Chains: []ChainConfig{
+			{Name: "mainnet", HTTPSEndpoint: "https://mainnet.example.com"},
+			{Name: "base", WSEndpoint: "https://base.example.com"},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted chains without endpoints")
+	}
+	for _, want := range []string{"chain mainnet in chains.json: wsEndpoint is not set", "chain base in chains.json: httpsEndpoint is not set", "chain base in chains.json: wsEndpoint"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't report %q", err, want)
+		}
+	}
+}
+
+// A chain whose RPC client can't be set up stops MonitorMempool with an error instead of a silent return
+func TestMonitorMempoolReportsDialFailure(t *testing.T) {
+	monitors = []*Monitor{{name: "mainnet", httpsEndpoint: "ftp://node.example.com", chain: &cache.Chain{ID: 1}}}
+	t.Cleanup(func() { monitors = nil })
+
+	err := MonitorMempool(context.Background(), nil, nil, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "chain mainnet") {
+		t.Errorf("MonitorMempool = %v, want the mainnet dial error", err)
+	}
+}
@@ -0,0 +1,143 @@
+package mempool
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Reconnection backoff bounds; the delay doubles after every failed attempt
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// JSON-RPC id of the pending transaction subscribe request
+const pendingSubscribeID = 1
+
+// fullPendingTransactions asks the provider to include full transaction objects in pending
+// transaction notifications, which saves an eth_getTransactionByHash call per hash (PENDING_FULL_TX=true)
+var fullPendingTransactions bool
+
+// connect dials the chain's WebSocket endpoint and subscribes to new pending transactions, filtered
+// by the provider or with the chain's custom subscription params when configured. Dialing gives up
+// when the context is cancelled.
+func (m *Monitor) connect(ctx context.Context, dialer *websocket.Dialer, header http.Header) (*websocket.Conn, error) {
+	conn, resp, err := dialer.DialContext(ctx, m.wsEndpoint, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to WebSocket: %s", describeHandshakeFailure(err, resp))
+	}
+
+	// Prefer having the provider filter transactions to the watched contracts, when enabled
+	subscribed := false
+	if params, ok := m.filteredSubscription(); ok {
+		if subscribed, err = m.subscribeFiltered(conn, params); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if !subscribed {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(subscribeRequest(m.pendingSubscription()))); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe: %w", err)
+		}
+	}
+
+	// Optionally follow new blocks over the same connection; the id is picked up from the response
+	if subscribeHeads {
+		m.headsSubscription.Store("")
+		subscribe := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"eth_subscribe","params":["newHeads"]}`, headsSubscribeID)
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(subscribe)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe to newHeads: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// readMessages forwards messages from the connection to msgChan until a read fails or the context
+// is cancelled. onMessage is called after every successful read.
+func (m *Monitor) readMessages(ctx context.Context, conn *websocket.Conn, msgChan chan<- wsMessage, onMessage func()) error {
+	// Closing the connection unblocks ReadMessage once the context is cancelled. The provider is told
+	// first with a close frame so it can end the subscription cleanly.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			closeFrame := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "shutting down")
+			conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(time.Second))
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// Ping the provider so a silently dropped connection is noticed and re-established
+	startHeartbeat(conn, done)
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return describeReadError(err)
+		}
+		if pingInterval > 0 {
+			conn.SetReadDeadline(idleDeadline())
+		}
+		onMessage()
+		m.markMessageReceived()
+		received := time.Now()
+		capture(message, received) // Record the raw stream for later replay when enabled
+		select {
+		case msgChan <- wsMessage{data: string(message), received: received, monitor: m}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// maintainConnection keeps the subscription alive until the context is cancelled, reconnecting with
+// exponential backoff whenever dialing or reading fails. The backoff resets after a successful read.
+func (m *Monitor) maintainConnection(ctx context.Context, dialer *websocket.Dialer, header http.Header, msgChan chan<- wsMessage) {
+	delay := minReconnectDelay
+	for {
+		conn, err := m.connect(ctx, dialer, header)
+		if err == nil {
+			m.markConnected()
+			m.conn.Store(conn)
+			err = m.readMessages(ctx, conn, msgChan, func() { delay = minReconnectDelay })
+			m.conn.Store(nil)
+			conn.Close()
+			m.markDisconnected()
+		}
+
+		// Errors after shutdown are expected since the connection is closed underneath the read
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Connections closed to change the server-side filter are replaced straight away
+		if m.resubscribing.CompareAndSwap(true, false) {
+			m.logf(slog.LevelInfo, "Resubscribing with the reloaded contracts")
+			continue
+		}
+		m.logf(slog.LevelWarn, "WebSocket error: %v; reconnecting in %s", err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+		atomic.AddUint64(&metricReconnects, 1)
+		m.logf(slog.LevelInfo, "Reconnecting to WebSocket...")
+	}
+}
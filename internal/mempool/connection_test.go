@@ -0,0 +1,149 @@
+package mempool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newWSServer starts a WebSocket server that sends the given messages to each client, then either
+// closes the connection or keeps it open until the client goes away
+func newWSServer(t *testing.T, messages []string, closeAfter bool) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, message := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+				return
+			}
+		}
+		if closeAfter {
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialWS connects to a test server
+func dialWS(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	return conn
+}
+
+// waitForGoroutines waits for the number of goroutines to drop back to at most want
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines still running, want at most %d:\n%s", runtime.NumGoroutine(), want, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReadMessagesExitsWithoutLeaking(t *testing.T) {
+	tests := []struct {
+		name       string
+		closeAfter bool // The server closes the connection; otherwise the context is cancelled
+	}{
+		{"connection closed by the server", true},
+		{"context cancelled", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWSServer(t, []string{`{"jsonrpc":"2.0","id":1,"result":"0x1"}`}, tt.closeAfter)
+			before := runtime.NumGoroutine()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			conn := dialWS(t, server)
+			msgChan := make(chan wsMessage, 10)
+			done := make(chan error, 1)
+			go func() { done <- (&Monitor{}).readMessages(ctx, conn, msgChan, func() {}) }()
+
+			// Wait for the message so the reader is known to be running
+			select {
+			case <-msgChan:
+			case <-time.After(2 * time.Second):
+				t.Fatal("no message read")
+			}
+			if !tt.closeAfter {
+				cancel()
+			}
+
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Error("readMessages returned nil, want the reason it stopped")
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("readMessages did not return")
+			}
+			conn.Close()
+			waitForGoroutines(t, before)
+		})
+	}
+}
+
+// A provider that accepts the TCP connection but never answers the handshake must not hold up
+// shutdown or reconnection
+func TestConnectGivesUpOnUnansweredHandshake(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	t.Run("context cancelled", func(t *testing.T) {
+		m := &Monitor{wsEndpoint: endpoint}
+		dialer, header := m.wsDialer()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := m.connect(ctx, dialer, header); err == nil {
+			t.Fatal("connected without a handshake")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("connect returned %s after the context was cancelled", elapsed)
+		}
+	})
+
+	t.Run("handshake timeout", func(t *testing.T) {
+		rpcTimeout = 50 * time.Millisecond
+		t.Cleanup(func() { rpcTimeout = defaultRPCTimeout })
+		m := &Monitor{wsEndpoint: endpoint}
+		dialer, header := m.wsDialer()
+
+		start := time.Now()
+		if _, err := m.connect(context.Background(), dialer, header); err == nil {
+			t.Fatal("connected without a handshake")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("connect returned after %s, want it to give up after RPC_TIMEOUT", elapsed)
+		}
+	})
+}
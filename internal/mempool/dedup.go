@@ -0,0 +1,66 @@
+package mempool
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default deduplication of re-announced transactions
+const (
+	defaultDedupWindow     = 60 * time.Second
+	defaultDedupMaxEntries = 100000
+)
+
+// Hashes seen within the dedup window; nil when deduplication is disabled (DEDUP_WINDOW=0)
+var seenHashes *seenSet
+
+// seenEntry is a hash in the order it was first seen
+type seenEntry struct {
+	hash string
+	at   time.Time
+}
+
+// seenSet remembers recently seen transaction hashes for a fixed window. It holds at most max
+// hashes, forgetting the oldest first, so memory stays bounded during bursts.
+type seenSet struct {
+	window time.Duration
+	max    int
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	order []seenEntry // Oldest first; entries may be stale if the hash was seen again after expiring
+}
+
+func newSeenSet(window time.Duration, max int) *seenSet {
+	if max <= 0 {
+		max = defaultDedupMaxEntries
+	}
+	return &seenSet{window: window, max: max, seen: make(map[string]time.Time)}
+}
+
+// firstSeen records a hash and reports whether it wasn't already seen within the window
+func (s *seenSet) firstSeen(hash string) bool {
+	hash = strings.ToLower(hash)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if at, exists := s.seen[hash]; exists && now.Sub(at) < s.window {
+		return false
+	}
+
+	// Forget expired hashes, then the oldest ones if the set is still full
+	for len(s.order) > 0 && (now.Sub(s.order[0].at) >= s.window || len(s.seen) >= s.max) {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		if s.seen[oldest.hash] == oldest.at {
+			delete(s.seen, oldest.hash)
+		}
+	}
+
+	s.seen[hash] = now
+	s.order = append(s.order, seenEntry{hash: hash, at: now})
+	return true
+}
@@ -0,0 +1,175 @@
+package mempool
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Endpoint health: an endpoint failing this many requests in a row is skipped for the cooldown
+const (
+	endpointFailureThreshold = 3
+	defaultEndpointCooldown  = 30 * time.Second
+)
+
+// rpcEndpoint is one HTTPS endpoint along with its recent health
+type rpcEndpoint struct {
+	url       *url.URL
+	failures  int       // Consecutive failed requests
+	skipUntil time.Time // Not used before this time unless every endpoint is unhealthy
+}
+
+// endpointPool spreads requests round-robin over the configured HTTPS endpoints, skipping
+// endpoints that keep failing until their cooldown has passed
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*rpcEndpoint
+	next      int
+	cooldown  time.Duration
+}
+
+// newEndpointPool parses a comma-separated list of endpoint URLs
+func newEndpointPool(list string, cooldown time.Duration) (*endpointPool, error) {
+	pool := &endpointPool{cooldown: cooldown}
+	for _, raw := range strings.Split(list, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTTPS endpoint %q: %w", raw, err)
+		}
+		pool.endpoints = append(pool.endpoints, &rpcEndpoint{url: u})
+	}
+	if len(pool.endpoints) == 0 {
+		return nil, fmt.Errorf("no HTTPS endpoint configured")
+	}
+	return pool, nil
+}
+
+// order returns the endpoints to try for one request: healthy endpoints in round-robin order,
+// followed by the endpoints that are cooling down as a last resort
+func (p *endpointPool) order() []*rpcEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var healthy, cooling []*rpcEndpoint
+	for i := range p.endpoints {
+		ep := p.endpoints[(p.next+i)%len(p.endpoints)]
+		if now.Before(ep.skipUntil) {
+			cooling = append(cooling, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+	p.next = (p.next + 1) % len(p.endpoints)
+	return append(healthy, cooling...)
+}
+
+// report records the outcome of a request to an endpoint
+func (p *endpointPool) report(ep *rpcEndpoint, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ok {
+		ep.failures = 0
+		ep.skipUntil = time.Time{}
+		return
+	}
+
+	ep.failures++
+	if ep.failures >= endpointFailureThreshold && time.Now().After(ep.skipUntil) {
+		ep.skipUntil = time.Now().Add(p.cooldown)
+		logging.Warnf("HTTPS endpoint %s failed %d requests in a row, skipping it for %s", redactedURL(ep.url), ep.failures, p.cooldown)
+	}
+}
+
+// failoverTransport sends each request to the endpoint pool, retrying on the next endpoint when one
+// errors, times out or answers with a server error or rate limit. The request URL is replaced, so
+// callers can address requests to any of the endpoints.
+type failoverTransport struct {
+	pool *endpointPool
+	base http.RoundTripper
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Buffer the body so it can be replayed against another endpoint
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var lastErr error
+	for _, ep := range t.pool.order() {
+		attempt := req.Clone(req.Context())
+		attempt.URL = ep.url
+		attempt.Host = ep.url.Host
+		attempt.Body = io.NopCloser(bytes.NewReader(body))
+		attempt.ContentLength = int64(len(body))
+		if ep.url.User != nil {
+			// Credentials embedded in this endpoint's URL take precedence over the configured ones
+			password, _ := ep.url.User.Password()
+			attempt.SetBasicAuth(ep.url.User.Username(), password)
+		}
+
+		resp, err := t.base.RoundTrip(attempt)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			t.pool.report(ep, true)
+			logging.Debugf("RPC request served by %s", redactedURL(ep.url))
+			return resp, nil
+		}
+
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("HTTP %s", resp.Status)
+		}
+		t.pool.report(ep, false)
+		lastErr = fmt.Errorf("%s: %w", redactedURL(ep.url), err)
+
+		// Don't keep trying once the caller has given up
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// redactedURL shows an endpoint by its scheme and host only. Providers put API keys in the path or
+// query as often as in the credentials, so url.Redacted isn't enough.
+func redactedURL(u *url.URL) string {
+	redacted := u.Scheme + "://" + u.Host
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+		redacted += "/<redacted>"
+	}
+	return redacted
+}
+
+// addEndpointSecrets registers the parts of an endpoint URL that may hold an API key so they are
+// redacted wherever they turn up in the logs, such as in errors from the HTTP client
+func addEndpointSecrets(raw string) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		logging.AddSecret(raw)
+		return
+	}
+	logging.AddSecret(strings.Trim(u.EscapedPath(), "/"))
+	logging.AddSecret(strings.Trim(u.Path, "/"))
+	logging.AddSecret(u.RawQuery)
+	if password, ok := u.User.Password(); ok {
+		logging.AddSecret(password)
+	}
+}
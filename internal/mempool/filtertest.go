@@ -0,0 +1,99 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrUnknownChain is returned by TestFilter when the named chain isn't configured
+var ErrUnknownChain = errors.New("unknown chain")
+
+// FilterCheck is the outcome of a single filtering step
+type FilterCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// filterTrace collects the outcome of each filtering step. A nil trace records nothing,
+// so the live pipeline pays no formatting cost.
+type filterTrace struct {
+	checks []FilterCheck
+}
+
+// record appends a check outcome to the trace
+func (t *filterTrace) record(name string, passed bool, format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.checks = append(t.checks, FilterCheck{Name: name, Passed: passed, Detail: fmt.Sprintf(format, args...)})
+}
+
+// FilterInput describes a hypothetical transaction to run through the filters
+type FilterInput struct {
+	To    string // Recipient address
+	From  string // Sender address
+	Data  string // Calldata as hex
+	Value string // Value in wei, decimal or 0x-prefixed hex
+	Chain string // Name of the chain whose contracts are matched; empty for the first chain
+}
+
+// FilterResult is the outcome of running a transaction through the filters
+type FilterResult struct {
+	Checks  []FilterCheck // Outcome of each check, in the order they ran
+	Passed  bool          // The transaction would be shown
+	Decoded bool          // The calldata was decoded with the matched contract's ABI
+}
+
+// TestFilter runs a hypothetical transaction through the same filters as the live monitor and
+// reports whether it would be shown, along with the outcome of each check
+func TestFilter(input FilterInput) (FilterResult, error) {
+	value := "0x0"
+	if input.Value != "" {
+		wei, ok := parseWei(input.Value)
+		if !ok {
+			return FilterResult{}, fmt.Errorf("invalid value %q", input.Value)
+		}
+		value = hexutil.EncodeBig(wei)
+	}
+
+	m := monitors[0]
+	if input.Chain != "" {
+		m = nil
+		for _, candidate := range monitors {
+			if candidate.name == input.Chain {
+				m = candidate
+			}
+		}
+		if m == nil {
+			return FilterResult{}, fmt.Errorf("%w %q", ErrUnknownChain, input.Chain)
+		}
+	}
+
+	var result decoder.TransactionResult
+	result.Result.Hash = "(test-filter)"
+	result.Result.To = input.To
+	result.Result.From = input.From
+	result.Result.Input = input.Data
+	result.Result.Value = value
+
+	trace := &filterTrace{}
+	result.Chain = m.chain
+	_, decoded, ok := m.applyFilters(result, trace)
+	return FilterResult{Checks: trace.checks, Passed: ok, Decoded: decoded != nil}, nil
+}
+
+// parseWei parses a wei amount given in decimal or 0x-prefixed hex
+func parseWei(s string) (*big.Int, bool) {
+	if strings.HasPrefix(s, "0x") {
+		wei, err := hexutil.DecodeBig(s)
+		return wei, err == nil
+	}
+	return new(big.Int).SetString(s, 10)
+}
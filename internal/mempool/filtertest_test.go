@@ -0,0 +1,33 @@
+package mempool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// An unknown chain is reported as such, so test-filter can exit with its not-found code
+func TestFilterReportsUnknownChain(t *testing.T) {
+	dir := inTempDir(t)
+	contracts := filepath.Join(dir, "contracts.json")
+	if err := os.WriteFile(contracts, []byte(`[]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { monitors = nil })
+
+	cfg := Config{
+		ChainsPath: "chains.json",
+		Chains: []ChainConfig{
+			{Name: "mainnet", ChainID: 1, WSEndpoint: "wss://mainnet.example.com", HTTPSEndpoint: "https://mainnet.example.com", ContractsPath: contracts},
+		},
+	}
+	if err := Setup(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := TestFilter(FilterInput{To: "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D", Data: "0x095ea7b3", Chain: "base"})
+	if !errors.Is(err, ErrUnknownChain) {
+		t.Errorf("TestFilter with an unknown chain returned %v, want ErrUnknownChain", err)
+	}
+}
@@ -0,0 +1,74 @@
+package mempool
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Defaults for gas price coloring
+const (
+	defaultGasOracleInterval = 12 * time.Second // Roughly one block
+	defaultGasHighRatio      = 1.5              // Bids at least this multiple of the oracle price are aggressive
+	defaultGasLowRatio       = 1.0              // Bids at or below this multiple of the oracle price are patient
+)
+
+// Gas price coloring settings; colorGasPrices is enabled with GAS_PRICE_COLORS=true
+var (
+	colorGasPrices    bool
+	gasOracleInterval = defaultGasOracleInterval
+	gasHighRatio      = defaultGasHighRatio
+	gasLowRatio       = defaultGasLowRatio
+)
+
+// pollGasOracle refreshes the chain's suggested gas price until the context is cancelled
+func (m *Monitor) pollGasOracle(ctx context.Context) {
+	ticker := time.NewTicker(gasOracleInterval)
+	defer ticker.Stop()
+
+	for {
+		var price hexutil.Big
+		if err := m.chain.Client.CallContext(ctx, &price, "eth_gasPrice"); err != nil {
+			if ctx.Err() == nil {
+				m.logf(slog.LevelWarn, "Failed to fetch suggested gas price: %v", err)
+			}
+		} else {
+			m.suggestedGasPrice.Store(price.ToInt())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// formatGasPrice renders a gas price, colored relative to the chain's suggested price when coloring is
+// enabled: red for aggressive bids well above it, green for patient bids at or below it
+func (m *Monitor) formatGasPrice(gasPriceHex string) string {
+	if !colorGasPrices {
+		return formatGwei(gasPriceHex)
+	}
+
+	suggested := m.suggestedGasPrice.Load()
+	price, err := hexutil.DecodeBig(gasPriceHex)
+	if suggested == nil || suggested.Sign() == 0 || err != nil {
+		return formatGwei(gasPriceHex)
+	}
+
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(price), new(big.Float).SetInt(suggested)).Float64()
+	text := fmt.Sprintf("%s, %.2fx suggested", formatGwei(gasPriceHex), ratio)
+	switch {
+	case ratio >= gasHighRatio:
+		return "[red]" + text + "[-]"
+	case ratio <= gasLowRatio:
+		return "[green]" + text + "[-]"
+	default:
+		return text
+	}
+}
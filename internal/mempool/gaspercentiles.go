@@ -0,0 +1,130 @@
+package mempool
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Defaults of the gas price percentile window
+const (
+	defaultGasPercentileWindow     = 60 * time.Second
+	defaultGasPercentileMaxSamples = 100000
+)
+
+// Which transactions feed the percentiles (GAS_PERCENTILE_SCOPE)
+const (
+	gasScopeAll     = "all"
+	gasScopeMatched = "matched"
+)
+
+// GasPercentiles summarises the gas prices, in wei, of pending transactions seen over the window
+type GasPercentiles struct {
+	P10     *big.Int      `json:"p10"`
+	P50     *big.Int      `json:"p50"`
+	P90     *big.Int      `json:"p90"`
+	Samples int           `json:"samples"`
+	Window  time.Duration `json:"windowNs"`
+	Scope   string        `json:"scope"`
+}
+
+// gasSample is a gas price along with when it was seen
+type gasSample struct {
+	at    time.Time
+	price *big.Int
+}
+
+// gasPriceHistory keeps the gas prices seen over a sliding time window, capped at maxSamples
+type gasPriceHistory struct {
+	window     time.Duration
+	maxSamples int
+	scope      string
+
+	mu      sync.Mutex
+	samples []gasSample // Oldest first
+}
+
+// Gas prices for the percentile panel; nil unless GAS_PERCENTILES is enabled
+var gasHistory *gasPriceHistory
+
+// GasPercentilesEnabled reports whether gas price percentiles are collected
+func GasPercentilesEnabled() bool {
+	return gasHistory != nil
+}
+
+// add records a gas price, dropping samples that have left the window
+func (h *gasPriceHistory) add(price *big.Int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.samples = append(h.samples, gasSample{at: now, price: price})
+	h.prune(now)
+}
+
+// prune drops samples older than the window, and the oldest beyond the cap; the caller holds the lock
+func (h *gasPriceHistory) prune(now time.Time) {
+	drop := 0
+	for drop < len(h.samples) && (now.Sub(h.samples[drop].at) > h.window || len(h.samples)-drop > h.maxSamples) {
+		drop++
+	}
+	h.samples = h.samples[drop:]
+}
+
+// snapshot computes the percentiles of the gas prices currently in the window
+func (h *gasPriceHistory) snapshot() *GasPercentiles {
+	h.mu.Lock()
+	h.prune(time.Now())
+	prices := make([]*big.Int, len(h.samples))
+	for i, sample := range h.samples {
+		prices[i] = sample.price
+	}
+	h.mu.Unlock()
+
+	stats := &GasPercentiles{Samples: len(prices), Window: h.window, Scope: h.scope}
+	if len(prices) == 0 {
+		return stats
+	}
+
+	// Gas prices are compared as big integers so huge bids can't overflow
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+	stats.P10 = percentile(prices, 10)
+	stats.P50 = percentile(prices, 50)
+	stats.P90 = percentile(prices, 90)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []*big.Int, p int) *big.Int {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// recordGasPercentile adds a gas price to the percentile window when it matches the configured scope
+func recordGasPercentile(gasPriceHex string, matched bool) {
+	if gasHistory == nil || (gasHistory.scope == gasScopeMatched && !matched) || (gasHistory.scope == gasScopeAll && matched) {
+		return
+	}
+	if price, err := hexutil.DecodeBig(gasPriceHex); err == nil {
+		gasHistory.add(price)
+	}
+}
+
+// FormatGasPercentiles renders the percentiles in Gwei for the TUI panel
+func FormatGasPercentiles(stats *GasPercentiles) string {
+	header := fmt.Sprintf("Gas price (%s txs, last %s)", stats.Scope, stats.Window)
+	if stats.Samples == 0 {
+		return header + ": no samples yet"
+	}
+	return fmt.Sprintf("%s: p10 %s | p50 %s | p90 %s Gwei over %d txs", header,
+		decoder.FormatUnits(stats.P10, gweiDecimals), decoder.FormatUnits(stats.P50, gweiDecimals), decoder.FormatUnits(stats.P90, gweiDecimals), stats.Samples)
+}
@@ -0,0 +1,46 @@
+package mempool
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Default User-Agent sent on WebSocket and RPC requests so providers can identify the tool
+const defaultUserAgent = "eth-mempool-monitor"
+
+// Connection headers some providers require; configured with USER_AGENT, WS_ORIGIN and WS_SUBPROTOCOLS
+var (
+	userAgent      = defaultUserAgent
+	wsOrigin       string
+	wsSubprotocols []string
+)
+
+// parseSubprotocols splits a comma-separated subprotocol list, dropping empty entries
+func parseSubprotocols(list string) []string {
+	var protocols []string
+	for _, protocol := range strings.Split(list, ",") {
+		if protocol = strings.TrimSpace(protocol); protocol != "" {
+			protocols = append(protocols, protocol)
+		}
+	}
+	return protocols
+}
+
+// describeHandshakeFailure explains a rejected WebSocket handshake using the provider's response,
+// which otherwise surfaces only as "bad handshake"
+func describeHandshakeFailure(err error, resp *http.Response) string {
+	if resp == nil {
+		return err.Error()
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	msg := fmt.Sprintf("%v (HTTP %s", err, resp.Status)
+	if text := strings.TrimSpace(string(body)); text != "" {
+		msg += ": " + text
+	}
+	msg += "); the provider may require WS_SUBPROTOCOLS, WS_ORIGIN or USER_AGENT to be set"
+	return msg
+}
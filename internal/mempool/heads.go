@@ -0,0 +1,87 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// JSON-RPC id of the newHeads subscribe request, used to pick out its response
+const headsSubscribeID = 2
+
+// subscribeHeads enables the optional newHeads subscription (NEW_HEADS=true)
+var subscribeHeads bool
+
+// Channel receiving new block heads; set when monitoring starts
+var headChan chan BlockHead
+
+// BlockHead is the number, hash and timestamp of a newly seen block
+type BlockHead struct {
+	Number    uint64
+	Hash      string
+	Timestamp time.Time
+	Received  time.Time
+}
+
+// handleSubscribeResponse records the newHeads subscription id, or explains that the provider
+// rejected it so the monitor carries on with pending transactions only
+func (m *Monitor) handleSubscribeResponse(result json.RawMessage, rpcErr *jsonRPCError) {
+	if rpcErr != nil {
+		m.logf(slog.LevelWarn, "Provider rejected the newHeads subscription, block heads won't be shown: %s", rpcErr.Message)
+		return
+	}
+
+	var id string
+	if err := json.Unmarshal(result, &id); err != nil {
+		m.logf(slog.LevelWarn, "Unexpected newHeads subscription response: %s", result)
+		return
+	}
+	m.headsSubscription.Store(id)
+}
+
+// isHeadsNotification reports whether a notification belongs to the newHeads subscription
+func (m *Monitor) isHeadsNotification(subscription string) bool {
+	id, _ := m.headsSubscription.Load().(string)
+	return id != "" && subscription == id
+}
+
+// handleHead parses a newHeads notification and passes the block on. The chain's latest base fee is
+// kept for effective priority fees.
+func (m *Monitor) handleHead(ctx context.Context, raw json.RawMessage, received time.Time) {
+	var header struct {
+		Number    hexutil.Uint64 `json:"number"`
+		Hash      string         `json:"hash"`
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+		BaseFee   *hexutil.Big   `json:"baseFeePerGas"` // Absent before London
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		m.logf(slog.LevelWarn, "Failed to parse block header: %v", err)
+		return
+	}
+
+	head := BlockHead{
+		Number:    uint64(header.Number),
+		Hash:      header.Hash,
+		Timestamp: time.Unix(int64(header.Timestamp), 0),
+		Received:  received,
+	}
+	if header.BaseFee != nil {
+		m.latestBaseFee.Store(header.BaseFee.ToInt())
+	}
+
+	// Never block processing on a slow UI; a newer head will follow shortly
+	select {
+	case headChan <- head:
+	default:
+	}
+
+	// Catch up on the blocks missed while the monitor was down, then see which of the watched pending
+	// transactions made it into this block
+	m.resumeOnce.Do(func() { m.backfill(ctx, head.Number) })
+	m.checkMined(ctx, head)
+	m.recordBlock(head.Number)
+	saveBlockState()
+}
@@ -0,0 +1,143 @@
+package mempool
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// When the process started, used for the minimum warm-up before reporting ready
+var startedAt = time.Now()
+
+// readyMinWarmup is an optional extra delay before the monitor reports ready (READY_MIN_WARMUP)
+var readyMinWarmup time.Duration
+
+// markConnected records that the chain's subscription has been established, keeping the time of
+// the first success while it stays up
+func (m *Monitor) markConnected() {
+	m.connectedAt.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// markDisconnected records that the chain's subscription has dropped, until it is re-established
+func (m *Monitor) markDisconnected() {
+	m.connectedAt.Store(0)
+}
+
+// markMessageReceived records the first message received over the chain's subscription
+func (m *Monitor) markMessageReceived() {
+	m.firstMessageAt.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// healthStatus is the JSON body of the health and readiness endpoints. ConnectedAt and
+// FirstMessageAt are when the last of the chains connected and received its first message, so they
+// are only set once every chain has; Chains breaks them down when several chains are monitored.
+type healthStatus struct {
+	Status         string        `json:"status"`
+	Reason         string        `json:"reason,omitempty"`
+	Uptime         string        `json:"uptime"`
+	ConnectedAt    *time.Time    `json:"connectedAt,omitempty"`
+	FirstMessageAt *time.Time    `json:"firstMessageAt,omitempty"`
+	Chains         []chainHealth `json:"chains,omitempty"`
+}
+
+// chainHealth is the connection state of one chain
+type chainHealth struct {
+	Name           string     `json:"name"`
+	ConnectedAt    *time.Time `json:"connectedAt,omitempty"`
+	FirstMessageAt *time.Time `json:"firstMessageAt,omitempty"`
+}
+
+// receivingMonitors returns the chains transactions are received from: all of them, or only the
+// first when replaying a capture
+func receivingMonitors() []*Monitor {
+	if replayPath != "" && len(monitors) > 0 {
+		return monitors[:1]
+	}
+	return monitors
+}
+
+// timestamp converts unix nanoseconds to a time, or nil when the event hasn't happened yet
+func timestamp(ns int64) *time.Time {
+	if ns == 0 {
+		return nil
+	}
+	t := time.Unix(0, ns)
+	return &t
+}
+
+// currentHealth builds the health status, reporting whether the monitor is ready and why not. The
+// monitor is ready once every chain is connected and has received a message, and READY_MIN_WARMUP
+// has passed since it started.
+func currentHealth() (healthStatus, bool) {
+	status := healthStatus{Status: "ok", Uptime: time.Since(startedAt).Round(time.Second).String()}
+
+	receiving := receivingMonitors()
+	var reasons []string
+	if len(receiving) == 0 {
+		reasons = append(reasons, "subscription not connected")
+	}
+	var lastConnected, lastFirstMessage int64 // Latest of the chains, kept only while every chain has one
+	for i, m := range receiving {
+		connected, firstMessage := m.connectedAt.Load(), m.firstMessageAt.Load()
+		if len(receiving) > 1 {
+			status.Chains = append(status.Chains, chainHealth{Name: m.name, ConnectedAt: timestamp(connected), FirstMessageAt: timestamp(firstMessage)})
+		}
+
+		switch {
+		case connected == 0:
+			reasons = append(reasons, m.errorf("subscription not connected").Error())
+		case firstMessage == 0:
+			reasons = append(reasons, m.errorf("no message received yet").Error())
+		}
+		if i == 0 || (lastConnected != 0 && connected != 0) {
+			lastConnected = max(lastConnected, connected)
+		} else {
+			lastConnected = 0
+		}
+		if i == 0 || (lastFirstMessage != 0 && firstMessage != 0) {
+			lastFirstMessage = max(lastFirstMessage, firstMessage)
+		} else {
+			lastFirstMessage = 0
+		}
+	}
+	status.ConnectedAt, status.FirstMessageAt = timestamp(lastConnected), timestamp(lastFirstMessage)
+
+	if len(reasons) == 0 && time.Since(startedAt) < readyMinWarmup {
+		reasons = append(reasons, "warming up")
+	}
+	if len(reasons) == 0 {
+		return status, true
+	}
+	status.Status = "not ready"
+	status.Reason = strings.Join(reasons, "; ")
+	return status, false
+}
+
+// HealthHandler serves /healthz, which reports the process is alive along with connection timestamps
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := currentHealth()
+		status.Status = "ok"
+		status.Reason = ""
+		writeHealth(w, http.StatusOK, status)
+	})
+}
+
+// ReadyHandler serves /readyz, which only succeeds once the subscriptions of all chains are genuinely live
+func ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, ready := currentHealth()
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeHealth(w, code, status)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,71 @@
+package mempool
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Ready means every chain is connected and has received a message, and the warm-up has passed
+func TestReadyNeedsEveryChain(t *testing.T) {
+	mainnet, base := &Monitor{name: "mainnet"}, &Monitor{name: "base"}
+	monitors = []*Monitor{mainnet, base}
+	t.Cleanup(func() { monitors = nil; readyMinWarmup = 0 })
+
+	ready := func() (int, healthStatus) {
+		t.Helper()
+		status, _ := currentHealth()
+		rec := httptest.NewRecorder()
+		ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code, status
+	}
+
+	if code, status := ready(); code != http.StatusServiceUnavailable || !strings.Contains(status.Reason, "chain base: subscription not connected") {
+		t.Errorf("no chain connected: %d %q", code, status.Reason)
+	}
+
+	// One chain live isn't enough
+	mainnet.markConnected()
+	mainnet.markMessageReceived()
+	code, status := ready()
+	if code != http.StatusServiceUnavailable || strings.Contains(status.Reason, "mainnet") || !strings.Contains(status.Reason, "chain base") {
+		t.Errorf("only mainnet live: %d %q", code, status.Reason)
+	}
+	if status.ConnectedAt != nil || len(status.Chains) != 2 || status.Chains[0].ConnectedAt == nil {
+		t.Errorf("only mainnet live: connectedAt %v, chains %+v", status.ConnectedAt, status.Chains)
+	}
+
+	base.markConnected()
+	if code, status := ready(); code != http.StatusServiceUnavailable || !strings.Contains(status.Reason, "chain base: no message received yet") {
+		t.Errorf("base without messages: %d %q", code, status.Reason)
+	}
+
+	base.markMessageReceived()
+	if code, status := ready(); code != http.StatusOK || status.ConnectedAt == nil || status.FirstMessageAt == nil {
+		t.Errorf("every chain live: %d %+v", code, status)
+	}
+
+	readyMinWarmup = time.Hour
+	if code, status := ready(); code != http.StatusServiceUnavailable || status.Reason != "warming up" {
+		t.Errorf("warming up: %d %q", code, status.Reason)
+	}
+	readyMinWarmup = 0
+
+	// A chain dropping its connection makes the monitor unready again
+	base.markDisconnected()
+	if code, _ := ready(); code != http.StatusServiceUnavailable {
+		t.Errorf("base disconnected: %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestSetupRejectsInvalidWarmup(t *testing.T) {
+	inTempDir(t)
+	t.Setenv("READY_MIN_WARMUP", "soon")
+	t.Cleanup(func() { monitors = nil })
+	err := Setup(Config{Chains: []ChainConfig{{WSEndpoint: "wss://node.example.com", HTTPSEndpoint: "https://node.example.com"}}})
+	if err == nil || !strings.Contains(err.Error(), "READY_MIN_WARMUP") {
+		t.Errorf("Setup = %v, want an invalid READY_MIN_WARMUP error", err)
+	}
+}
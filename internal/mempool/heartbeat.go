@@ -0,0 +1,69 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/gorilla/websocket"
+)
+
+// Heartbeat defaults: a ping every 30s (WS_PING_INTERVAL, 0 disables) and up to 10s for the
+// connection to answer it (WS_PONG_TIMEOUT)
+const (
+	defaultPingInterval = 30 * time.Second
+	defaultPongTimeout  = 10 * time.Second
+)
+
+// Heartbeat settings, read in Setup
+var (
+	pingInterval = defaultPingInterval
+	pongTimeout  = defaultPongTimeout
+)
+
+// idleDeadline is how long a connection may go without data or a pong before it is considered dead
+func idleDeadline() time.Time {
+	return time.Now().Add(pingInterval + pongTimeout)
+}
+
+// startHeartbeat pings the provider every pingInterval until done is closed, so idle connections are
+// kept open. Every pong, like every message, pushes the read deadline back; once it passes, the
+// blocked read fails and the connection is re-established.
+func startHeartbeat(conn *websocket.Conn, done <-chan struct{}) {
+	if pingInterval <= 0 {
+		return
+	}
+
+	conn.SetReadDeadline(idleDeadline())
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(idleDeadline())
+	})
+
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// WriteControl may be called concurrently with the reads and other writes
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pongTimeout)); err != nil {
+					logging.Debugf("Failed to send WebSocket ping: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// describeReadError explains a read that failed because the heartbeat deadline passed
+func describeReadError(err error) error {
+	var netErr net.Error
+	if pingInterval > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("no data or pong for %s, connection presumed dead: %w", pingInterval+pongTimeout, err)
+	}
+	return err
+}
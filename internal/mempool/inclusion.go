@@ -0,0 +1,98 @@
+package mempool
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Default number of recent pending gas prices kept for the inclusion estimate
+const defaultGasSampleSize = 2000
+
+// Share of recent pending transactions a gas price must beat to be considered likely included
+const inclusionThreshold = 0.5
+
+// gasPriceWindow keeps the gas prices of the most recently seen pending transactions in a ring buffer
+type gasPriceWindow struct {
+	mu      sync.Mutex
+	samples []*big.Int
+	next    int
+	full    bool
+}
+
+func newGasPriceWindow(size int) *gasPriceWindow {
+	if size <= 0 {
+		size = defaultGasSampleSize
+	}
+	return &gasPriceWindow{samples: make([]*big.Int, size)}
+}
+
+// add records a gas price, overwriting the oldest sample once the window is full
+func (w *gasPriceWindow) add(price *big.Int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.samples[w.next] = price
+	w.next++
+	if w.next == len(w.samples) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+// rank returns the fraction of sampled gas prices at or below the given price, and the sample count
+func (w *gasPriceWindow) rank(price *big.Int) (float64, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	count := w.next
+	if w.full {
+		count = len(w.samples)
+	}
+	if count == 0 {
+		return 0, 0
+	}
+
+	below := 0
+	for _, sample := range w.samples[:count] {
+		if sample.Cmp(price) <= 0 {
+			below++
+		}
+	}
+	return float64(below) / float64(count), count
+}
+
+// Recent pending gas prices; nil unless ESTIMATE_INCLUSION is enabled
+var pendingGasPrices *gasPriceWindow
+
+// recordGasPrice adds a pending transaction's gas price to the sample window
+func recordGasPrice(gasPriceHex string) {
+	if pendingGasPrices == nil {
+		return
+	}
+	if price, err := hexutil.DecodeBig(gasPriceHex); err == nil {
+		pendingGasPrices.add(price)
+	}
+}
+
+// estimateInclusion gives a coarse, heuristic read on whether a transaction's gas price is competitive
+// for the next block by comparing it against recently seen pending transactions
+func estimateInclusion(gasPriceHex string) string {
+	price, err := hexutil.DecodeBig(gasPriceHex)
+	if err != nil {
+		return "unknown (no gas price)"
+	}
+
+	rank, count := pendingGasPrices.rank(price)
+	if count == 0 {
+		return "unknown (no pending samples yet)"
+	}
+
+	verdict := "likely delayed"
+	if rank >= inclusionThreshold {
+		verdict = "likely included"
+	}
+	return fmt.Sprintf("%s (bids at or above %.0f%% of %d recent pending txs)", verdict, rank*100, count)
+}
@@ -0,0 +1,108 @@
+package mempool
+
+import (
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Upper bounds of the processing latency histogram buckets; a final bucket catches everything slower
+var latencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// Matched transactions slower than this end to end are logged with a timing breakdown; zero disables it
+var slowTxThreshold time.Duration
+
+// latencyHistogram accumulates end-to-end processing latencies of matched transactions
+type latencyHistogram struct {
+	mu     sync.Mutex
+	counts []uint64 // One count per bucket plus the overflow bucket
+	count  uint64
+	sum    time.Duration
+}
+
+// Histogram of end-to-end latency from hash received to decoded and displayed
+var processingLatency = &latencyHistogram{counts: make([]uint64, len(latencyBuckets)+1)}
+
+// observe records a single latency sample
+func (h *latencyHistogram) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += d
+}
+
+// LatencyBucket is the number of samples at or below an upper bound; LeMs is -1 for the overflow bucket
+type LatencyBucket struct {
+	LeMs  float64 `json:"leMs"`
+	Count uint64  `json:"count"`
+}
+
+// LatencyStats summarizes the processing latency histogram
+type LatencyStats struct {
+	Count   uint64          `json:"count"`
+	MeanMs  float64         `json:"meanMs"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// snapshot returns a copy of the histogram with cumulative bucket counts
+func (h *latencyHistogram) snapshot() LatencyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := LatencyStats{Count: h.count}
+	if h.count > 0 {
+		stats.MeanMs = float64(h.sum.Milliseconds()) / float64(h.count)
+	}
+
+	var cumulative uint64
+	for i, count := range h.counts {
+		cumulative += count
+		le := -1.0
+		if i < len(latencyBuckets) {
+			le = float64(latencyBuckets[i].Milliseconds())
+		}
+		stats.Buckets = append(stats.Buckets, LatencyBucket{LeMs: le, Count: cumulative})
+	}
+	return stats
+}
+
+// txTiming records when a transaction reached each stage of the pipeline
+type txTiming struct {
+	received time.Time // Notification received over the WebSocket
+	fetched  time.Time // Transaction details available (fetched or inline)
+	decoded  time.Time // Calldata decoded against the ABI
+	enriched time.Time // Details formatted, including token lookups
+}
+
+// finish records the end-to-end latency of a displayed transaction and logs it if slow
+func (t *txTiming) finish(txHash string) {
+	done := time.Now()
+	total := done.Sub(t.received)
+	processingLatency.observe(total)
+
+	if slowTxThreshold > 0 && total >= slowTxThreshold {
+		logging.Warnf("Slow transaction %s: total %s (fetch %s, decode %s, enrich %s, display %s)",
+			txHash, total.Round(time.Millisecond),
+			t.fetched.Sub(t.received).Round(time.Millisecond),
+			t.decoded.Sub(t.fetched).Round(time.Millisecond),
+			t.enriched.Sub(t.decoded).Round(time.Millisecond),
+			done.Sub(t.enriched).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,97 @@
+package mempool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ContractMatches is the number of transactions matched for a watched contract since the counts
+// were last reset
+type ContractMatches struct {
+	Name    string `json:"name"`
+	Chain   string `json:"chain,omitempty"`
+	Address string `json:"address"`
+	Count   uint64 `json:"count"`
+}
+
+// chainContract identifies a contract on one of the monitored chains
+type chainContract struct {
+	chain   string
+	address common.Address
+}
+
+// Matched transactions per contract for the TUI. Unlike the Prometheus counters these can be reset
+// from the UI.
+var (
+	matchCountsMu sync.Mutex
+	matchCounts   = make(map[chainContract]uint64)
+)
+
+// countContractMatch counts a matched transaction against the contract it was sent to
+func countContractMatch(chain, to string) {
+	matchCountsMu.Lock()
+	matchCounts[chainContract{chain, common.HexToAddress(to)}]++
+	matchCountsMu.Unlock()
+}
+
+// ResetContractMatches sets every contract's match count back to zero
+func ResetContractMatches() {
+	matchCountsMu.Lock()
+	matchCounts = make(map[chainContract]uint64)
+	matchCountsMu.Unlock()
+}
+
+// contractMatchesSnapshot lists every watched contract with its match count, busiest first, and
+// sums matches to unwatched contracts decoded with the generic ABI under a single entry
+func contractMatchesSnapshot() []ContractMatches {
+	matchCountsMu.Lock()
+	counts := make(map[chainContract]uint64, len(matchCounts))
+	for key, count := range matchCounts {
+		counts[key] = count
+	}
+	matchCountsMu.Unlock()
+
+	var list []ContractMatches
+	for _, m := range monitors {
+		m.contractsMu.RLock()
+		for addr, contract := range m.contractsByAddress {
+			key := chainContract{m.name, addr}
+			list = append(list, ContractMatches{Name: contract.Name, Chain: m.name, Address: addr.Hex(), Count: counts[key]})
+			delete(counts, key)
+		}
+		m.contractsMu.RUnlock()
+	}
+
+	var other uint64
+	for _, count := range counts {
+		other += count
+	}
+	if other > 0 {
+		list = append(list, ContractMatches{Name: "other (generic ABI)", Count: other})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// FormatContractMatches renders the per-contract match counts as a single status line
+func FormatContractMatches(matches []ContractMatches) string {
+	parts := make([]string, len(matches))
+	for i, m := range matches {
+		if m.Chain != "" {
+			parts[i] = fmt.Sprintf("%s (%s): %d", m.Name, m.Chain, m.Count)
+		} else {
+			parts[i] = fmt.Sprintf("%s: %d", m.Name, m.Count)
+		}
+	}
+	return "Matches (r to reset): " + strings.Join(parts, " | ")
+}
@@ -0,0 +1,41 @@
+package mempool
+
+import "strings"
+
+// colorMethods colors matched transactions in the TUI by the category of their method; disabled with
+// METHOD_COLORS=false
+var colorMethods = true
+
+// Colors of the method categories, as tview color names. Red and green are left to gas prices.
+const (
+	swapColor      = "aqua"
+	liquidityColor = "fuchsia"
+	approvalColor  = "yellow"
+)
+
+// methodColor returns the color of a method's category, or "" when the method isn't a swap, liquidity
+// operation or approval. Methods are categorized by name, so selectors labelled in selectors.json and
+// methods decoded with a contract's ABI are colored alike.
+func methodColor(method string) string {
+	name := strings.ToLower(method)
+	switch {
+	case strings.Contains(name, "swap"), strings.HasPrefix(name, "exactinput"), strings.HasPrefix(name, "exactoutput"):
+		return swapColor
+	case strings.Contains(name, "liquidity"):
+		return liquidityColor
+	case strings.HasPrefix(name, "approve"), strings.HasPrefix(name, "permit"), strings.HasPrefix(name, "setapprovalforall"),
+		strings.HasPrefix(name, "increaseallowance"), strings.HasPrefix(name, "decreaseallowance"):
+		return approvalColor
+	}
+	return ""
+}
+
+// colorizeMethod wraps text in the color of a method's category, leaving it as is for uncategorized
+// methods or when method colors are disabled
+func colorizeMethod(method, text string) string {
+	color := methodColor(method)
+	if !colorMethods || color == "" {
+		return text
+	}
+	return "[" + color + "]" + text + "[-]"
+}
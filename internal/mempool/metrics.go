@@ -0,0 +1,77 @@
+package mempool
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"eth-mempool-monitor/internal/cache"
+)
+
+// Counters exported in the Prometheus text format on /metrics
+var (
+	metricTransactionsSeen uint64 // Pending transaction notifications received
+	metricRPCErrors        uint64 // Failed transaction lookups
+	metricReconnects       uint64 // WebSocket reconnection attempts
+	metricTPS              uint64 // Transactions per second over the last second
+	metricDuplicates       uint64 // Re-announced transactions skipped by deduplication
+	metricWebhookFailures  uint64 // Webhook alerts that were dropped or could not be delivered
+	metricTelegramFailures uint64 // Telegram notifications that were dropped or could not be delivered
+	metricSenderMismatches uint64 // Matched transactions whose signature doesn't match the reported sender
+
+	metricMatchedMu sync.Mutex
+	metricMatched   = make(map[string]uint64) // Matched transactions per contract name
+)
+
+// recordMatched counts a matched transaction against its contract
+func recordMatched(contract string) {
+	metricMatchedMu.Lock()
+	metricMatched[contract]++
+	metricMatchedMu.Unlock()
+}
+
+// MetricsHandler serves the counters in the Prometheus text exposition format
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var out strings.Builder
+
+		writeMetric(&out, "eth_mempool_transactions_seen_total", "counter", "Pending transaction notifications received.", atomic.LoadUint64(&metricTransactionsSeen))
+
+		out.WriteString("# HELP eth_mempool_transactions_matched_total Transactions matched per watched contract.\n")
+		out.WriteString("# TYPE eth_mempool_transactions_matched_total counter\n")
+		metricMatchedMu.Lock()
+		names := make([]string, 0, len(metricMatched))
+		for name := range metricMatched {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&out, "eth_mempool_transactions_matched_total{contract=%q} %d\n", name, metricMatched[name])
+		}
+		metricMatchedMu.Unlock()
+
+		writeMetric(&out, "eth_mempool_tps", "gauge", "Transactions per second over the last second.", atomic.LoadUint64(&metricTPS))
+		writeMetric(&out, "eth_mempool_duplicate_transactions_total", "counter", "Re-announced transactions skipped by deduplication.", atomic.LoadUint64(&metricDuplicates))
+		writeMetric(&out, "eth_mempool_webhook_failures_total", "counter", "Webhook alerts that were dropped or could not be delivered.", atomic.LoadUint64(&metricWebhookFailures))
+		writeMetric(&out, "eth_mempool_telegram_failures_total", "counter", "Telegram notifications that were dropped or could not be delivered.", atomic.LoadUint64(&metricTelegramFailures))
+		writeMetric(&out, "eth_mempool_token_cache_entries", "gauge", "Tokens held in the token cache.", uint64(cache.TokenCache.Len()))
+		writeMetric(&out, "eth_mempool_token_cache_evictions_total", "counter", "Tokens evicted from the token cache to stay within its size limit.", cache.TokenCache.Evictions())
+		writeMetric(&out, "eth_mempool_sender_mismatches_total", "counter", "Matched transactions whose signature doesn't match the reported sender.", atomic.LoadUint64(&metricSenderMismatches))
+		writeMetric(&out, "eth_mempool_rpc_errors_total", "counter", "Failed transaction lookups.", atomic.LoadUint64(&metricRPCErrors))
+		writeMetric(&out, "eth_mempool_ws_reconnects_total", "counter", "WebSocket reconnection attempts.", atomic.LoadUint64(&metricReconnects))
+		writeMetric(&out, "eth_mempool_in_flight_transactions", "gauge", "Transactions currently being processed.", uint64(atomic.LoadInt64(&inFlightTransactions)))
+		writeMetric(&out, "eth_mempool_dropped_ui_updates_total", "counter", "UI updates dropped because the display fell behind.", droppedUpdates())
+		writeMetric(&out, "eth_mempool_in_flight_rpc", "gauge", "RPC requests awaiting a response.", uint64(atomic.LoadInt64(&inFlightRPC)))
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write([]byte(out.String()))
+	})
+}
+
+// writeMetric writes a single unlabelled metric with its HELP and TYPE lines
+func writeMetric(out *strings.Builder, name, typ, help string, value uint64) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, typ, name, value)
+}
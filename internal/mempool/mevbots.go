@@ -0,0 +1,112 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// How the MEV bot list is applied to transaction senders
+const (
+	mevBotsExclude = "exclude" // Hide transactions sent by listed bots
+	mevBotsOnly    = "only"    // Show only transactions sent by listed bots
+)
+
+// Known MEV bot addresses loaded from MEV_BOT_LIST; the set is replaced wholesale on SIGHUP
+var (
+	mevBotsMu   sync.RWMutex
+	mevBots     map[common.Address]bool
+	mevBotsPath string
+	mevBotsMode = mevBotsExclude
+)
+
+// LoadMEVBots loads a JSON array of bot addresses. A missing file disables bot filtering.
+func LoadMEVBots(filename string) (map[common.Address]bool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read MEV bot list: %w", err)
+	}
+
+	var addresses []string
+	if err := json.Unmarshal(data, &addresses); err != nil {
+		return nil, fmt.Errorf("failed to parse MEV bot list: %w", err)
+	}
+
+	bots := make(map[common.Address]bool, len(addresses))
+	for _, addr := range addresses {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid address %q in MEV bot list", addr)
+		}
+		bots[common.HexToAddress(addr)] = true
+	}
+
+	logging.Infof("Loaded %d MEV bot addresses from %s (mode: %s)", len(bots), filename, mevBotsMode)
+	return bots, nil
+}
+
+// reloadMEVBots re-reads the MEV bot list, keeping the current list if the file is broken
+func reloadMEVBots() {
+	if mevBotsPath == "" {
+		return
+	}
+
+	bots, err := LoadMEVBots(mevBotsPath)
+	if err != nil {
+		logging.Warnf("Error reloading MEV bot list, keeping the previous list: %v", err)
+		return
+	}
+
+	mevBotsMu.Lock()
+	mevBots = bots
+	mevBotsMu.Unlock()
+}
+
+// reloadOnSignal reloads the runtime-updatable configuration whenever the process receives SIGHUP
+func reloadOnSignal(ctx context.Context) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+			logging.Infof("Received SIGHUP, reloading configuration")
+			reloadMEVBots()
+			reloadAddressBook()
+			for _, m := range monitors {
+				m.reloadContracts()
+			}
+		}
+	}
+}
+
+// matchMEVBots reports whether a sender passes the MEV bot filter, whether bot filtering is enabled,
+// and whether the sender is a listed bot. Without a list every sender passes.
+func matchMEVBots(from string) (passed, enabled, isBot bool) {
+	mevBotsMu.RLock()
+	defer mevBotsMu.RUnlock()
+
+	if mevBots == nil {
+		return true, false, false
+	}
+
+	isBot = mevBots[common.HexToAddress(from)]
+	if mevBotsMode == mevBotsOnly {
+		return isBot, true, isBot
+	}
+	return !isBot, true, isBot
+}
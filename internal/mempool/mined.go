@@ -0,0 +1,97 @@
+package mempool
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Default time a matched transaction is watched for inclusion before it is forgotten
+const defaultPendingTrackTTL = 10 * time.Minute
+
+// How long matched transactions are tracked, from PENDING_TRACK_TTL
+var pendingTrackTTL = defaultPendingTrackTTL
+
+// Channel receiving watched transactions as they are mined; set when monitoring starts
+var minedChan chan MinedTx
+
+// MinedTx is a watched transaction found in a block, with how long it sat in the mempool
+type MinedTx struct {
+	Hash        string
+	BlockNumber uint64
+	Dwell       time.Duration // From first seen pending to the block arriving
+}
+
+// trackPending starts watching a matched transaction for inclusion in the chain's blocks. Tracking
+// needs block heads, so it is only active with NEW_HEADS enabled.
+func (m *Monitor) trackPending(hash string, firstSeen time.Time) {
+	if !subscribeHeads || hash == "" {
+		return
+	}
+
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	if m.watchedPending == nil {
+		m.watchedPending = make(map[string]time.Time)
+	}
+	if _, exists := m.watchedPending[strings.ToLower(hash)]; !exists {
+		m.watchedPending[strings.ToLower(hash)] = firstSeen
+	}
+}
+
+// checkMined looks for the chain's watched transactions in a new block, reports their mempool dwell
+// time and forgets them along with any that have been pending longer than the tracking TTL
+func (m *Monitor) checkMined(ctx context.Context, head BlockHead) {
+	m.pendingMu.Lock()
+	empty := len(m.watchedPending) == 0
+	m.pendingMu.Unlock()
+	if empty {
+		return
+	}
+
+	var block struct {
+		Timestamp    hexutil.Uint64 `json:"timestamp"`
+		Transactions []struct {
+			Hash string `json:"hash"`
+		} `json:"transactions"`
+	}
+	callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	if err := m.chain.Client.CallContext(callCtx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(head.Number), true); err != nil {
+		if ctx.Err() != nil {
+			return // Shutting down
+		}
+		m.logf(slog.LevelWarn, "Failed to fetch block %d: %v", head.Number, err)
+		return
+	}
+
+	// Backfilled blocks weren't received live, so their dwell times run to the block's timestamp
+	received := head.Received
+	if received.IsZero() {
+		received = time.Unix(int64(block.Timestamp), 0)
+	}
+
+	var mined []MinedTx
+	m.pendingMu.Lock()
+	for _, tx := range block.Transactions {
+		hash := strings.ToLower(tx.Hash)
+		if firstSeen, exists := m.watchedPending[hash]; exists {
+			mined = append(mined, MinedTx{Hash: tx.Hash, BlockNumber: head.Number, Dwell: received.Sub(firstSeen)})
+			delete(m.watchedPending, hash)
+		}
+	}
+	for hash, firstSeen := range m.watchedPending {
+		if time.Since(firstSeen) > pendingTrackTTL {
+			delete(m.watchedPending, hash)
+		}
+	}
+	m.pendingMu.Unlock()
+
+	// Never block the heads on a slow UI; mined transactions it has no room for are dropped and counted
+	for _, tx := range mined {
+		sendUpdate(minedChan, tx, &droppedMined)
+	}
+}
@@ -0,0 +1,70 @@
+package mempool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"eth-mempool-monitor/internal/cache"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newBlockServer serves every eth_getBlockByNumber with a block holding the given transaction hash
+func newBlockServer(t *testing.T, hash string) *rpc.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"timestamp":"0x6553f100","transactions":[{"hash":"` + hash + `"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestCheckMinedKeepsChainsApart(t *testing.T) {
+	subscribeHeads = true
+	t.Cleanup(func() { subscribeHeads = false; minedChan = nil })
+
+	const minedHash = "0xAAAA"
+	mainnet := &Monitor{chain: &cache.Chain{ID: 1, Client: newBlockServer(t, minedHash)}}
+	other := &Monitor{chain: &cache.Chain{ID: 10}}
+
+	// Both chains watch a transaction that has outlived the TTL, and mainnet's mined one
+	stale := time.Now().Add(-2 * pendingTrackTTL)
+	mainnet.trackPending("0xaaaa", time.Now())
+	mainnet.trackPending("0xdead", stale)
+	other.trackPending("0xbeef", stale)
+
+	// A full channel must not hold up the heads; the mined transaction is dropped and counted
+	minedChan = make(chan MinedTx)
+	dropped := atomic.LoadUint64(&droppedMined)
+
+	done := make(chan struct{})
+	go func() {
+		mainnet.checkMined(context.Background(), BlockHead{Number: 1, Received: time.Now()})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("checkMined blocked on a full mined channel")
+	}
+
+	if got := atomic.LoadUint64(&droppedMined) - dropped; got != 1 {
+		t.Errorf("dropped %d mined transactions, want 1", got)
+	}
+	if len(mainnet.watchedPending) != 0 {
+		t.Errorf("mainnet still watches %v, want the mined and expired transactions gone", mainnet.watchedPending)
+	}
+	if _, exists := other.watchedPending["0xbeef"]; !exists {
+		t.Error("mainnet's TTL sweep purged another chain's pending transaction")
+	}
+}
@@ -3,159 +3,683 @@ package mempool
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"eth-mempool-monitor/internal/cache"
 	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+	"eth-mempool-monitor/internal/settings"
+	"eth-mempool-monitor/internal/sink"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/big"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/gorilla/websocket"
-	"github.com/joho/godotenv"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
+// Number of raw WebSocket messages buffered between the reader and the processing workers
+const msgBufferSize = 256
+
+// Default number of workers processing messages
+const defaultWorkerCount = 20
+
+// Default retrying of transactions not yet known to the HTTPS node; the delay doubles after each attempt
+const (
+	defaultNotFoundRetries    = 2
+	defaultNotFoundRetryDelay = 250 * time.Millisecond
+)
+
+// Retrying of lookups that come back empty (NOT_FOUND_RETRIES, NOT_FOUND_RETRY_DELAY)
+var (
+	notFoundRetries    = defaultNotFoundRetries
+	notFoundRetryDelay = defaultNotFoundRetryDelay
+)
+
+// wsMessage is a raw WebSocket message along with the time it was received and the chain it came from
+type wsMessage struct {
+	data     string
+	received time.Time
+	monitor  *Monitor
+}
+
 // Global variables
 var (
-	wsEndpoint    string
-	httpsEndpoint string
-	username      string
-	password      string
-	txCount       uint64     // Counter for the number of transactions
-	contracts     []Contract // Loaded contracts
+	txCount       uint64 // Counter for the number of transactions
 	recentTx      string
+	unifiedLayout bool     // Send summaries and decoded details as one entry on txChan
+	workerCount   int      // Number of message processing workers
+	minValue      *big.Int // Transactions with a lower value in wei are skipped; nil disables the check
 )
 
-var relevantSelectorsUniswap = map[string]bool{
-	"38ed1739": true, // swapExactTokensForTokens
-	"8803dbee": true, // swapTokensForExactTokens
-	"7ff36ab5": true, // swapExactETHForTokens
-	"4a25d94a": true, // swapTokensForExactETH
-	"18cbafe5": true, // swapExactTokensForETH
-	"fb3bdb41": true, // swapETHForExactTokens
-	"e8e33700": true, // addLiquidity
-	"f305d719": true, // addLiquidityETH
-	"baa2abde": true, // removeLiquidity
-	"02751cec": true, // removeLiquidityETH
+// Setup prepares the package for monitoring: it applies the configuration, reads the optional
+// settings from the environment and loads the contracts, selectors, rules and token data. It must be
+// called once before MonitorMempool or TestFilter. The endpoints aren't checked here, so commands
+// that don't connect can run without them; see Config.Validate.
+func Setup(cfg Config) error {
+	if len(cfg.Chains) == 0 {
+		return fmt.Errorf("no chains configured")
+	}
+
+	// Read the optional settings into package-level variables, reporting every invalid value at once
+	var problems settings.Problems
+	setupOutput(&problems)
+	setupConnections(&problems)
+	setupLookups(&problems)
+	setupFilters(&problems)
+	setupAnalysis(&problems)
+	setupAlerts(&problems)
+	setupTokenCache(&problems)
+	setupHeads(&problems)
+	setupSinks(&problems)
+	if err := problems.Err(); err != nil {
+		return err
+	}
+
+	if err := setupChains(cfg); err != nil {
+		return err
+	}
+	return loadDataFiles()
 }
 
-var relevantSelectorsWETH = map[string]bool{
-	"d0e30db0": true, // deposit
-	"2e1a7d4d": true, // withdraw
-	"095ea7b3": true, // approve
-	"a9059cbb": true, // transfer
-	"23b872dd": true, // transferFrom
+// setupOutput reads the logging, display and decoding settings
+func setupOutput(p *settings.Problems) {
+	if err := logging.SetLevel(os.Getenv("LOG_LEVEL")); err != nil {
+		p.Add("%v", err)
+	}
+	unifiedLayout = os.Getenv("LAYOUT") == "unified"
+	slowTxThreshold = 0
+	p.Duration("SLOW_TX_THRESHOLD", &slowTxThreshold, true)
+
+	// Optional extra delay before /readyz reports ready
+	readyMinWarmup = 0
+	p.Duration("READY_MIN_WARMUP", &readyMinWarmup, true)
+
+	// Seconds covered by the rolling TPS average shown next to the per-second count
+	p.Int("TPS_WINDOW", &tpsWindowSize, 1)
+
+	// Token details are fetched while decoding unless RESOLVE_TOKENS=false
+	p.Bool("RESOLVE_TOKENS", &decoder.ResolveTokens)
+
+	// Long arrays and bytes are cut short in the details; MAX_ARRAY_ITEMS=0 or MAX_PARAM_BYTES=0 shows them whole
+	p.Int("MAX_ARRAY_ITEMS", &decoder.MaxArrayItems, 0)
+	p.Int("MAX_PARAM_BYTES", &decoder.MaxBytes, 0)
+
+	// Transactions to unwatched contracts are decoded against the common ABIs with GENERIC_DECODE
+	genericDecode = false
+	p.Bool("GENERIC_DECODE", &genericDecode)
+
+	// Matched transactions are colored by method category unless METHOD_COLORS=false
+	p.Bool("METHOD_COLORS", &colorMethods)
+
+	// Optionally color gas prices relative to the node's suggested gas price
+	p.Bool("GAS_PRICE_COLORS", &colorGasPrices)
+	if colorGasPrices {
+		p.Duration("GAS_ORACLE_INTERVAL", &gasOracleInterval, false)
+		p.PositiveFloat("GAS_HIGH_RATIO", &gasHighRatio)
+		p.PositiveFloat("GAS_LOW_RATIO", &gasLowRatio)
+	}
 }
 
-// Combine the two maps into a single map
-var relevantSelectors = make(map[string]bool)
+// setupConnections reads the settings of the WebSocket and RPC connections
+func setupConnections(p *settings.Problems) {
+	// Optional connection headers for providers that reject connections without them
+	if ua := os.Getenv("USER_AGENT"); ua != "" {
+		userAgent = ua
+	}
+	wsOrigin = os.Getenv("WS_ORIGIN")
+	wsSubprotocols = parseSubprotocols(os.Getenv("WS_SUBPROTOCOLS"))
+	fullPendingTransactions = false
+	p.Bool("PENDING_FULL_TX", &fullPendingTransactions)
+	serverFilter = false
+	p.Bool("ALCHEMY_FILTER", &serverFilter)
+
+	// How long a failing HTTPS endpoint is skipped when a chain lists several
+	endpointCooldown = defaultEndpointCooldown
+	p.Duration("ENDPOINT_COOLDOWN", &endpointCooldown, false)
+
+	// Bound how long a single RPC request may take
+	rpcTimeout = defaultRPCTimeout
+	p.Duration("RPC_TIMEOUT", &rpcTimeout, false)
+
+	// WebSocket heartbeat; WS_PING_INTERVAL=0 turns it off
+	p.Duration("WS_PING_INTERVAL", &pingInterval, true)
+	p.Duration("WS_PONG_TIMEOUT", &pongTimeout, false)
 
-// Initialize and load environment variables
-func init() {
-	// Merge selectors from Uniswap
-	for key, value := range relevantSelectorsUniswap {
-		relevantSelectors[key] = value
+	// Chains with the poll transport poll their HTTPS endpoint for pending transactions this often
+	p.Duration("POLL_INTERVAL", &pollInterval, false)
+
+	// Time allowed for in-flight work to stop on shutdown
+	p.Duration("SHUTDOWN_GRACE", &shutdownGrace, false)
+
+	// Route the WebSocket and RPC connections through a SOCKS5 proxy when one is configured
+	if v := os.Getenv("SOCKS5_PROXY"); v != "" {
+		proxyURL, err := parseSOCKS5Proxy(v)
+		if err != nil {
+			p.Add("%v", err)
+			return
+		}
+		proxyFunc = http.ProxyURL(proxyURL)
+		rpcTransport.Proxy = proxyFunc
 	}
+}
 
-	// Merge selectors from WETH
-	for key, value := range relevantSelectorsWETH {
-		relevantSelectors[key] = value
+// setupLookups reads how transactions are fetched and processed
+func setupLookups(p *settings.Problems) {
+	// Skip hashes re-announced within DEDUP_WINDOW; DEDUP_WINDOW=0 disables deduplication
+	dedupWindow := defaultDedupWindow
+	p.Duration("DEDUP_WINDOW", &dedupWindow, true)
+	seenHashes = nil
+	if dedupWindow > 0 {
+		var maxEntries int
+		p.Int("DEDUP_MAX_ENTRIES", &maxEntries, 0)
+		seenHashes = newSeenSet(dedupWindow, maxEntries)
 	}
 
-	// Load the environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	// Retry lookups of transactions the HTTPS node hasn't seen yet; NOT_FOUND_RETRIES=0 disables retrying
+	notFoundRetries = defaultNotFoundRetries
+	p.Int("NOT_FOUND_RETRIES", &notFoundRetries, 0)
+	p.Duration("NOT_FOUND_RETRY_DELAY", &notFoundRetryDelay, false)
+
+	// Number of workers processing messages; each holds at most one transaction in flight
+	workerCount = defaultWorkerCount
+	p.Int("WORKER_COUNT", &workerCount, 1)
+
+	// Batch transaction lookups to cut RPC round-trips; RPC_BATCH_SIZE=1 fetches each hash on its own
+	rpcBatchSize = defaultRPCBatchSize
+	p.Int("RPC_BATCH_SIZE", &rpcBatchSize, 1)
+	var batchWindowMs int
+	p.Int("RPC_BATCH_FLUSH_MS", &batchWindowMs, 0)
+	rpcBatchWindow = time.Duration(batchWindowMs) * time.Millisecond
+
+	// Optionally stay under the provider's request limit; RPC_RATE_LIMIT is in lookups per second
+	rpcLimiter = nil
+	var rate float64
+	p.PositiveFloat("RPC_RATE_LIMIT", &rate)
+	if rate > 0 {
+		var burst int
+		p.Int("RPC_RATE_BURST", &burst, 0)
+		rpcLimiter = newRateLimiter(rate, burst)
 	}
 
-	// Assign environment variables to package-level variables
-	wsEndpoint = os.Getenv("WS_ENDPOINT")
-	httpsEndpoint = os.Getenv("HTTPS_ENDPOINT")
-	username = os.Getenv("USERNAME")
-	password = os.Getenv("PASSWORD")
+	// Optionally rule out unwatched recipients with a Bloom filter, and skip fetching polled transactions to them
+	bloomPrefilter = false
+	p.Bool("BLOOM_FILTER", &bloomPrefilter)
+	bloomFPRate = defaultBloomFPRate
+	p.PositiveFloat("BLOOM_FP_RATE", &bloomFPRate)
+	if bloomFPRate >= 1 {
+		p.Add("invalid BLOOM_FP_RATE %q, expected a rate below 1 such as 0.01", os.Getenv("BLOOM_FP_RATE"))
+		bloomFPRate = defaultBloomFPRate
+	}
 
-	// Load contracts from the configuration file
-	contracts, err = LoadContracts("configs/contracts.json")
-	if err != nil {
-		log.Fatalf("Error loading contracts: %v", err)
+	// Fetch the tokens of swap paths through Multicall3 in one call where the chain has it deployed
+	cache.MulticallAddress = nil
+	if v := os.Getenv("MULTICALL3_ADDRESS"); v != "" {
+		if !common.IsHexAddress(v) {
+			p.Add("invalid MULTICALL3_ADDRESS %q", v)
+			return
+		}
+		addr := common.HexToAddress(v)
+		cache.MulticallAddress = &addr
+	}
+}
+
+// setupFilters reads which transactions are shown
+func setupFilters(p *settings.Problems) {
+	// Optionally skip dust transactions, with the threshold given in wei (decimal or hex) or in ETH
+	minValue = nil
+	switch weiValue, ethValue := os.Getenv("MIN_VALUE_WEI"), os.Getenv("MIN_VALUE_ETH"); {
+	case weiValue != "" && ethValue != "":
+		p.Add("set only one of MIN_VALUE_WEI and MIN_VALUE_ETH")
+	case weiValue != "":
+		if wei, ok := parseWei(weiValue); ok {
+			minValue = wei
+		} else {
+			p.Add("invalid MIN_VALUE_WEI %q", weiValue)
+		}
+	case ethValue != "":
+		if wei, ok := parseEther(ethValue); ok {
+			minValue = wei
+		} else {
+			p.Add("invalid MIN_VALUE_ETH %q", ethValue)
+		}
+	}
+
+	// Optional comma-separated sender lists: WATCH_FROM follows wallets whatever they call, IGNORE_FROM drops senders
+	var err error
+	if watchFrom, err = parseAddressList("WATCH_FROM", os.Getenv("WATCH_FROM")); err != nil {
+		p.Add("%v", err)
+	}
+	if ignoreFrom, err = parseAddressList("IGNORE_FROM", os.Getenv("IGNORE_FROM")); err != nil {
+		p.Add("%v", err)
+	}
+
+	// The optional MEV bot list separates bot traffic from organic activity; it is loaded with the other files
+	mevBotsPath = os.Getenv("MEV_BOT_LIST")
+	if mevBotsPath != "" {
+		switch mode := os.Getenv("MEV_BOT_MODE"); mode {
+		case "", mevBotsExclude:
+			mevBotsMode = mevBotsExclude
+		case mevBotsOnly:
+			mevBotsMode = mevBotsOnly
+		default:
+			p.Add("invalid MEV_BOT_MODE %q, expected %q or %q", mode, mevBotsExclude, mevBotsOnly)
+		}
+	}
+
+	// The watched contracts files are checked for changes this often; 0 only reloads on SIGHUP
+	p.Duration("CONTRACTS_RELOAD_INTERVAL", &contractsReloadInterval, true)
+}
+
+// setupAnalysis reads the optional analyses of matched transactions
+func setupAnalysis(p *settings.Problems) {
+	// Optionally sample pending gas prices to estimate whether matched transactions make the next block
+	var estimateInclusion bool
+	p.Bool("ESTIMATE_INCLUSION", &estimateInclusion)
+	if estimateInclusion {
+		var sampleSize int
+		p.Int("GAS_SAMPLE_SIZE", &sampleSize, 0)
+		pendingGasPrices = newGasPriceWindow(sampleSize)
+	}
+
+	// Optionally simulate matched transactions to flag those that would revert
+	simulateReverts = false
+	p.Bool("SIMULATE_REVERTS", &simulateReverts)
+
+	// Optionally check that matched transactions were signed by their reported sender
+	verifySenders = false
+	p.Bool("VERIFY_SENDER", &verifySenders)
+
+	// Optionally keep gas price percentiles over a sliding window for the TUI and stats API;
+	// GAS_PERCENTILE_SCOPE picks whether all pending transactions or only matched ones are counted
+	var gasPercentiles bool
+	p.Bool("GAS_PERCENTILES", &gasPercentiles)
+	if gasPercentiles {
+		history := &gasPriceHistory{window: defaultGasPercentileWindow, maxSamples: defaultGasPercentileMaxSamples}
+		p.Duration("GAS_PERCENTILE_WINDOW", &history.window, false)
+		p.Int("GAS_PERCENTILE_MAX_SAMPLES", &history.maxSamples, 1)
+		switch scope := os.Getenv("GAS_PERCENTILE_SCOPE"); scope {
+		case "", gasScopeAll:
+			history.scope = gasScopeAll
+		case gasScopeMatched:
+			history.scope = gasScopeMatched
+		default:
+			p.Add("invalid GAS_PERCENTILE_SCOPE %q, expected %q or %q", scope, gasScopeAll, gasScopeMatched)
+		}
+		gasHistory = history
+	}
+
+	// Optionally aggregate swap volume per token pair; SWAP_VOLUME_HOPS picks how multi-hop paths are attributed
+	p.Bool("SWAP_VOLUME", &trackSwapVolume)
+	if trackSwapVolume {
+		switch hops := os.Getenv("SWAP_VOLUME_HOPS"); hops {
+		case "", volumeHopsEndpoints:
+			volumeHops = volumeHopsEndpoints
+		case volumeHopsEach:
+			volumeHops = volumeHopsEach
+		default:
+			p.Add("invalid SWAP_VOLUME_HOPS %q, expected %q or %q", hops, volumeHopsEndpoints, volumeHopsEach)
+		}
+	}
+
+	// Optionally estimate the price impact of matched swaps from the pool reserves
+	p.Bool("PRICE_IMPACT", &estimatePriceImpact)
+	if estimatePriceImpact {
+		p.Duration("PRICE_IMPACT_CACHE_TTL", &reservesTTL, false)
+	}
+}
+
+// setupAlerts reads the optional webhook and Telegram notifications of matched transactions
+func setupAlerts(p *settings.Problems) {
+	// Optional webhook for matched transactions above ALERT_VALUE_ETH, or all of them without a threshold
+	webhookURL = os.Getenv("WEBHOOK_URL")
+	p.Duration("WEBHOOK_TIMEOUT", &webhookTimeout, false)
+	p.Int("WEBHOOK_RETRIES", &webhookRetries, 0)
+	alertMinValue = nil
+	if v := os.Getenv("ALERT_VALUE_ETH"); v != "" {
+		if threshold, ok := parseEther(v); ok {
+			alertMinValue = threshold
+		} else {
+			p.Add("invalid ALERT_VALUE_ETH %q", v)
+		}
+	}
+
+	// Optional Telegram notifications for matched transactions, optionally above TELEGRAM_MIN_VALUE_ETH
+	telegramToken = os.Getenv("TELEGRAM_BOT_TOKEN")
+	logging.AddSecret(telegramToken)
+	telegramChatID = os.Getenv("TELEGRAM_CHAT_ID")
+	p.Duration("TELEGRAM_INTERVAL", &telegramInterval, false)
+	telegramMinValue = nil
+	if v := os.Getenv("TELEGRAM_MIN_VALUE_ETH"); v != "" {
+		if threshold, ok := parseEther(v); ok {
+			telegramMinValue = threshold
+		} else {
+			p.Add("invalid TELEGRAM_MIN_VALUE_ETH %q", v)
+		}
 	}
 }
 
-// MonitorMempool connects to the Ethereum mempool via WebSocket and listens for new pending transactions
-func MonitorMempool(ctx context.Context, tpsChan chan uint64, txChan chan string, txDetailsChan chan string) {
-	// Setup a dialer for connecting with basic authentication
-	dialer := websocket.Dialer{
-		Proxy: http.ProxyFromEnvironment,
+// setupTokenCache reads the token and price cache settings; the caches themselves are loaded by
+// loadDataFiles
+func setupTokenCache(p *settings.Problems) {
+	cache.PriceOracleURL = os.Getenv("PRICE_ORACLE_URL")
+	p.Duration("PRICE_CACHE_TTL", &cache.PriceTTL, true)
+
+	// Bound the token cache before restoring it so an oversized cache file is trimmed on load
+	maxEntries := -1 // Unset keeps the current limit
+	p.Int("TOKEN_CACHE_MAX_ENTRIES", &maxEntries, 0)
+	if maxEntries >= 0 {
+		cache.TokenCache.SetMaxSize(maxEntries) // 0 disables the limit
+	}
+
+	// Re-fetch token details after TOKEN_CACHE_TTL in case a token changed its name or symbol
+	cache.TokenTTL = 0
+	p.Duration("TOKEN_CACHE_TTL", &cache.TokenTTL, true)
+
+	if path := os.Getenv("TOKEN_CACHE_PATH"); path != "" {
+		tokenCachePath = path
+	}
+	p.Duration("TOKEN_CACHE_SAVE_INTERVAL", &tokenCacheSaveInterval, false)
+}
+
+// setupHeads reads the block head subscription settings and where block tracking is persisted
+func setupHeads(p *settings.Problems) {
+	subscribeHeads = false
+	p.Bool("NEW_HEADS", &subscribeHeads)
+	p.Duration("PENDING_TRACK_TTL", &pendingTrackTTL, false)
+	if subscribeHeads {
+		if path := os.Getenv("BLOCK_STATE_PATH"); path != "" {
+			blockStatePath = path
+		}
+		p.Int("BACKFILL_MAX_BLOCKS", &backfillMaxBlocks, 0) // 0 disables backfilling
+	}
+}
+
+// setupSinks reads how the sinks and the capture file buffer, rotate and sync their records. JSON
+// output is enabled after Setup, so its buffering is always read.
+func setupSinks(p *settings.Problems) {
+	outputBuffer = sink.BufferConfigFromEnv("OUTPUT", p)
+	if os.Getenv("PARQUET_DIR") != "" {
+		parquetBuffer = sink.BufferConfigFromEnv("PARQUET", p)
+		parquetRotation = sink.RotationPolicyFromEnv("PARQUET", p)
+	}
+	if os.Getenv("JSONL_PATH") != "" {
+		jsonlBuffer = sink.BufferConfigFromEnv("JSONL", p)
+		jsonlRotation = sink.RotationPolicyFromEnv("JSONL", p)
+		jsonlFsync = false
+		p.Bool("JSONL_FSYNC", &jsonlFsync)
+	}
+	if os.Getenv("CSV_OUTPUT") != "" {
+		csvBuffer = sink.BufferConfigFromEnv("CSV", p)
+	}
+	if os.Getenv("CAPTURE_FILE") != "" {
+		captureBuffer = sink.BufferConfigFromEnv("CAPTURE", p)
+		captureRotation = sink.RotationPolicyFromEnv("CAPTURE", p)
+		captureFsync = false
+		p.Bool("CAPTURE_FSYNC", &captureFsync)
+	}
+}
+
+// setupChains sets up a monitor per chain, each with its own endpoints and contracts file
+func setupChains(cfg Config) error {
+	monitors = nil
+	for _, chainCfg := range cfg.Chains {
+		m, err := newMonitor(chainCfg)
+		if err != nil {
+			return err
+		}
+		monitors = append(monitors, m)
+
+		// Keep API keys and passwords out of the logs, which are also served at /logs
+		addEndpointSecrets(chainCfg.WSEndpoint)
+		for _, endpoint := range strings.Split(chainCfg.HTTPSEndpoint, ",") {
+			addEndpointSecrets(endpoint)
+		}
+		logging.AddSecret(chainCfg.Password)
+	}
+	for _, m := range monitors {
+		m.warnIfNoContracts()
 	}
 
-	header := http.Header{}
-	header.Set("Authorization", "Basic "+basicAuth(username, password))
+	// Token lookups without a chain, such as from detail templates, go to the first chain
+	cache.DefaultChain = monitors[0].chain
+
+	// Decode calls wrapped in Safe transactions against the ABIs watched on the same chain
+	decoder.InnerCallABI = innerCallABI
+	return nil
+}
 
-	// Init the RPC
-	cache.InitializeRPCClient()
-	defer cache.RpcClient.Close()
+// warnUnusedTokenOverrides reports overrides grouped under a chain id no configured chain has, such
+// as mainnet overrides for a single chain set up without CHAIN_ID
+func warnUnusedTokenOverrides(path string) {
+	configured := make(map[uint64]bool, len(monitors))
+	for _, m := range monitors {
+		configured[m.chainID] = true
+	}
+	for _, id := range cache.OverrideChainIDs() {
+		if !configured[id] {
+			logging.Warnf("Token overrides for chain %d in %s are never used since no configured chain has that id; set CHAIN_ID or list them by address", id, path)
+		}
+	}
+}
 
-	// Connect to the WebSocket
-	conn, _, err := dialer.Dial(wsEndpoint, header)
+// loadDataFiles loads the address book, selectors, rules, MEV bots and token data, once the chains
+// are set up
+func loadDataFiles() error {
+	// Label known addresses such as exchange wallets with the optional address book
+	if path := os.Getenv("ADDRESS_BOOK_PATH"); path != "" {
+		addressBookPath = path
+	}
+	book, err := LoadAddressBook(addressBookPath)
 	if err != nil {
-		log.Fatalf("Failed to connect to WebSocket: %v", err)
+		return fmt.Errorf("error loading address book: %w", err)
 	}
-	defer conn.Close()
+	addressBook = book
+	decoder.AddressLabel = addressLabel
 
-	// Subscribe to new pending transactions
-	subscribe := `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newPendingTransactions"]}`
-	if err := conn.WriteMessage(websocket.TextMessage, []byte(subscribe)); err != nil {
-		log.Fatalf("Failed to subscribe: %v", err)
+	// Load the relevant selectors and their names, falling back to the built-in set without a config file
+	selectorsPath := os.Getenv("SELECTORS_PATH")
+	if selectorsPath == "" {
+		selectorsPath = "configs/selectors.json"
+	}
+	if err := LoadSelectors(selectorsPath); err != nil {
+		return fmt.Errorf("error loading selectors: %w", err)
+	}
+	if len(relevantSelectors) == 0 {
+		useBuiltinSelectors()
 	}
 
-	// Create a channel to handle incoming messages
-	msgChan := make(chan string)
+	// Apply a custom details template; a broken template is reported and the default output kept
+	if err := decoder.LoadDetailTemplate(os.Getenv("DETAIL_TEMPLATE"), os.Getenv("DETAIL_TEMPLATE_FILE")); err != nil {
+		logging.Warnf("Error loading detail template, falling back to the default format: %v", err)
+	}
 
-	// Launch a goroutine to listen to incoming messages
-	go func() {
-		for {
-			_, message, err := conn.ReadMessage()
-			if err != nil {
-				log.Printf("Error reading message: %v", err)
-				return
+	// Load the optional post-decode calldata rules
+	rulesPath := os.Getenv("RULES_PATH")
+	if rulesPath == "" {
+		rulesPath = "configs/rules.json"
+	}
+	rules, err = LoadRules(rulesPath)
+	if err != nil {
+		return fmt.Errorf("error loading rules: %w", err)
+	}
+
+	if mevBotsPath != "" {
+		mevBots, err = LoadMEVBots(mevBotsPath)
+		if err != nil {
+			return fmt.Errorf("error loading MEV bot list: %w", err)
+		}
+	}
+
+	// Load token metadata overrides so they are consulted before any RPC fetch. Overrides listed by
+	// address apply to the first chain; those grouped under a chain id only apply to that chain.
+	tokenOverridesPath := os.Getenv("TOKEN_OVERRIDES_PATH")
+	if tokenOverridesPath == "" {
+		tokenOverridesPath = "configs/token_overrides.json"
+	}
+	if err := cache.LoadTokenOverrides(tokenOverridesPath); err != nil {
+		return fmt.Errorf("error loading token overrides: %w", err)
+	}
+	warnUnusedTokenOverrides(tokenOverridesPath)
+
+	// Optionally annotate token amounts with their USD value, from Chainlink feeds or a price oracle
+	priceFeedsPath := os.Getenv("PRICE_FEEDS_PATH")
+	if priceFeedsPath == "" {
+		priceFeedsPath = "configs/price_feeds.json"
+	}
+	if err := cache.LoadPriceFeeds(priceFeedsPath); err != nil {
+		return fmt.Errorf("error loading price feeds: %w", err)
+	}
+
+	// Restore token details fetched in previous runs to save RPC calls
+	if err := cache.LoadTokenCache(tokenCachePath); err != nil {
+		logging.Warnf("Error loading token cache, starting with an empty cache: %v", err)
+	}
+
+	// Resume inclusion tracking from the last block processed by the previous run
+	if subscribeHeads {
+		if err := loadBlockState(blockStatePath); err != nil {
+			logging.Warnf("Error loading block state, tracking from the next block: %v", err)
+		}
+	}
+	return nil
+}
+
+// MonitorMempool connects to the mempool of every configured chain and listens for new pending
+// transactions, processing those of all chains with a shared pool of workers. It returns nil once
+// the context is cancelled, or an error when a chain's RPC client can't be set up.
+func MonitorMempool(ctx context.Context, tpsChan chan TPS, txChan chan string, txDetailsChan chan string, headsChan chan BlockHead, minedTxChan chan MinedTx) error {
+	for _, m := range monitors {
+		// Repeat the empty watchlist warning now that logs are shown in the TUI
+		m.warnIfNoContracts()
+
+		// Init the RPC client of the chain
+		if err := m.dial(); err != nil {
+			return err
+		}
+		defer m.chain.Client.Close()
+
+		// Recovering senders needs the chain id the transactions are signed for
+		if verifySenders {
+			if err := m.detectChainID(ctx); err != nil {
+				return err
 			}
-			msgChan <- string(message)
 		}
+
+		// Keep the suggested gas price fresh for coloring matched transactions, and reload the
+		// contracts whenever their file changes
+		if colorGasPrices {
+			go m.pollGasOracle(cache.WithChain(ctx, m.chain))
+		}
+		go m.watchContracts(ctx)
+	}
+	go watchAddressBook(ctx)
+	decoder.LookupContext = ctx // Abandon token lookups made while formatting on shutdown
+
+	// Reload runtime-updatable configuration such as the MEV bot list on SIGHUP
+	go reloadOnSignal(ctx)
+
+	// Open the configured sinks; they are flushed and closed when monitoring stops
+	openSinks()
+	defer closeSinks()
+	openCapture()
+	defer closeCapture()
+
+	// Persist the token cache periodically and once more on shutdown
+	go saveTokenCachePeriodically(ctx)
+	defer saveTokenCache()
+
+	// Save the last processed blocks once more on shutdown; they are also saved after every head
+	if subscribeHeads {
+		defer saveBlockState()
+	}
+
+	// Create a buffered channel to handle incoming messages so short processing stalls don't block the reader
+	msgChan := make(chan wsMessage, msgBufferSize)
+
+	// Expose the channel depths through the stats, which the API may already be serving
+	statsQueues.Store(&pipelineQueues{msg: msgChan, tx: txChan, txDetails: txDetailsChan, tps: tpsChan})
+	headChan, minedChan = headsChan, minedTxChan
+
+	// Keep each chain's subscription alive in the background, reconnecting as needed, or replay a file
+	// instead as if it came from the first chain. readerDone is closed once every reader exits.
+	readerDone := make(chan struct{})
+	var readers sync.WaitGroup
+	if replayPath != "" {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			if err := monitors[0].replayMessages(ctx, msgChan); err != nil {
+				logging.Errorf("Replay error: %v", err)
+			}
+		}()
+	} else {
+		for _, m := range monitors {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				m.receive(ctx, msgChan)
+			}()
+		}
+	}
+	go func() {
+		readers.Wait()
+		close(readerDone)
 	}()
 
-	// Use a ticker to calculate and display TPS every second
+	// Post webhook alerts and Telegram notifications in the background
+	startWebhookSenders(ctx)
+	startTelegramSender(ctx)
+
+	// Report UI updates dropped because the display fell behind
+	go reportDroppedUpdates(ctx)
+
+	// Process messages with a fixed number of workers so bursts queue up instead of spawning unbounded goroutines
+	var workers sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			processWorker(ctx, msgChan, txChan, txDetailsChan)
+		}()
+	}
+
+	// Use a ticker to calculate and display TPS every second, along with its rolling average
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
+	tpsHistory := newTPSWindow(tpsWindowSize)
 
-	// Use a goroutine and channel to handle messages
+	// Report TPS until monitoring stops
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Println("Shutting down mempool monitoring...")
-			return
+			logging.Infof("Shutting down mempool monitoring...")
+			// The connection is closed on cancellation, so the reader exits promptly; in-flight
+			// requests are cancelled along with the context
+			awaitShutdown(readerDone, &workers, txChan, txDetailsChan)
+			return nil
 		case <-ticker.C:
 			// Calculate and display TPS
 			currentTxCount := atomic.SwapUint64(&txCount, 0) // Atomically get and reset the transaction count
-			tpsChan <- currentTxCount
-		case msg := <-msgChan:
-			go processTransaction(msg, txChan, txDetailsChan) // Process transaction in a separate goroutine
+			atomic.StoreUint64(&metricTPS, currentTxCount)
+			sendUpdate(tpsChan, tpsHistory.add(currentTxCount), &droppedTps)
 		}
 	}
 }
 
 // Filter transactions based on relevant selectors
 func filterTransaction(inputData string) bool {
-
+	// Remove the "0x" prefix before checking the length, since the prefix isn't part of the selector
+	inputData = strings.TrimPrefix(inputData, "0x")
 	if len(inputData) < 8 {
-		//log.Printf("Invalid input data (too short): %s", inputData)
-		return false
+		return false // Too short to hold a selector, e.g. a plain transfer
 	}
-	// Remove the "0x" prefix
-	inputData = strings.TrimPrefix(inputData, "0x")
 
 	// Get the method selector (first 4 bytes)
 	methodSelector := inputData[:8]
@@ -165,111 +689,494 @@ func filterTransaction(inputData string) bool {
 	return exists
 }
 
+// applyFilters runs a transaction through the selector, contract, decode and calldata rule checks.
+// It returns the matched contract and the decode (nil if the ABI couldn't decode it) when the
+// transaction should be shown. A non-nil trace records why each check passed or failed.
+func (m *Monitor) applyFilters(result decoder.TransactionResult, trace *filterTrace) (Contract, *decoder.DecodedTransaction, bool) {
+	// Drop ignored senders, and with WATCH_FROM everyone but the watched ones
+	passed, watched := matchSender(result.Result.From)
+	if watchFrom != nil || ignoreFrom != nil {
+		trace.record("sender", passed, "sender %q (watched: %t, %d watched, %d ignored)", result.Result.From, watched, len(watchFrom), len(ignoreFrom))
+	}
+	if !passed {
+		return Contract{}, nil, false
+	}
+
+	// Filter based on the relevant selectors; a watched sender is tracked whatever it calls
+	switch {
+	case watched:
+		trace.record("selector", true, "%s is sent by a watched sender", describeSelector(result.Result.Input))
+	case !filterTransaction(result.Result.Input):
+		trace.record("selector", false, "%s is not a relevant selector", describeSelector(result.Result.Input))
+		return Contract{}, nil, false
+	default:
+		trace.record("selector", true, "%s is a relevant selector", describeSelector(result.Result.Input))
+	}
+
+	// Skip dust below the minimum value; this comes after counting, so TPS still reflects every transaction
+	if minValue != nil {
+		value, err := hexutil.DecodeBig(result.Result.Value)
+		if err != nil {
+			value = new(big.Int) // Treat a missing or malformed value as zero
+		}
+		if value.Cmp(minValue) < 0 {
+			trace.record("value", false, "%s ETH is below the minimum of %s ETH", decoder.FormatUnits(value, etherDecimals), decoder.FormatUnits(minValue, etherDecimals))
+			return Contract{}, nil, false
+		}
+		trace.record("value", true, "%s ETH meets the minimum of %s ETH", decoder.FormatUnits(value, etherDecimals), decoder.FormatUnits(minValue, etherDecimals))
+	}
+
+	// Check if the transaction is to one of the loaded contracts, optionally decoding transactions to
+	// other contracts with the generic ABI since their selector is relevant
+	contract, ok := m.matchContract(result.Result.To)
+	switch {
+	case ok:
+		trace.record("contract", true, "sent to watched contract %s", contract.Name)
+	case (genericDecode || watched) && result.Result.To != "":
+		contract = genericContract(result.Result.To)
+		trace.record("contract", true, "%q is not a watched contract; decoding with the generic ABI", result.Result.To)
+	default:
+		trace.record("contract", false, "%q is not a watched contract", result.Result.To)
+		return Contract{}, nil, false
+	}
+
+	// Separate known MEV bot traffic from organic activity
+	passed, enabled, isBot := matchMEVBots(result.Result.From)
+	if enabled {
+		label := "not a listed MEV bot"
+		if isBot {
+			label = "a listed MEV bot"
+		}
+		trace.record("mev-bots", passed, "sender %q is %s (mode: %s)", result.Result.From, label, mevBotsMode)
+	}
+	if !passed {
+		return Contract{}, nil, false
+	}
+
+	// Decode the input up front so calldata rules can be applied before anything is displayed
+	// A transaction that can't be decoded is still shown, just without its parameters
+	decoded, err := decoder.DecodeInputData(result, string(contract.ABI))
+	if err != nil {
+		logging.Warnf("Failed to decode transaction %s: %v", result.Result.Hash, err)
+		trace.record("decode", true, "could not decode with the %s ABI (%v); shown with the selector name only", contract.Name, err)
+	} else {
+		decoded.Timestamp = time.Now()
+		decoded.Chain = m.name
+		decoded.Contract = contract.Name
+		trace.record("decode", true, "decoded as %s with %d params", decoded.Method, len(decoded.Params))
+	}
+
+	// Skip transactions whose decoded parameters don't satisfy the configured rules
+	if !matchRules(decoded) {
+		trace.record("rules", false, "no calldata rule matched (%d configured)", len(rules))
+		return contract, decoded, false
+	}
+	trace.record("rules", true, "calldata rules satisfied (%d configured)", len(rules))
+
+	return contract, decoded, true
+}
+
+// Default timeout of a single request to the HTTPS endpoint
+const defaultRPCTimeout = 30 * time.Second
+
+// rpcTransport pools the connections to the HTTPS endpoints of every chain. Each chain's HTTP client
+// wraps it to fail over between its endpoints, with a timeout (RPC_TIMEOUT) that keeps a hung
+// provider from stalling a worker forever.
+var rpcTransport = &http.Transport{
+	Proxy:               http.ProxyFromEnvironment, // Setup switches this to SOCKS5_PROXY when set
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 100,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// processWorker handles messages from msgChan one at a time until the context is cancelled
+func processWorker(ctx context.Context, msgChan <-chan wsMessage, txChan chan string, txDetailsChan chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-msgChan:
+			atomic.AddInt64(&busyWorkers, 1)
+			msg.monitor.processTransaction(ctx, msg.data, msg.received, txChan, txDetailsChan)
+			atomic.AddInt64(&busyWorkers, -1)
+		}
+	}
+}
+
+// jsonRPCError is the error object of a failed JSON-RPC call
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// newRPCRequest builds an authenticated JSON-RPC POST request to the chain's HTTPS endpoint, cancelled along with ctx
+func (m *Monitor) newRPCRequest(ctx context.Context, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", m.httpsEndpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if m.hasBasicAuth() {
+		req.SetBasicAuth(m.username, m.password)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return req, nil
+}
+
 // Fetch the full transaction details and check if it pertains to one of the loaded contracts
-func fetchTransactionDetails(txHash string, txChan chan string, txDetailsChan chan string) {
-	// Define the payload for the JSON-RPC request
-	payload := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getTransactionByHash","params":["%s"],"id":1}`, txHash)
+func (m *Monitor) fetchTransactionDetails(ctx context.Context, txHash string, timing *txTiming, txChan chan string, txDetailsChan chan string) {
+	result, err := m.lookupTransaction(ctx, txHash)
+
+	// The HTTPS node may not have seen a transaction announced over the WebSocket yet, in which case
+	// the lookup comes back empty; retry a few times with a growing delay before giving up
+	delay := notFoundRetryDelay
+	for attempt := 0; err == nil && result.Result.Hash == "" && attempt < notFoundRetries; attempt++ {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay *= 2
+		result, err = m.lookupTransaction(ctx, txHash)
+	}
 
-	req, err := http.NewRequest("POST", httpsEndpoint, bytes.NewBuffer([]byte(payload)))
 	if err != nil {
-		log.Printf("Failed to create request: %v", err)
+		if ctx.Err() != nil {
+			return // Shutting down
+		}
+		atomic.AddUint64(&metricRPCErrors, 1)
+		m.logf(slog.LevelWarn, "Failed to fetch transaction %s: %v", txHash, err)
+		return
+	}
+	if result.Result.Hash == "" {
+		// Still count it towards the TPS, once, even though it can't be matched
+		atomic.AddUint64(&txCount, 1)
+		logging.Debugf("Transaction %s was not found after %d retries", txHash, notFoundRetries)
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(username, password)
+	timing.fetched = time.Now()
+	m.handleTransaction(ctx, result, timing, txChan, txDetailsChan)
+}
+
+// lookupTransaction fetches a transaction by hash, as part of a batch when batching is enabled. The
+// result is empty when the node doesn't know the transaction.
+func (m *Monitor) lookupTransaction(ctx context.Context, txHash string) (decoder.TransactionResult, error) {
+	if m.batcher != nil {
+		return m.batcher.fetch(ctx, txHash)
+	}
+
+	var result decoder.TransactionResult
+
+	// Define the payload for the JSON-RPC request
+	payload := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getTransactionByHash","params":["%s"],"id":1}`, txHash)
+
+	req, err := m.newRPCRequest(ctx, []byte(payload))
+	if err != nil {
+		return result, fmt.Errorf("failed to create request: %w", err)
+	}
 
 	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	if err := waitForRPC(ctx); err != nil {
+		return result, err
+	}
+	atomic.AddInt64(&inFlightRPC, 1)
+	resp, err := m.httpClient.Do(req)
+	atomic.AddInt64(&inFlightRPC, -1)
 	if err != nil {
-		log.Printf("Failed to send request: %v", err)
-		return
+		return result, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Parse the response
-	var result struct {
-		Result struct {
-			BlockHash        string `json:"blockHash"`
-			BlockNumber      string `json:"blockNumber"`
-			From             string `json:"from"`
-			Gas              string `json:"gas"`
-			GasPrice         string `json:"gasPrice"`
-			Hash             string `json:"hash"`
-			Input            string `json:"input"`
-			Nonce            string `json:"nonce"`
-			To               string `json:"to"`
-			TransactionIndex string `json:"transactionIndex"`
-			Value            string `json:"value"`
-			V                string `json:"v"`
-			R                string `json:"r"`
-			S                string `json:"s"`
-		} `json:"result"`
-	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("Failed to decode response: %v", err)
-		return
+		return result, fmt.Errorf("failed to decode response: %w", err)
 	}
+	return result, nil
+}
 
+// handleTransaction counts a pending transaction and, if it is relevant to a watched contract,
+// decodes it and sends it to the TUI and sinks
+func (m *Monitor) handleTransaction(ctx context.Context, result decoder.TransactionResult, timing *txTiming, txChan chan string, txDetailsChan chan string) {
 	atomic.AddUint64(&txCount, 1)
+	result.Chain = m.chain // Token lookups while decoding go to the chain the transaction was seen on
+	recordGasPrice(result.Result.GasPrice)
+	recordGasPercentile(result.Result.GasPrice, false)
 
-	// Filter based on the relevant selectors
-	if !filterTransaction(result.Result.Input) {
+	// Run the selector, contract, decode and rule checks
+	contract, decoded, ok := m.applyFilters(result, nil)
+	timing.decoded = time.Now()
+	if !ok {
 		return // Skip transactions that are not relevant
 	}
+	recordMatched(contract.Name)
+	countContractMatch(m.name, result.Result.To)
+	recordGasPercentile(result.Result.GasPrice, true)
 
-	// Check if the transaction is to one of the loaded contracts
-	// Check if the transaction is to one of the loaded contracts
-	for _, contract := range contracts {
-		if result.Result.To != "" && common.HexToAddress(result.Result.To) == common.HexToAddress(contract.Address) {
-			recentTx := fmt.Sprintf("Transaction to contract (%s) at %s:\n", contract.Name, time.Now())
-			recentTx += fmt.Sprintf("Hash: %s\n", result.Result.Hash)
-			recentTx += fmt.Sprintf("From: %s\n", result.Result.From)
-			recentTx += fmt.Sprintf("To: %s\n", result.Result.To)
-			recentTx += fmt.Sprintf("Value: %s\n", result.Result.Value)
-			recentTx += fmt.Sprintf("Gas: %s\n", result.Result.Gas)
-			recentTx += fmt.Sprintf("Gas Price: %s\n", result.Result.GasPrice)
-			recentTx += fmt.Sprintf("Nonce: %s\n", result.Result.Nonce)
-			recentTx += fmt.Sprintf("Block Hash: %s\n", result.Result.BlockHash)
-			recentTx += fmt.Sprintf("Block Number: %s\n", result.Result.BlockNumber)
-			recentTx += fmt.Sprintf("Transaction Index: %s\n", result.Result.TransactionIndex)
-			recentTx += fmt.Sprintf("Input Data: %s\n", result.Result.Input)
-			recentTx += fmt.Sprintf("V: %s, R: %s, S: %s\n", result.Result.V, result.Result.R, result.Result.S)
-
-			txChan <- recentTx // Send the transaction details to the channel
+	method := describeSelector(result.Result.Input)
+	if decoded != nil {
+		method = decoded.Method
+	}
 
-			decoder.DecodeInputData(result, string(contract.ABI), txDetailsChan) // Use the decoder to parse the input
+	// The header and method are colored by the method's category so the feed is easy to scan
+	chainLabel := ""
+	if m.name != "" {
+		chainLabel = " on " + m.name
+	}
+	recentTx := colorizeMethod(method, fmt.Sprintf("Transaction%s to contract (%s) at %s:", chainLabel, contract.Name, time.Now())) + "\n"
+	recentTx += fmt.Sprintf("Hash: %s\n", result.Result.Hash)
+	recentTx += colorizeMethod(method, fmt.Sprintf("Method: %s", describeSelector(result.Result.Input))) + "\n"
+	recentTx += fmt.Sprintf("Type: %s\n", decoder.TxTypeLabel(result.Result.Type))
+	recentTx += fmt.Sprintf("From: %s\n", labelAddress(result.Result.From))
+	recentTx += fmt.Sprintf("To: %s\n", labelAddress(result.Result.To))
+	recentTx += fmt.Sprintf("Value: %s\n", formatEther(result.Result.Value, m.chain.NativeSymbol()))
+	recentTx += fmt.Sprintf("Gas: %s\n", formatQuantity(result.Result.Gas))
+	recentTx += fmt.Sprintf("Gas Price: %s\n", m.formatGasPrice(result.Result.GasPrice))
+	if result.Result.MaxFeePerGas != "" {
+		recentTx += fmt.Sprintf("Max Fee: %s\n", formatGwei(result.Result.MaxFeePerGas))
+		recentTx += fmt.Sprintf("Max Priority Fee: %s\n", formatGwei(result.Result.MaxPriorityFee))
+		if tip, ok := effectivePriorityFee(m.latestBaseFee.Load(), result.Result.MaxFeePerGas, result.Result.MaxPriorityFee); ok {
+			recentTx += fmt.Sprintf("Effective Priority Fee: %s Gwei at the latest base fee\n", decoder.FormatUnits(tip, gweiDecimals))
+		}
+	}
+	if pendingGasPrices != nil {
+		recentTx += fmt.Sprintf("Inclusion (heuristic): %s\n", estimateInclusion(result.Result.GasPrice))
+	}
+	recentTx += fmt.Sprintf("Nonce: %s\n", formatQuantity(result.Result.Nonce))
+	recentTx += fmt.Sprintf("Block Hash: %s\n", result.Result.BlockHash)
+	recentTx += fmt.Sprintf("Block Number: %s\n", result.Result.BlockNumber)
+	recentTx += fmt.Sprintf("Transaction Index: %s\n", result.Result.TransactionIndex)
+	recentTx += fmt.Sprintf("Input Data: %s\n", result.Result.Input)
+	recentTx += fmt.Sprintf("V: %s, R: %s, S: %s\n", result.Result.V, result.Result.R, result.Result.S)
 
-			break
+	// Without an ABI decode, at least name the method from the known selectors
+	details := fmt.Sprintf("TxHash: %s\n%s\n", result.Result.Hash, colorizeMethod(method, "Method: "+describeSelector(result.Result.Input)))
+	if decoded != nil {
+		details = decoder.FormatDetails(decoded)
+		if m.name != "" {
+			details = fmt.Sprintf("Chain: %s\n", m.name) + details
+		}
+		if estimatePriceImpact {
+			if impact, ok := describePriceImpact(ctx, decoded); ok {
+				details += fmt.Sprintf("Price Impact: %s\n", impact)
+			}
 		}
 	}
+	if warning := m.checkSender(result, decoded); warning != "" {
+		details += fmt.Sprintf("[red]%s[-]\n", warning)
+	}
+	if simulateReverts {
+		if reason, reverts := simulateRevert(ctx, result); reverts {
+			details += fmt.Sprintf("[red]WILL REVERT: %s[-]\n", reason)
+		}
+	}
+	timing.enriched = time.Now()
+
+	// Tag both entries with the hash so the TUI can show the details of the transaction selected in the feed
+	if !unifiedLayout {
+		recentTx, details = txRegion(result.Result.Hash, recentTx), txRegion(result.Result.Hash, details)
+	}
+
+	// Never wait on the UI; entries it has no room for are dropped and counted
+	if unifiedLayout {
+		// Keep the summary and its decoded details together as one contiguous entry
+		sendUpdate(txChan, recentTx+details, &droppedTx)
+	} else {
+		sendUpdate(txChan, recentTx, &droppedTx)              // Send the transaction summary to the feed
+		sendUpdate(txDetailsChan, details, &droppedTxDetails) // Send the decoded details to their own pane
+	}
+	timing.finish(result.Result.Hash)
+
+	// Watch for the block that includes it to measure its mempool dwell time
+	m.trackPending(result.Result.Hash, timing.received)
+
+	// Alert on high-value transactions; the webhook and Telegram messages are sent in the background
+	alertHighValue(m.name, result, contract, method)
+	notifyTelegram(m.name, result, contract, method)
+
+	// Hand the structured result to the sinks and the volume totals. A transaction that couldn't be
+	// decoded still gets a record, without its parameters.
+	if decoded == nil {
+		publish(m.undecodedTransaction(result, contract))
+		return
+	}
+	decoded.FromLabel, decoded.ToLabel = hexAddressLabel(decoded.From), hexAddressLabel(decoded.To)
+	publish(*decoded)
+	if trackSwapVolume {
+		recordSwapVolume(ctx, decoded)
+	}
+}
+
+// undecodedTransaction is the record published for a matched transaction whose input couldn't be
+// decoded with its contract's ABI, with the method named from the known selectors
+func (m *Monitor) undecodedTransaction(result decoder.TransactionResult, contract Contract) decoder.DecodedTransaction {
+	return decoder.DecodedTransaction{
+		Hash:      result.Result.Hash,
+		Timestamp: time.Now(),
+		Chain:     m.name,
+		Contract:  contract.Name,
+		From:      result.Result.From,
+		FromLabel: hexAddressLabel(result.Result.From),
+		To:        result.Result.To,
+		ToLabel:   hexAddressLabel(result.Result.To),
+		Value:     result.Result.Value,
+		Gas:       result.Result.Gas,
+		GasPrice:  result.Result.GasPrice,
+		Type:      decoder.TxTypeLabel(result.Result.Type),
+		Method:    describeSelector(result.Result.Input),
+		Params:    []decoder.DecodedParam{},
+
+		MaxFeePerGas:         result.Result.MaxFeePerGas,
+		MaxPriorityFeePerGas: result.Result.MaxPriorityFee,
+	}
+}
+
+// txRegion wraps a feed entry in a tview region named after the transaction hash. Entries of hashes
+// that aren't hex are left as they are, as region names can't hold arbitrary text.
+func txRegion(hash, entry string) string {
+	if _, err := hexutil.Decode(hash); err != nil {
+		return entry
+	}
+	return `["` + hash + `"]` + entry + `[""]`
 }
 
-// Process the transaction to check if it pertains to any of the loaded contracts
-func processTransaction(msg string, txChan chan string, txDetailsChan chan string) {
+// Process the transaction to check if it pertains to any of the loaded contracts. Subscription
+// notifications carry either a transaction hash, which is fetched over RPC, or the full
+// transaction object, which is handled directly without a fetch.
+func (m *Monitor) processTransaction(ctx context.Context, msg string, received time.Time, txChan chan string, txDetailsChan chan string) {
+	atomic.AddInt64(&inFlightTransactions, 1)
+	defer atomic.AddInt64(&inFlightTransactions, -1)
+
+	// RPC calls made for the transaction, such as token lookups, go to its chain
+	ctx = cache.WithChain(ctx, m.chain)
+
 	// Define the correct struct based on the provided JSON
 	var tx struct {
-		Jsonrpc string `json:"jsonrpc"`
-		Method  string `json:"method"`
+		Jsonrpc string          `json:"jsonrpc"`
+		ID      int             `json:"id"`
+		Method  string          `json:"method"`
+		Result  json.RawMessage `json:"result"` // Subscription id in subscribe responses
+		Error   *jsonRPCError   `json:"error"`
 		Params  struct {
-			Subscription string `json:"subscription"`
-			Result       string `json:"result"` // Transaction hash
+			Subscription string          `json:"subscription"`
+			Result       json.RawMessage `json:"result"` // Transaction hash, full transaction object or block header
 		} `json:"params"`
 	}
 
 	// Attempt to parse the JSON message
 	err := json.Unmarshal([]byte(msg), &tx)
 	if err != nil {
-		log.Printf("Failed to parse transaction message: %v", err)
+		logging.Warnf("Failed to parse transaction message: %v", err)
+		return
+	}
+
+	// Only subscription notifications carry transactions; subscribe responses are only of interest for
+	// newHeads, or when the provider rejects the pending transaction subscription
+	if tx.Method != "eth_subscription" {
+		switch {
+		case tx.ID == headsSubscribeID:
+			m.handleSubscribeResponse(tx.Result, tx.Error)
+		case tx.ID == pendingSubscribeID && tx.Error != nil:
+			m.logf(slog.LevelError, "Provider rejected the pending transaction subscription: %s", tx.Error.Message)
+			switch {
+			case m.subscription != nil:
+				m.logf(slog.LevelError, "The provider may not support the configured subscription; check SUBSCRIPTION_PARAMS")
+			case fullPendingTransactions:
+				m.logf(slog.LevelError, "The provider may not support full transaction objects; unset PENDING_FULL_TX to subscribe to hashes only")
+			}
+		}
+		return
+	}
+
+	// Block heads share the connection with pending transactions
+	if m.isHeadsNotification(tx.Params.Subscription) {
+		m.handleHead(ctx, tx.Params.Result, received)
+		return
+	}
+
+	// Custom subscriptions may announce several transactions in one notification
+	items, err := notificationItems(tx.Params.Result)
+	if err != nil {
+		logging.Warnf("Failed to parse transaction notification: %v", err)
+		return
+	}
+	for _, item := range items {
+		m.processNotificationItem(ctx, item, received, txChan, txDetailsChan)
+	}
+}
+
+// processNotificationItem handles one transaction announced by a notification, either a hash to
+// fetch or the full transaction object
+func (m *Monitor) processNotificationItem(ctx context.Context, item json.RawMessage, received time.Time, txChan chan string, txDetailsChan chan string) {
+	atomic.AddUint64(&metricTransactionsSeen, 1)
+
+	timing := &txTiming{received: received}
+	result, txHash, err := parseNotificationResult(item)
+	if err != nil {
+		logging.Warnf("Failed to parse transaction notification: %v", err)
+		return
+	}
+
+	// Providers re-announce the same transaction; only the first announcement is looked up and counted
+	hash := txHash
+	if hash == "" {
+		hash = result.Result.Hash
+	}
+	if seenHashes != nil && hash != "" && !seenHashes.firstSeen(hash) {
+		atomic.AddUint64(&metricDuplicates, 1)
 		return
 	}
 
-	// Fetch the transaction details by its hash
-	fetchTransactionDetails(tx.Params.Result, txChan, txDetailsChan)
+	if txHash != "" {
+		// Fetch the transaction details by its hash
+		m.fetchTransactionDetails(ctx, txHash, timing, txChan, txDetailsChan)
+		return
+	}
+
+	// The notification already contains the full transaction, so no fetch is needed
+	timing.fetched = time.Now()
+	m.handleTransaction(ctx, result, timing, txChan, txDetailsChan)
+}
+
+// parseNotificationResult interprets the result of a pending transaction notification. It returns
+// the hash for hash-only notifications, or the parsed transaction for full-object notifications.
+func parseNotificationResult(raw json.RawMessage) (decoder.TransactionResult, string, error) {
+	var result decoder.TransactionResult
+
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return result, "", fmt.Errorf("notification has no result")
+	}
+
+	switch raw[0] {
+	case '"':
+		var txHash string
+		if err := json.Unmarshal(raw, &txHash); err != nil {
+			return result, "", err
+		}
+		return result, txHash, nil
+	case '{':
+		if err := json.Unmarshal(raw, &result.Result); err != nil {
+			return result, "", err
+		}
+
+		// Some providers name the calldata field "data" instead of "input"
+		if result.Result.Input == "" {
+			var alt struct {
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal(raw, &alt); err == nil {
+				result.Result.Input = alt.Data
+			}
+		}
+		return result, "", nil
+	default:
+		return result, "", fmt.Errorf("unexpected result shape: %.20s", raw)
+	}
 }
 
 // basicAuth encodes the username and password for basic authentication
 func basicAuth(username, password string) string {
-	return "Basic " + username + ":" + password
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
 }
@@ -0,0 +1,89 @@
+package mempool
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Short or malformed input data must be rejected without panicking
+func TestFilterTransactionInputLengths(t *testing.T) {
+	saved := relevantSelectors
+	relevantSelectors = map[string]bool{"095ea7b3": true}
+	t.Cleanup(func() { relevantSelectors = saved })
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "empty", input: "", want: false},
+		{name: "prefix only", input: "0x", want: false},
+		{name: "short", input: "0x123456", want: false},
+		{name: "odd length shorter than a selector", input: "0x095ea7b", want: false},
+		{name: "selector only", input: "0x095ea7b3", want: true},
+		{name: "selector without prefix", input: "095ea7b3", want: true},
+		{name: "other selector", input: "0xa9059cbb", want: false},
+		{name: "odd length after the selector", input: "0x095ea7b3abc", want: true}, // Left for the decoder to reject
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterTransaction(tt.input); got != tt.want {
+				t.Errorf("filterTransaction(%q) = %t, want %t", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// A matched transaction that can't be decoded still gets a CSV row, named by its selector
+func TestUndecodableTransactionIsPublished(t *testing.T) {
+	savedSelectors := relevantSelectors
+	relevantSelectors = map[string]bool{"095ea7b3": true}
+	t.Cleanup(func() { relevantSelectors = savedSelectors })
+
+	path := filepath.Join(t.TempDir(), "transactions.csv")
+	t.Setenv("CSV_OUTPUT", path)
+	openSinks()
+	t.Cleanup(func() { sinks = nil })
+
+	router := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	m := &Monitor{
+		chain:              &cache.Chain{ID: 1},
+		contractsByAddress: map[common.Address]Contract{router: {Name: "Router", Address: router.Hex(), ABI: json.RawMessage(`[]`)}},
+	}
+
+	var result decoder.TransactionResult
+	result.Result.Hash = "0xabc"
+	result.Result.From = "0x1111111111111111111111111111111111111111"
+	result.Result.To = router.Hex()
+	result.Result.Value = "0x0"
+	result.Result.GasPrice = "0x3b9aca00"
+	result.Result.Input = "0x095ea7b3" // The ABI has no approve, and the parameters are missing
+	m.handleTransaction(context.Background(), result, &txTiming{received: time.Now()}, make(chan string, 10), make(chan string, 10))
+	closeSinks()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("CSV has %d rows, want a header and one transaction:\n%s", len(rows), data)
+	}
+	if row := rows[1]; row[1] != "0xabc" || row[4] != "Router" || row[5] != "approve (0x095ea7b3)" {
+		t.Errorf("CSV row = %v, want the hash, contract and selector name", row)
+	}
+}
@@ -0,0 +1,141 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// How pending transactions are received (TRANSPORT)
+const (
+	transportWS   = "ws"   // newPendingTransactions subscription over WS_ENDPOINT
+	transportPoll = "poll" // Pending transaction filter, or txpool_content, polled over HTTPS_ENDPOINT
+)
+
+// Default interval between polls in poll mode (POLL_INTERVAL)
+const defaultPollInterval = time.Second
+
+// Interval between polls of chains using the poll transport, read in Setup
+var pollInterval = defaultPollInterval
+
+// pollSubscription stands in for the subscription id in the notifications built from polled hashes
+const pollSubscription = "poll"
+
+// pollPendingTransactions polls the HTTPS endpoint for new pending transaction hashes until the
+// context is cancelled, feeding each into the pipeline as if it had arrived over the subscription.
+// It uses a pending transaction filter, falling back to diffing txpool_content when the node
+// doesn't support filters.
+func (m *Monitor) pollPendingTransactions(ctx context.Context, msgChan chan<- wsMessage) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var filterID string
+	useTxpool := false
+	var known map[string]bool // Hashes in the previous txpool_content result
+
+	for {
+		var hashes []string
+		var err error
+		switch {
+		case useTxpool:
+			hashes, known, err = m.pollTxpool(ctx, known)
+		case filterID == "":
+			// Filters live on a single node and expire when not polled, so this repeats after failures
+			err = m.chain.Client.CallContext(ctx, &filterID, "eth_newPendingTransactionFilter")
+			var rpcErr rpc.Error
+			if errors.As(err, &rpcErr) {
+				// The node answered but doesn't offer filters
+				m.logf(slog.LevelWarn, "Pending transaction filter unavailable (%v); polling txpool_content instead", err)
+				useTxpool = true
+				continue
+			}
+			if err == nil {
+				m.markConnected()
+			}
+		default:
+			if err = m.chain.Client.CallContext(ctx, &hashes, "eth_getFilterChanges", filterID); err != nil {
+				filterID = "" // Likely expired or served by another endpoint; create a new one
+				m.markDisconnected()
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			m.logf(slog.LevelWarn, "Polling error: %v", err)
+		}
+		for _, hash := range hashes {
+			if !m.sendPolledHash(ctx, msgChan, hash) {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollTxpool fetches the node's pending pool and returns the hashes that weren't in the previous
+// result, along with the current set of hashes. The first poll only records what is already pending.
+// Since the pool lists each transaction's recipient, new transactions that can't match are counted
+// but not returned, saving their fetch.
+func (m *Monitor) pollTxpool(ctx context.Context, known map[string]bool) ([]string, map[string]bool, error) {
+	var content struct {
+		Pending map[string]map[string]struct {
+			Hash string `json:"hash"`
+			To   string `json:"to"`
+		} `json:"pending"`
+	}
+	if err := m.chain.Client.CallContext(ctx, &content, "txpool_content"); err != nil {
+		m.markDisconnected()
+		return nil, known, fmt.Errorf("txpool_content failed: %w", err)
+	}
+	m.markConnected()
+
+	var fresh []string
+	current := make(map[string]bool)
+	for _, txs := range content.Pending {
+		for _, tx := range txs {
+			current[tx.Hash] = true
+			switch {
+			case known == nil || known[tx.Hash]:
+			case m.mayWatch(tx.To):
+				fresh = append(fresh, tx.Hash)
+			default:
+				atomic.AddUint64(&metricTransactionsSeen, 1)
+				atomic.AddUint64(&txCount, 1)
+			}
+		}
+	}
+	return fresh, current, nil
+}
+
+// sendPolledHash wraps a polled hash in a subscription notification and queues it for processing.
+// It reports false once the context is cancelled.
+func (m *Monitor) sendPolledHash(ctx context.Context, msgChan chan<- wsMessage, hash string) bool {
+	notification, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "eth_subscription",
+		"params":  map[string]string{"subscription": pollSubscription, "result": hash},
+	})
+	m.markMessageReceived()
+	received := time.Now()
+	capture(notification, received) // Polled hashes can be replayed like the WebSocket stream
+
+	select {
+	case msgChan <- wsMessage{data: string(notification), received: received, monitor: m}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
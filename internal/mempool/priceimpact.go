@@ -0,0 +1,215 @@
+package mempool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Selectors of the Uniswap V2 calls used to locate pools and read their state
+const (
+	factorySelector     = "0xc45a0155" // factory() on the router
+	getPairSelector     = "0xe6a43905" // getPair(address,address) on the factory
+	getReservesSelector = "0x0902f1ac" // getReserves() on the pair
+)
+
+// Default time pool reserves are reused before being fetched again
+const defaultReservesTTL = 12 * time.Second
+
+// Price impact settings; estimatePriceImpact is enabled with PRICE_IMPACT=true since it adds RPC calls
+var (
+	estimatePriceImpact bool
+	reservesTTL         = defaultReservesTTL
+)
+
+// poolReserves are a pair's reserves ordered as the pair stores them (token0, token1)
+type poolReserves struct {
+	reserve0  *big.Int
+	reserve1  *big.Int
+	fetchedAt time.Time
+}
+
+// chainAddress is a contract address on a given chain, since the same address may hold a different
+// contract or state on each chain
+type chainAddress struct {
+	chainID uint64
+	address common.Address
+}
+
+// pairKey identifies a factory's pair of two sorted tokens on a given chain
+type pairKey struct {
+	chainID        uint64
+	factory        common.Address
+	token0, token1 common.Address
+}
+
+// Recently fetched reserves keyed by pair. Factories and pair addresses never change, so they are
+// cached for the whole session.
+var (
+	reservesMu    sync.Mutex
+	reservesCache = make(map[chainAddress]poolReserves)
+	factories     = make(map[chainAddress]common.Address) // Router -> factory
+	pairs         = make(map[pairKey]common.Address)      // Factory and tokens -> pair
+)
+
+// ethCall runs a read-only call against the latest block of the chain carried by ctx
+func ethCall(ctx context.Context, to common.Address, data string) (hexutil.Bytes, error) {
+	var result hexutil.Bytes
+	err := cache.ChainFrom(ctx).Client.CallContext(ctx, &result, "eth_call", map[string]interface{}{
+		"to":   to.Hex(),
+		"data": data,
+	}, "latest")
+	return result, err
+}
+
+// sortTokens orders two tokens the way Uniswap V2 pairs do
+func sortTokens(a, b common.Address) (common.Address, common.Address) {
+	if bytes.Compare(a.Bytes(), b.Bytes()) < 0 {
+		return a, b
+	}
+	return b, a
+}
+
+// pairAddress looks up the pair of two tokens through the factory of the router the swap was sent to
+func pairAddress(ctx context.Context, router, tokenA, tokenB common.Address) (common.Address, error) {
+	token0, token1 := sortTokens(tokenA, tokenB)
+	chainID := cache.ChainFrom(ctx).ID
+	routerKey := chainAddress{chainID, router}
+
+	reservesMu.Lock()
+	factory, knownFactory := factories[routerKey]
+	pair, knownPair := pairs[pairKey{chainID, factory, token0, token1}]
+	reservesMu.Unlock()
+	if knownFactory && knownPair {
+		return pair, nil
+	}
+
+	if !knownFactory {
+		result, err := ethCall(ctx, router, factorySelector)
+		if err != nil || len(result) < 32 {
+			return common.Address{}, fmt.Errorf("failed to fetch factory of router %s: %v", router.Hex(), err)
+		}
+		factory = common.BytesToAddress(result[:32])
+	}
+
+	data := getPairSelector + common.Bytes2Hex(common.LeftPadBytes(token0.Bytes(), 32)) + common.Bytes2Hex(common.LeftPadBytes(token1.Bytes(), 32))
+	result, err := ethCall(ctx, factory, data)
+	if err != nil || len(result) < 32 {
+		return common.Address{}, fmt.Errorf("failed to fetch pair from factory %s: %v", factory.Hex(), err)
+	}
+	pair = common.BytesToAddress(result[:32])
+	if pair == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("no pair for %s/%s", token0.Hex(), token1.Hex())
+	}
+
+	reservesMu.Lock()
+	factories[routerKey] = factory
+	pairs[pairKey{chainID, factory, token0, token1}] = pair
+	reservesMu.Unlock()
+	return pair, nil
+}
+
+// fetchReserves returns a pair's reserves, reusing recently fetched values
+func fetchReserves(ctx context.Context, pair common.Address) (poolReserves, error) {
+	pairKey := chainAddress{cache.ChainFrom(ctx).ID, pair}
+	reservesMu.Lock()
+	cached, exists := reservesCache[pairKey]
+	reservesMu.Unlock()
+	if exists && time.Since(cached.fetchedAt) < reservesTTL {
+		return cached, nil
+	}
+
+	result, err := ethCall(ctx, pair, getReservesSelector)
+	if err != nil {
+		return poolReserves{}, fmt.Errorf("failed to fetch reserves for pair %s: %w", pair.Hex(), err)
+	}
+	if len(result) < 64 {
+		return poolReserves{}, fmt.Errorf("no Uniswap V2 pair at %s", pair.Hex())
+	}
+
+	reserves := poolReserves{
+		reserve0:  new(big.Int).SetBytes(result[:32]),
+		reserve1:  new(big.Int).SetBytes(result[32:64]),
+		fetchedAt: time.Now(),
+	}
+
+	reservesMu.Lock()
+	reservesCache[pairKey] = reserves
+	reservesMu.Unlock()
+	return reserves, nil
+}
+
+// v3SwapMethods are the Uniswap V3 router swaps, whose concentrated liquidity pools can't be
+// estimated from reserves
+var v3SwapMethods = map[string]bool{
+	"exactInputSingle":  true,
+	"exactInput":        true,
+	"exactOutputSingle": true,
+	"exactOutput":       true,
+}
+
+// describePriceImpact estimates how far a Uniswap V2 swap moves the price along its path, using the
+// current reserves of each pair. It returns false when the transaction isn't a recognized swap.
+func describePriceImpact(ctx context.Context, decoded *decoder.DecodedTransaction) (string, bool) {
+	if v3SwapMethods[decoded.Method] {
+		return "unknown (unsupported pool type: Uniswap V3)", true
+	}
+
+	path := swapPath(decoded)
+	amountIn := swapAmountIn(decoded)
+	if path == nil || amountIn == nil {
+		return "", false
+	}
+	if amountIn.Sign() == 0 {
+		return "unknown (no input amount)", true
+	}
+
+	// Walk the path hop by hop, feeding each hop's output into the next. Impact excludes the 0.3% LP fee.
+	remaining := 1.0
+	amount := new(big.Int).Set(amountIn)
+	router := common.HexToAddress(decoded.To)
+	for i := 0; i+1 < len(path); i++ {
+		pair, err := pairAddress(ctx, router, path[i], path[i+1])
+		if err != nil {
+			return fmt.Sprintf("unknown (%v)", err), true
+		}
+		reserves, err := fetchReserves(ctx, pair)
+		if err != nil {
+			return fmt.Sprintf("unknown (%v)", err), true
+		}
+
+		reserveIn, reserveOut := reserves.reserve0, reserves.reserve1
+		if token0, _ := sortTokens(path[i], path[i+1]); token0 != path[i] {
+			reserveIn, reserveOut = reserveOut, reserveIn
+		}
+		if reserveIn.Sign() == 0 || reserveOut.Sign() == 0 {
+			return "unknown (empty pool)", true
+		}
+
+		// The execution price is worse than the mid price by reserveIn/(reserveIn+amountIn)
+		denominator := new(big.Int).Add(reserveIn, amount)
+		ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(reserveIn), new(big.Float).SetInt(denominator)).Float64()
+		remaining *= ratio
+
+		// amountOut = amountIn*997*reserveOut / (reserveIn*1000 + amountIn*997)
+		amountWithFee := new(big.Int).Mul(amount, big.NewInt(997))
+		numerator := new(big.Int).Mul(amountWithFee, reserveOut)
+		denominator = new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(1000)), amountWithFee)
+		amount = numerator.Div(numerator, denominator)
+	}
+
+	estimate := fmt.Sprintf("~%.2f%% (estimate from current reserves)", (1-remaining)*100)
+	if decoded.Method == "swapTokensForExactTokens" || decoded.Method == "swapTokensForExactETH" || decoded.Method == "swapETHForExactTokens" {
+		estimate = "at most " + estimate
+	}
+	return estimate, true
+}
@@ -0,0 +1,75 @@
+package mempool
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testFactory is the factory every router in these tests reports, as the same deployment would on
+// several chains
+var testFactory = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+
+// newPairServer answers factory() with testFactory and getPair with the given pair
+func newPairServer(t *testing.T, pair common.Address) *rpc.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		result := pair
+		if strings.Contains(string(body), factorySelector) {
+			result = testFactory
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x` + common.Bytes2Hex(common.LeftPadBytes(result.Bytes(), 32)) + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestPairAddressKeepsChainsApart(t *testing.T) {
+	t.Cleanup(func() {
+		factories = make(map[chainAddress]common.Address)
+		pairs = make(map[pairKey]common.Address)
+	})
+
+	router := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	mainnetPair := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	otherPair := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	// Mainnet's pair is cached; the other chain's factory and pair come from its own node
+	mainnet := cache.WithChain(context.Background(), &cache.Chain{ID: 1})
+	if _, err := pairAddress(cache.WithChain(context.Background(), &cache.Chain{ID: 1, Client: newPairServer(t, mainnetPair)}), router, weth, usdc); err != nil {
+		t.Fatal(err)
+	}
+	other := cache.WithChain(context.Background(), &cache.Chain{ID: 10, Client: newPairServer(t, otherPair)})
+
+	if pair, err := pairAddress(other, router, usdc, weth); err != nil || pair != otherPair {
+		t.Errorf("pair on chain 10 = %s, %v, want %s", pair.Hex(), err, otherPair.Hex())
+	}
+	if pair, err := pairAddress(mainnet, router, weth, usdc); err != nil || pair != mainnetPair {
+		t.Errorf("pair on chain 1 = %s, %v, want the cached %s", pair.Hex(), err, mainnetPair.Hex())
+	}
+}
+
+func TestPriceImpactOfV3Swap(t *testing.T) {
+	impact, ok := describePriceImpact(context.Background(), &decoder.DecodedTransaction{Method: "exactInputSingle"})
+	if !ok || impact != "unknown (unsupported pool type: Uniswap V3)" {
+		t.Errorf("describePriceImpact = %q, %v, want the unsupported pool type", impact, ok)
+	}
+}
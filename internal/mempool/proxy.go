@@ -0,0 +1,32 @@
+package mempool
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyFunc picks the proxy for the WebSocket dialer and the RPC transport. It follows the
+// HTTP(S)_PROXY environment variables unless SOCKS5_PROXY is set.
+var proxyFunc = http.ProxyFromEnvironment
+
+// parseSOCKS5Proxy parses SOCKS5_PROXY, given either as host:port or as a socks5:// URL with
+// optional user:password credentials
+func parseSOCKS5Proxy(value string) (*url.URL, error) {
+	if !strings.Contains(value, "://") {
+		value = "socks5://" + value
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5_PROXY: %w", err)
+	}
+	// socks5h resolves names on the proxy, which both clients already do for socks5
+	if proxyURL.Scheme == "socks5h" {
+		proxyURL.Scheme = "socks5"
+	}
+	if proxyURL.Scheme != "socks5" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid SOCKS5_PROXY %q, expected host:port or socks5://[user:password@]host:port", proxyURL.Redacted())
+	}
+	return proxyURL, nil
+}
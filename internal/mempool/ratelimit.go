@@ -0,0 +1,73 @@
+package mempool
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// rpcLimiter caps the rate of HTTPS transaction lookups across all chains (RPC_RATE_LIMIT requests
+// per second, bursting up to RPC_RATE_BURST); nil means unlimited. A batch counts as one request.
+var rpcLimiter *rateLimiter
+
+// rateLimiter is a token bucket: tokens refill continuously at rate per second up to burst, and
+// every request takes one
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a full bucket. A burst below 1 defaults to the rate, rounded up.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = int(math.Ceil(rate))
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// refill adds the tokens earned since the last call; the caller holds mu
+func (l *rateLimiter) refill(now time.Time) {
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// wait takes a token, sleeping until one is available or the context is cancelled
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		l.refill(time.Now())
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// available returns the tokens currently in the bucket
+func (l *rateLimiter) available() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	return l.tokens
+}
+
+// waitForRPC takes a token from rpcLimiter when RPC_RATE_LIMIT is set
+func waitForRPC(ctx context.Context) error {
+	if rpcLimiter == nil {
+		return nil
+	}
+	return rpcLimiter.wait(ctx)
+}
@@ -0,0 +1,92 @@
+package mempool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Maximum length of a single replayed message; full transaction objects with large calldata can be big
+const maxReplayLine = 16 * 1024 * 1024
+
+// Replay source used instead of the WebSocket; an empty path means monitoring live
+var (
+	replayPath  string
+	replaySpeed float64 // 1 replays at the captured pace, 2 twice as fast; 0 as fast as possible
+)
+
+// capturedMessage is a raw message along with the time it was received, as written by the capture
+type capturedMessage struct {
+	Received time.Time       `json:"received"`
+	Message  json.RawMessage `json:"message"`
+}
+
+// SetReplay makes MonitorMempool read messages from a file instead of connecting to the WebSocket.
+// Each line holds a JSON-RPC message, either raw or wrapped with the time it was received. Wrapped
+// messages are paced by their timestamps scaled by speed; with a speed of 0, or for raw lines, no
+// delay is added. It must be called before MonitorMempool.
+func SetReplay(path string, speed float64) {
+	replayPath = path
+	replaySpeed = speed
+}
+
+// replayMessages feeds the messages in the replay file to msgChan as if they came from this chain,
+// until the file ends or the context is cancelled
+func (m *Monitor) replayMessages(ctx context.Context, msgChan chan<- wsMessage) error {
+	file, err := os.Open(replayPath)
+	if err != nil {
+		return fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer file.Close()
+
+	m.markConnected()
+	defer m.markDisconnected()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxReplayLine)
+
+	var previous time.Time // Capture time of the previous wrapped message
+	count := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		message := string(line)
+		var captured capturedMessage
+		if err := json.Unmarshal(line, &captured); err == nil && len(captured.Message) > 0 {
+			message = string(captured.Message)
+
+			// Wait out the gap between captured messages, scaled by the replay speed
+			if replaySpeed > 0 && !previous.IsZero() && captured.Received.After(previous) {
+				gap := time.Duration(float64(captured.Received.Sub(previous)) / replaySpeed)
+				select {
+				case <-time.After(gap):
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			previous = captured.Received
+		}
+
+		m.markMessageReceived()
+		select {
+		case msgChan <- wsMessage{data: message, received: time.Now(), monitor: m}:
+			count++
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read replay file after %d messages: %w", count, err)
+	}
+
+	logging.Infof("Replay of %s finished after %d messages", replayPath, count)
+	return nil
+}
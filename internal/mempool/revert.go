@@ -0,0 +1,74 @@
+package mempool
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// simulateReverts runs each matched transaction with eth_call against the pending block to flag the
+// ones that would revert (SIMULATE_REVERTS=true). It costs an extra RPC call per matched transaction.
+var simulateReverts bool
+
+// simulateRevert replays a pending transaction with eth_call and reports whether it would revert,
+// along with the decoded reason. Failures that aren't reverts, such as network errors, report false.
+func simulateRevert(ctx context.Context, result decoder.TransactionResult) (string, bool) {
+	if result.Result.To == "" {
+		return "", false // Contract creations aren't simulated
+	}
+
+	call := map[string]interface{}{
+		"from": result.Result.From,
+		"to":   result.Result.To,
+		"data": result.Result.Input,
+	}
+	if result.Result.Value != "" {
+		call["value"] = result.Result.Value
+	}
+	if result.Result.Gas != "" {
+		call["gas"] = result.Result.Gas
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	var output hexutil.Bytes
+	err := cache.ChainFrom(ctx).Client.CallContext(callCtx, &output, "eth_call", call, "pending")
+	if err == nil {
+		return "", false
+	}
+
+	// Nodes return the revert data alongside an "execution reverted" error
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || !strings.Contains(strings.ToLower(rpcErr.Error()), "revert") {
+		logging.Debugf("Could not simulate transaction %s: %v", result.Result.Hash, err)
+		return "", false
+	}
+	return revertReason(err), true
+}
+
+// revertReason decodes the Error(string) or Panic(uint256) data of a revert, falling back to the
+// node's error message when there is no data or it uses a custom error
+func revertReason(err error) string {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if raw, decodeErr := hexutil.Decode(data); decodeErr == nil {
+				if reason, unpackErr := abi.UnpackRevert(raw); unpackErr == nil {
+					return reason
+				}
+				if len(raw) >= 4 {
+					return err.Error() + " (custom error " + hexutil.Encode(raw[:4]) + ")"
+				}
+			}
+		}
+	}
+	return err.Error()
+}
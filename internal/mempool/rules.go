@@ -0,0 +1,219 @@
+package mempool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+
+	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Supported rule condition operators. Addresses support eq and neq; address lists eq or contains
+// (the list holds the address) and neq (it doesn't); numbers support eq, neq and the ordering
+// operators, which need a number as their value; other values are compared as strings with eq, neq
+// or contains.
+const (
+	opEq       = "eq"
+	opNeq      = "neq"
+	opContains = "contains"
+	opGt       = "gt"
+	opGte      = "gte"
+	opLt       = "lt"
+	opLte      = "lte"
+)
+
+// Condition is a single check against one decoded parameter, selected by name or position
+type Condition struct {
+	Param string `json:"param,omitempty"` // Parameter name, e.g. "spender"
+	Index *int   `json:"index,omitempty"` // Parameter position, used when Param is empty
+	Op    string `json:"op"`              // One of eq, neq, contains, gt, gte, lt, lte
+	Value string `json:"value"`           // Address, decimal or 0x-prefixed hex number, or plain string to compare against
+}
+
+// Rule matches a decoded transaction when its method matches (if set) and all conditions hold
+type Rule struct {
+	Name       string      `json:"name"`
+	Method     string      `json:"method,omitempty"`
+	Conditions []Condition `json:"conditions"`
+}
+
+// Loaded post-decode rules; when empty every decoded transaction is shown
+var rules []Rule
+
+// LoadRules loads post-decode calldata rules from a JSON file. A missing file means no rules.
+func LoadRules(filename string) ([]Rule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var loaded []Rule
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	for _, rule := range loaded {
+		for _, cond := range rule.Conditions {
+			if cond.Param == "" && cond.Index == nil {
+				return nil, fmt.Errorf("rule %q: condition needs a param name or index", rule.Name)
+			}
+			switch cond.Op {
+			case opEq, opNeq, opContains:
+			case opGt, opGte, opLt, opLte:
+				if _, ok := parseRuleNumber(cond.Value); !ok {
+					return nil, fmt.Errorf("rule %q: operator %q needs a decimal or 0x-prefixed hex number, got %q", rule.Name, cond.Op, cond.Value)
+				}
+			default:
+				return nil, fmt.Errorf("rule %q: unsupported operator %q", rule.Name, cond.Op)
+			}
+		}
+	}
+
+	logging.Infof("Loaded %d calldata rules from %s", len(loaded), filename)
+	return loaded, nil
+}
+
+// matchRules reports whether a decoded transaction satisfies any of the loaded rules.
+// With no rules configured everything matches; undecodable transactions never match a rule.
+func matchRules(decoded *decoder.DecodedTransaction) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	if decoded == nil {
+		return false
+	}
+
+	for _, rule := range rules {
+		if rule.matches(decoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the method and all conditions of the rule hold for the transaction
+func (r Rule) matches(decoded *decoder.DecodedTransaction) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, decoded.Method) {
+		return false
+	}
+
+	for _, cond := range r.Conditions {
+		param, ok := cond.lookup(decoded.Params)
+		if !ok || !cond.evaluate(param.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup finds the parameter a condition refers to
+func (c Condition) lookup(params []decoder.DecodedParam) (decoder.DecodedParam, bool) {
+	if c.Param != "" {
+		for _, param := range params {
+			if param.Name == c.Param {
+				return param, true
+			}
+		}
+		return decoder.DecodedParam{}, false
+	}
+
+	if *c.Index < 0 || *c.Index >= len(params) {
+		return decoder.DecodedParam{}, false
+	}
+	return params[*c.Index], true
+}
+
+// evaluate applies the condition's operator to a decoded parameter value
+func (c Condition) evaluate(value interface{}) bool {
+	switch v := value.(type) {
+	case common.Address:
+		return c.compareEquality(v == common.HexToAddress(c.Value))
+	case []common.Address:
+		target := common.HexToAddress(c.Value)
+		listed := false
+		for _, addr := range v {
+			if addr == target {
+				listed = true
+				break
+			}
+		}
+		if c.Op == opContains {
+			return listed
+		}
+		return c.compareEquality(listed)
+	case *big.Int:
+		return c.compareNumber(v)
+	}
+
+	// ABI integers of up to 64 bits are decoded to native Go integers
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return c.compareNumber(big.NewInt(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return c.compareNumber(new(big.Int).SetUint64(rv.Uint()))
+	}
+
+	str := fmt.Sprint(value)
+	if c.Op == opContains {
+		return strings.Contains(strings.ToLower(str), strings.ToLower(c.Value))
+	}
+	return c.compareEquality(strings.EqualFold(str, c.Value))
+}
+
+// compareNumber compares a numeric parameter with the condition's value
+func (c Condition) compareNumber(v *big.Int) bool {
+	target, ok := parseRuleNumber(c.Value)
+	if !ok {
+		return false
+	}
+	return c.compareOrdering(v.Cmp(target))
+}
+
+// parseRuleNumber parses a rule value as a decimal or 0x-prefixed hex number
+func parseRuleNumber(value string) (*big.Int, bool) {
+	value = strings.TrimSpace(value)
+	if hex, ok := strings.CutPrefix(strings.ToLower(value), "0x"); ok {
+		return new(big.Int).SetString(hex, 16)
+	}
+	return new(big.Int).SetString(value, 10)
+}
+
+// compareEquality maps an equality result onto the eq/neq operators
+func (c Condition) compareEquality(equal bool) bool {
+	switch c.Op {
+	case opEq:
+		return equal
+	case opNeq:
+		return !equal
+	}
+	return false
+}
+
+// compareOrdering maps a Cmp result onto the numeric operators
+func (c Condition) compareOrdering(cmp int) bool {
+	switch c.Op {
+	case opEq:
+		return cmp == 0
+	case opNeq:
+		return cmp != 0
+	case opGt:
+		return cmp > 0
+	case opGte:
+		return cmp >= 0
+	case opLt:
+		return cmp < 0
+	case opLte:
+		return cmp <= 0
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package mempool
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	weth    = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc    = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	spender = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+)
+
+func TestMatchRules(t *testing.T) {
+	swap := &decoder.DecodedTransaction{Method: "swapExactTokensForTokens", Params: []decoder.DecodedParam{
+		{Name: "amountIn", Value: big.NewInt(5000)},
+		{Name: "path", Value: []common.Address{usdc, weth}},
+		{Name: "fee", Value: uint32(500)},
+	}}
+	approve := &decoder.DecodedTransaction{Method: "approve", Params: []decoder.DecodedParam{
+		{Name: "spender", Value: spender},
+		{Name: "amount", Value: big.NewInt(255)},
+	}}
+	index := func(i int) *int { return &i }
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		decoded *decoder.DecodedTransaction
+		want    bool
+	}{
+		{"token in path", Rule{Method: "swapExactTokensForTokens", Conditions: []Condition{{Param: "path", Op: opContains, Value: weth.Hex()}}}, swap, true},
+		{"token not in path", Rule{Conditions: []Condition{{Param: "path", Op: opContains, Value: spender.Hex()}}}, swap, false},
+		{"path eq", Rule{Conditions: []Condition{{Param: "path", Op: opEq, Value: usdc.Hex()}}}, swap, true},
+		{"path neq", Rule{Conditions: []Condition{{Param: "path", Op: opNeq, Value: spender.Hex()}}}, swap, true},
+		{"path neq listed", Rule{Conditions: []Condition{{Param: "path", Op: opNeq, Value: weth.Hex()}}}, swap, false},
+		{"approve to spender", Rule{Method: "approve", Conditions: []Condition{{Param: "spender", Op: opEq, Value: spender.Hex()}}}, approve, true},
+		{"approve to another spender", Rule{Method: "approve", Conditions: []Condition{{Param: "spender", Op: opEq, Value: weth.Hex()}}}, approve, false},
+		{"spender neq", Rule{Conditions: []Condition{{Param: "spender", Op: opNeq, Value: weth.Hex()}}}, approve, true},
+		{"other method", Rule{Method: "approve"}, swap, false},
+		{"amount gt", Rule{Conditions: []Condition{{Param: "amountIn", Op: opGt, Value: "4999"}}}, swap, true},
+		{"amount lte", Rule{Conditions: []Condition{{Param: "amountIn", Op: opLte, Value: "4999"}}}, swap, false},
+		{"amount hex", Rule{Conditions: []Condition{{Param: "amount", Op: opEq, Value: "0xff"}}}, approve, true},
+		{"native int gte", Rule{Conditions: []Condition{{Param: "fee", Op: opGte, Value: "500"}}}, swap, true},
+		{"native int lt", Rule{Conditions: []Condition{{Param: "fee", Op: opLt, Value: "100"}}}, swap, false},
+		{"by index", Rule{Conditions: []Condition{{Index: index(0), Op: opEq, Value: spender.Hex()}}}, approve, true},
+		{"missing param", Rule{Conditions: []Condition{{Param: "deadline", Op: opGt, Value: "0"}}}, swap, false},
+		{"undecoded", Rule{}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := rules
+			rules = []Rule{tt.rule}
+			t.Cleanup(func() { rules = saved })
+			if got := matchRules(tt.decoded); got != tt.want {
+				t.Errorf("matchRules = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{"valid", `[{"name":"weth swaps","method":"swapExactTokensForTokens","conditions":[{"param":"path","op":"contains","value":"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"},{"param":"amountIn","op":"gte","value":"0x10"}]}]`, false},
+		{"no param", `[{"name":"r","conditions":[{"op":"eq","value":"1"}]}]`, true},
+		{"unknown operator", `[{"name":"r","conditions":[{"param":"a","op":"like","value":"1"}]}]`, true},
+		{"ordering without a number", `[{"name":"r","conditions":[{"param":"a","op":"gt","value":"lots"}]}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tt.rules), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadRules(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadRules error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+
+	if loaded, err := LoadRules(filepath.Join(t.TempDir(), "missing.json")); err != nil || loaded != nil {
+		t.Errorf("LoadRules of a missing file = %v, %v, want no rules", loaded, err)
+	}
+}
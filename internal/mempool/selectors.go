@@ -0,0 +1,153 @@
+package mempool
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Built-in selectors, keyed by method selector hex (without 0x) and labelled with the method name
+var relevantSelectorsUniswap = map[string]string{
+	"38ed1739": "swapExactTokensForTokens",
+	"8803dbee": "swapTokensForExactTokens",
+	"7ff36ab5": "swapExactETHForTokens",
+	"4a25d94a": "swapTokensForExactETH",
+	"18cbafe5": "swapExactTokensForETH",
+	"fb3bdb41": "swapETHForExactTokens",
+	"e8e33700": "addLiquidity",
+	"f305d719": "addLiquidityETH",
+	"baa2abde": "removeLiquidity",
+	"02751cec": "removeLiquidityETH",
+}
+
+var relevantSelectorsWETH = map[string]string{
+	"d0e30db0": "deposit",
+	"2e1a7d4d": "withdraw",
+	"095ea7b3": "approve",
+	"a9059cbb": "transfer",
+	"23b872dd": "transferFrom",
+}
+
+var relevantSelectorsPermit2 = map[string]string{
+	"2b67b570": "permit (PermitSingle)",
+	"2a2d80d1": "permit (PermitBatch)",
+}
+
+var relevantSelectorsSafe = map[string]string{
+	"6a761202": "execTransaction (Safe)",
+}
+
+// ERC-721 and ERC-1155 transfers and operator approvals, as used by NFT marketplaces
+var relevantSelectorsNFT = map[string]string{
+	"42842e0e": "safeTransferFrom (ERC-721)",
+	"b88d4fde": "safeTransferFrom (ERC-721, with data)",
+	"a22cb465": "setApprovalForAll",
+	"f242432a": "safeTransferFrom (ERC-1155)",
+	"2eb2c2d6": "safeBatchTransferFrom (ERC-1155)",
+}
+
+// Router multicalls, whose batched calls are decoded against the router's ABI
+var relevantSelectorsMulticall = map[string]string{
+	"ac9650d8": "multicall",
+	"5ae401dc": "multicall (with deadline)",
+	"1f0464d1": "multicall (with previous blockhash)",
+}
+
+// Selectors whose transactions are considered relevant, loaded from config or the built-in maps
+var relevantSelectors = make(map[string]bool)
+
+// Selector labels loaded from selectors.json; these take priority over the built-in labels
+var selectorNames = make(map[string]string)
+
+// LoadSelectors loads a JSON object mapping method selectors (hex without 0x) to human-readable
+// labels. Every selector in the file is treated as relevant. A missing file is not an error; the
+// caller falls back to the built-in selectors.
+func LoadSelectors(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read selectors file: %w", err)
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return fmt.Errorf("failed to parse selectors file: %w", err)
+	}
+
+	for selector, label := range labels {
+		selector = normalizeSelector(selector)
+		if _, err := hex.DecodeString(selector); err != nil || len(selector) != 8 {
+			return fmt.Errorf("invalid selector %q in selectors file, expected 4 bytes of hex", selector)
+		}
+		selectorNames[selector] = label
+		relevantSelectors[selector] = true
+	}
+
+	logging.Infof("Loaded %d selectors from %s", len(labels), filename)
+	return nil
+}
+
+// useBuiltinSelectors marks the built-in Uniswap, WETH, Permit2, Safe, NFT and multicall selectors as relevant
+func useBuiltinSelectors() {
+	for _, builtin := range []map[string]string{relevantSelectorsUniswap, relevantSelectorsWETH, relevantSelectorsPermit2, relevantSelectorsSafe, relevantSelectorsNFT, relevantSelectorsMulticall} {
+		for selector := range builtin {
+			relevantSelectors[selector] = true
+		}
+	}
+	logging.Infof("No selectors configured, using %d built-in selectors", len(relevantSelectors))
+}
+
+// selectorName looks up a human-readable name for a selector, preferring user-provided names
+// over the built-in ones
+func selectorName(selector string) (string, bool) {
+	selector = normalizeSelector(selector)
+
+	if name, ok := selectorNames[selector]; ok {
+		return name, true
+	}
+	if name, ok := relevantSelectorsUniswap[selector]; ok {
+		return name, true
+	}
+	if name, ok := relevantSelectorsWETH[selector]; ok {
+		return name, true
+	}
+	if name, ok := relevantSelectorsPermit2[selector]; ok {
+		return name, true
+	}
+	if name, ok := relevantSelectorsSafe[selector]; ok {
+		return name, true
+	}
+	if name, ok := relevantSelectorsNFT[selector]; ok {
+		return name, true
+	}
+	if name, ok := relevantSelectorsMulticall[selector]; ok {
+		return name, true
+	}
+	return "", false
+}
+
+// describeSelector renders the method selector of the input data with its name when known
+func describeSelector(inputData string) string {
+	inputData = strings.TrimPrefix(inputData, "0x")
+	if len(inputData) < 8 {
+		return "unknown"
+	}
+
+	selector := normalizeSelector(inputData[:8])
+	if name, ok := selectorName(selector); ok {
+		return fmt.Sprintf("%s (0x%s)", name, selector)
+	}
+	return "0x" + selector
+}
+
+// normalizeSelector lowercases a selector and strips any 0x prefix
+func normalizeSelector(selector string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(selector)), "0x")
+}
@@ -0,0 +1,47 @@
+package mempool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Sender lists from WATCH_FROM and IGNORE_FROM; nil when unset
+var (
+	watchFrom  map[common.Address]bool // Only these senders are shown, whichever contract they call
+	ignoreFrom map[common.Address]bool // These senders are always dropped
+)
+
+// parseAddressList parses a comma-separated list of addresses, as used by WATCH_FROM and IGNORE_FROM
+func parseAddressList(name, list string) (map[common.Address]bool, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	addresses := make(map[common.Address]bool)
+	for _, addr := range strings.Split(list, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid address %q in %s", addr, name)
+		}
+		addresses[common.HexToAddress(addr)] = true
+	}
+	return addresses, nil
+}
+
+// matchSender reports whether a sender passes the WATCH_FROM and IGNORE_FROM lists, and whether it
+// is a watched sender, whose transactions are shown even when they don't call a watched contract
+func matchSender(from string) (passed, watched bool) {
+	sender := common.HexToAddress(from)
+	if ignoreFrom[sender] {
+		return false, false
+	}
+	if watchFrom == nil {
+		return true, false
+	}
+	return watchFrom[sender], watchFrom[sender]
+}
@@ -0,0 +1,85 @@
+package mempool
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/gorilla/websocket"
+)
+
+// serverFilter subscribes with Alchemy's alchemy_pendingTransactions, filtered by the provider to
+// transactions sent to the watched contracts and carrying full transaction objects, so neither
+// unrelated hashes nor lookups go over the wire (ALCHEMY_FILTER=true). Providers that reject it
+// get the usual newPendingTransactions subscription. Features that look at every transaction, such
+// as GENERIC_DECODE and the gas history, then only see the watched contracts' transactions.
+var serverFilter bool
+
+// Most addresses alchemy_pendingTransactions accepts in its toAddress filter
+const maxFilterAddresses = 1000
+
+// filteredSubscription returns the alchemy_pendingTransactions params filtering to the watched
+// contracts, or false when the chain doesn't use server-side filtering
+func (m *Monitor) filteredSubscription() (json.RawMessage, bool) {
+	if !serverFilter || m.subscription != nil || m.filterUnsupported.Load() {
+		return nil, false
+	}
+
+	m.contractsMu.RLock()
+	addresses := make([]string, 0, len(m.contractsByAddress))
+	for addr := range m.contractsByAddress {
+		addresses = append(addresses, addr.Hex())
+	}
+	m.contractsMu.RUnlock()
+
+	switch {
+	case len(addresses) == 0:
+		return nil, false // An empty filter would match every transaction
+	case len(addresses) > maxFilterAddresses:
+		m.logf(slog.LevelWarn, "Watching %d contracts, more than the %d a server-side filter allows; subscribing to all pending transactions", len(addresses), maxFilterAddresses)
+		return nil, false
+	}
+	sort.Strings(addresses)
+
+	params, err := json.Marshal([]interface{}{
+		"alchemy_pendingTransactions",
+		map[string]interface{}{"toAddress": addresses, "hashesOnly": false},
+	})
+	if err != nil {
+		return nil, false
+	}
+	return params, true
+}
+
+// subscribeFiltered sends the filtered subscription and waits for the provider's response. It
+// returns false when the provider rejects the subscription, which is remembered so reconnections
+// go straight to newPendingTransactions.
+func (m *Monitor) subscribeFiltered(conn *websocket.Conn, params json.RawMessage) (bool, error) {
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(subscribeRequest(params))); err != nil {
+		return false, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	rpcErr, err := awaitSubscribeResponse(conn)
+	if err != nil {
+		return false, err
+	}
+	if rpcErr != nil {
+		m.filterUnsupported.Store(true)
+		m.logf(slog.LevelWarn, "Provider doesn't support alchemy_pendingTransactions (%s); subscribing to all pending transactions", rpcErr.Message)
+		return false, nil
+	}
+	m.logf(slog.LevelInfo, "Provider is filtering pending transactions to %d watched contracts", m.watchedContractCount())
+	return true, nil
+}
+
+// refreshServerFilter replaces the connection after the watched contracts change, so the provider
+// filters to the new set
+func (m *Monitor) refreshServerFilter() {
+	if !serverFilter || m.subscription != nil || m.filterUnsupported.Load() {
+		return
+	}
+	if conn := m.conn.Load(); conn != nil {
+		m.resubscribing.Store(true)
+		conn.Close()
+	}
+}
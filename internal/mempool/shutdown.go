@@ -0,0 +1,63 @@
+package mempool
+
+import (
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Default time allowed for the reader and workers to stop once monitoring is cancelled
+const defaultShutdownGrace = 2 * time.Second
+
+// shutdownGrace bounds how long shutdown waits for in-flight work (SHUTDOWN_GRACE)
+var shutdownGrace = defaultShutdownGrace
+
+// How often shutdown checks whether the UI has taken the updates still queued for it
+const drainPollInterval = 10 * time.Millisecond
+
+// awaitShutdown waits up to the grace period for the reader and workers to stop and for the UI to
+// take the updates still queued for it, then reports how many were never displayed
+func awaitShutdown(readerDone <-chan struct{}, workers *sync.WaitGroup, txChan chan string, txDetailsChan chan string) {
+	expired := make(chan struct{})
+	timer := time.AfterFunc(shutdownGrace, func() { close(expired) })
+	defer timer.Stop()
+
+	stopped := make(chan struct{})
+	go func() {
+		<-readerDone
+		workers.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-expired:
+		logging.Warnf("In-flight work did not stop within %s, shutting down anyway", shutdownGrace)
+	}
+
+	if left := awaitDrained(expired, txChan, txDetailsChan); left > 0 {
+		logging.Infof("%d queued updates were never displayed", left)
+	}
+}
+
+// awaitDrained waits until the channels' consumers have emptied them or expired is closed, and
+// returns how many values are still queued
+func awaitDrained(expired <-chan struct{}, chans ...chan string) int {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		queued := 0
+		for _, ch := range chans {
+			queued += len(ch)
+		}
+		if queued == 0 {
+			return 0
+		}
+		select {
+		case <-ticker.C:
+		case <-expired:
+			return queued
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package mempool
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+)
+
+// Shutdown must finish within the grace period even when workers are stuck and still flooding the
+// UI channels
+func TestAwaitShutdownIsBoundedUnderLoad(t *testing.T) {
+	shutdownGrace = 50 * time.Millisecond
+	t.Cleanup(func() { shutdownGrace = defaultShutdownGrace })
+
+	txChan := make(chan string, 100)
+	txDetailsChan := make(chan string, 100)
+
+	// A worker that never finishes, and another that keeps sending updates
+	var workers sync.WaitGroup
+	workers.Add(1)
+	t.Cleanup(workers.Done)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				sendUpdate(txChan, "tx", &droppedTx)
+				sendUpdate(txDetailsChan, "details", &droppedTxDetails)
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		awaitShutdown(make(chan struct{}), &workers, txChan, txDetailsChan) // The reader never stops either
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown didn't finish under load")
+	}
+	if elapsed := time.Since(start); elapsed < shutdownGrace {
+		t.Errorf("shutdown finished after %s, before the grace period", elapsed)
+	}
+}
+
+// Updates queued when the workers stop are left for the UI to draw rather than discarded
+func TestAwaitShutdownDeliversQueuedUpdates(t *testing.T) {
+	txChan := make(chan string, 3)
+	txDetailsChan := make(chan string, 3)
+	txChan <- "tx"
+	txChan <- "tx"
+	txDetailsChan <- "details"
+
+	readerDone := make(chan struct{})
+	close(readerDone)
+	var workers sync.WaitGroup
+
+	// The UI only gets round to the updates after shutdown has started
+	var delivered atomic.Int32
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for {
+			select {
+			case <-txChan:
+			case <-txDetailsChan:
+			case <-stop:
+				return
+			}
+			delivered.Add(1)
+		}
+	}()
+
+	start := time.Now()
+	awaitShutdown(readerDone, &workers, txChan, txDetailsChan)
+	if elapsed := time.Since(start); elapsed >= shutdownGrace {
+		t.Errorf("shutdown waited %s although everything was delivered", elapsed)
+	}
+	if got := delivered.Load(); got != 3 {
+		t.Errorf("%d updates delivered, want 3", got)
+	}
+}
+
+// Workers that outlive the grace period may still publish while the sinks are being closed
+func TestPublishWhileClosingSinks(t *testing.T) {
+	SetJSONOutput(io.Discard)
+	t.Cleanup(func() { SetJSONOutput(nil) })
+	openSinks()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				publish(decoder.DecodedTransaction{Hash: "0x1"})
+			}
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	closeSinks()
+	time.Sleep(10 * time.Millisecond) // Keep publishing to the closed sinks
+	close(stop)
+	<-done
+}
@@ -0,0 +1,194 @@
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync/atomic"
+
+	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// verifySenders recovers the sender of each matched transaction from its signature and flags the ones
+// whose reported from address differs (VERIFY_SENDER=true), for RPC endpoints that aren't fully trusted
+var verifySenders bool
+
+// errUnverifiable marks transactions whose signature can't be checked, such as unsupported types
+var errUnverifiable = errors.New("sender can't be verified")
+
+// detectChainID asks the endpoint for its chain id with eth_chainId, which transactions are signed
+// for. A configured chain id that disagrees with the endpoint is only warned about.
+func (m *Monitor) detectChainID(ctx context.Context) error {
+	callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	var id hexutil.Big
+	if err := m.chain.Client.CallContext(callCtx, &id, "eth_chainId"); err != nil {
+		return m.errorf("failed to fetch chain id: %w", err)
+	}
+	chainID := (*big.Int)(&id)
+	if m.chainID != 0 && chainID.Cmp(new(big.Int).SetUint64(m.chainID)) != 0 {
+		m.logf(slog.LevelWarn, "Configured chain id %d differs from the endpoint's chain id %s; verifying senders with the endpoint's", m.chainID, chainID)
+	}
+	m.signerID = chainID
+	m.logf(slog.LevelInfo, "Verifying transaction senders for chain id %s", chainID)
+	return nil
+}
+
+// recoverSender recovers the address that signed a transaction with go-ethereum's signer for the
+// chain. It wraps errUnverifiable when the transaction can't be parsed or is of an unsupported type,
+// and returns other errors for signatures that are invalid or made for another chain.
+func (m *Monitor) recoverSender(result decoder.TransactionResult) (common.Address, error) {
+	tx, err := signedTransaction(result)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(m.signerID), tx)
+	if errors.Is(err, types.ErrTxTypeNotSupported) {
+		return common.Address{}, fmt.Errorf("%w: %v", errUnverifiable, err)
+	}
+	return sender, err
+}
+
+// signedTransaction rebuilds a signed transaction from its RPC fields, so its signature can be checked
+func signedTransaction(result decoder.TransactionResult) (*types.Transaction, error) {
+	tx := result.Result
+	var q quantityParser
+	nonce := q.uint64("nonce", tx.Nonce)
+	gas := q.uint64("gas", tx.Gas)
+	value := q.parse("value", tx.Value)
+	v := q.parse("v", tx.V)
+	r := q.parse("r", tx.R)
+	s := q.parse("s", tx.S)
+
+	input, err := hexutil.Decode(tx.Input)
+	if tx.Input == "" || tx.Input == "0x" {
+		input, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid input: %v", errUnverifiable, err)
+	}
+	var to *common.Address // Nil for contract creations
+	if tx.To != "" {
+		addr := common.HexToAddress(tx.To)
+		to = &addr
+	}
+	var accessList types.AccessList
+	if len(tx.AccessList) > 0 {
+		if err := json.Unmarshal(tx.AccessList, &accessList); err != nil {
+			return nil, fmt.Errorf("%w: invalid access list: %v", errUnverifiable, err)
+		}
+	}
+
+	var inner types.TxData
+	switch tx.Type {
+	case "", "0x0":
+		// Only 27 and 28 (signed before EIP-155) and chainId*2 + 35 or 36 are valid; some providers
+		// report other values, such as a bare recovery id, which can't be checked
+		if v.Cmp(big.NewInt(27)) != 0 && v.Cmp(big.NewInt(28)) != 0 && v.Cmp(big.NewInt(35)) < 0 {
+			return nil, fmt.Errorf("%w: legacy signature with v = %s", errUnverifiable, v)
+		}
+		inner = &types.LegacyTx{Nonce: nonce, GasPrice: q.parse("gasPrice", tx.GasPrice), Gas: gas, To: to, Value: value, Data: input, V: v, R: r, S: s}
+	case "0x1":
+		inner = &types.AccessListTx{ChainID: q.parse("chainId", tx.ChainID), Nonce: nonce, GasPrice: q.parse("gasPrice", tx.GasPrice),
+			Gas: gas, To: to, Value: value, Data: input, AccessList: accessList, V: v, R: r, S: s}
+	case "0x2":
+		inner = &types.DynamicFeeTx{ChainID: q.parse("chainId", tx.ChainID), Nonce: nonce, GasTipCap: q.parse("maxPriorityFeePerGas", tx.MaxPriorityFee),
+			GasFeeCap: q.parse("maxFeePerGas", tx.MaxFeePerGas), Gas: gas, To: to, Value: value, Data: input, AccessList: accessList, V: v, R: r, S: s}
+	case "0x3":
+		if to == nil {
+			return nil, fmt.Errorf("%w: blob transaction without a recipient", errUnverifiable)
+		}
+		blobHashes := make([]common.Hash, len(tx.BlobVersionedHashes))
+		for i, h := range tx.BlobVersionedHashes {
+			blobHashes[i] = common.HexToHash(h)
+		}
+		inner = &types.BlobTx{ChainID: q.uint256("chainId", tx.ChainID), Nonce: nonce, GasTipCap: q.uint256("maxPriorityFeePerGas", tx.MaxPriorityFee),
+			GasFeeCap: q.uint256("maxFeePerGas", tx.MaxFeePerGas), Gas: gas, To: *to, Value: q.uint256("value", tx.Value), Data: input,
+			AccessList: accessList, BlobFeeCap: q.uint256("maxFeePerBlobGas", tx.MaxFeePerBlobGas), BlobHashes: blobHashes,
+			V: q.uint256("v", tx.V), R: q.uint256("r", tx.R), S: q.uint256("s", tx.S)}
+	default:
+		return nil, fmt.Errorf("%w: unsupported transaction type %s", errUnverifiable, tx.Type)
+	}
+	if q.err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnverifiable, q.err)
+	}
+	return types.NewTx(inner), nil
+}
+
+// quantityParser parses hex quantities, keeping the first error so a run of fields can be checked once
+type quantityParser struct {
+	err error
+}
+
+// parse parses a hex quantity. Unlike hexutil.DecodeBig it accepts leading zeros, which some
+// providers send in signature values.
+func (q *quantityParser) parse(name, hex string) *big.Int {
+	v, ok := new(big.Int).SetString(strings.TrimPrefix(hex, "0x"), 16)
+	if !ok {
+		if q.err == nil {
+			q.err = fmt.Errorf("invalid %s %q", name, hex)
+		}
+		return new(big.Int)
+	}
+	return v
+}
+
+// uint64 parses a hex quantity that must fit in 64 bits, such as a nonce or gas limit
+func (q *quantityParser) uint64(name, hex string) uint64 {
+	v := q.parse(name, hex)
+	if !v.IsUint64() {
+		if q.err == nil {
+			q.err = fmt.Errorf("%s %q out of range", name, hex)
+		}
+		return 0
+	}
+	return v.Uint64()
+}
+
+// uint256 parses a hex quantity that must fit in 256 bits, as the fields of blob transactions do
+func (q *quantityParser) uint256(name, hex string) *uint256.Int {
+	v, overflow := uint256.FromBig(q.parse(name, hex))
+	if overflow && q.err == nil {
+		q.err = fmt.Errorf("%s %q out of range", name, hex)
+	}
+	return v
+}
+
+// checkSender compares the sender recovered from a transaction's signature with its reported from
+// address. It returns a warning to show with the transaction, or "" when the sender checks out or
+// can't be verified.
+func (m *Monitor) checkSender(result decoder.TransactionResult, decoded *decoder.DecodedTransaction) string {
+	if !verifySenders || m.signerID == nil {
+		return ""
+	}
+
+	sender, err := m.recoverSender(result)
+	switch {
+	case errors.Is(err, errUnverifiable):
+		logging.Debugf("Not verifying sender of %s: %v", result.Result.Hash, err)
+		return ""
+	case err != nil:
+		atomic.AddUint64(&metricSenderMismatches, 1)
+		m.logf(slog.LevelWarn, "Invalid signature on transaction %s: %v", result.Result.Hash, err)
+		return fmt.Sprintf("INVALID SIGNATURE: %v", err)
+	case !strings.EqualFold(sender.Hex(), result.Result.From):
+		atomic.AddUint64(&metricSenderMismatches, 1)
+		m.logf(slog.LevelWarn, "Transaction %s reports sender %s but was signed by %s", result.Result.Hash, result.Result.From, sender.Hex())
+		if decoded != nil {
+			decoded.RecoveredFrom = sender.Hex()
+		}
+		return fmt.Sprintf("SENDER MISMATCH: signed by %s", sender.Hex())
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package mempool
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// signedResult signs a transaction and returns it as an RPC node reports it
+func signedResult(t *testing.T, inner types.TxData, signer types.Signer) (decoder.TransactionResult, common.Address) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignTx(types.NewTx(inner), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := tx.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result decoder.TransactionResult
+	if err := json.Unmarshal([]byte(`{"result":`+string(encoded)+`}`), &result); err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	result.Result.From = sender.Hex()
+	return result, sender
+}
+
+func TestRecoverSender(t *testing.T) {
+	chainID := big.NewInt(1)
+	to := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	data := common.FromHex("0x095ea7b3")
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+
+	tests := []struct {
+		name   string
+		tx     types.TxData
+		signer types.Signer
+	}{
+		{"legacy before EIP-155", &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1), Data: data}, types.HomesteadSigner{}},
+		{"legacy", &types.LegacyTx{Nonce: 2, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1), Data: data}, types.NewEIP155Signer(chainID)},
+		{"contract creation", &types.LegacyTx{Nonce: 3, GasPrice: big.NewInt(1e9), Gas: 100000, Data: data}, types.NewEIP155Signer(chainID)},
+		{"access list", &types.AccessListTx{ChainID: chainID, Nonce: 4, GasPrice: big.NewInt(1e9), Gas: 30000, To: &to, Data: data, AccessList: accessList}, types.LatestSignerForChainID(chainID)},
+		{"dynamic fee", &types.DynamicFeeTx{ChainID: chainID, Nonce: 5, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(3e10), Gas: 30000, To: &to, Value: big.NewInt(7), Data: data, AccessList: accessList}, types.LatestSignerForChainID(chainID)},
+		{"blob", &types.BlobTx{ChainID: uint256.NewInt(1), Nonce: 6, GasTipCap: uint256.NewInt(1e9), GasFeeCap: uint256.NewInt(3e10), Gas: 30000, To: to, Value: uint256.NewInt(0), Data: data,
+			BlobFeeCap: uint256.NewInt(1e9), BlobHashes: []common.Hash{{0x01, 2}}}, types.LatestSignerForChainID(chainID)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, sender := signedResult(t, tt.tx, tt.signer)
+			m := &Monitor{signerID: chainID}
+			got, err := m.recoverSender(result)
+			if err != nil {
+				t.Fatalf("recoverSender: %v", err)
+			}
+			if got != sender {
+				t.Errorf("recovered %s, want %s", got.Hex(), sender.Hex())
+			}
+		})
+	}
+}
+
+func TestCheckSender(t *testing.T) {
+	verifySenders = true
+	t.Cleanup(func() { verifySenders = false })
+
+	chainID := big.NewInt(1)
+	to := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	dynamicFee := func() types.TxData {
+		return &types.DynamicFeeTx{ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(3e10), Gas: 21000, To: &to}
+	}
+	m := &Monitor{signerID: chainID}
+
+	result, _ := signedResult(t, dynamicFee(), types.LatestSignerForChainID(chainID))
+	if warning := m.checkSender(result, nil); warning != "" {
+		t.Errorf("genuine transaction flagged: %s", warning)
+	}
+
+	// A node reporting another sender than the one that signed
+	tampered, sender := signedResult(t, dynamicFee(), types.LatestSignerForChainID(chainID))
+	tampered.Result.From = "0x1111111111111111111111111111111111111111"
+	decoded := &decoder.DecodedTransaction{}
+	if warning := m.checkSender(tampered, decoded); !strings.HasPrefix(warning, "SENDER MISMATCH") {
+		t.Errorf("tampered sender gave warning %q, want a sender mismatch", warning)
+	}
+	if decoded.RecoveredFrom != sender.Hex() {
+		t.Errorf("recovered sender %s, want %s", decoded.RecoveredFrom, sender.Hex())
+	}
+
+	// Signed for another chain
+	other, _ := signedResult(t, &types.DynamicFeeTx{ChainID: big.NewInt(8453), Nonce: 1, GasFeeCap: big.NewInt(1), Gas: 21000, To: &to}, types.LatestSignerForChainID(big.NewInt(8453)))
+	if warning := m.checkSender(other, nil); !strings.HasPrefix(warning, "INVALID SIGNATURE") {
+		t.Errorf("transaction for another chain gave warning %q, want an invalid signature", warning)
+	}
+}
+
+// Legacy v values that are neither 27/28 nor EIP-155, such as a bare recovery id, can't be checked
+func TestRecoverSenderUnverifiableLegacyV(t *testing.T) {
+	to := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	result, _ := signedResult(t, &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to}, types.HomesteadSigner{})
+	m := &Monitor{signerID: big.NewInt(1)}
+	for _, v := range []string{"0x0", "0x1", "0x22"} {
+		result.Result.V = v
+		if _, err := m.recoverSender(result); !errors.Is(err, errUnverifiable) {
+			t.Errorf("v = %s: recoverSender returned %v, want errUnverifiable", v, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package mempool
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"eth-mempool-monitor/internal/api"
+	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+	"eth-mempool-monitor/internal/sink"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Sinks that matched transactions are published to, opened when monitoring starts. Workers that
+// outlive the shutdown grace period may still publish while the sinks are closed, hence the lock.
+var (
+	sinksMu sync.RWMutex
+	sinks   []*sink.Buffered[decoder.DecodedTransaction]
+)
+
+// Buffering and rotation of the sinks, read in Setup so invalid values are reported before monitoring starts
+var (
+	outputBuffer, parquetBuffer, jsonlBuffer, csvBuffer sink.BufferConfig
+	parquetRotation, jsonlRotation                      sink.RotationPolicy
+	jsonlFsync                                          bool
+)
+
+// Stream that decoded transactions are written to as JSON lines; nil unless JSON output is enabled
+var jsonOutput io.Writer
+
+// SetJSONOutput writes every matched transaction to w as newline-delimited JSON.
+// It must be called before MonitorMempool.
+func SetJSONOutput(w io.Writer) {
+	jsonOutput = w
+}
+
+// Stream that matched transactions are published to as "transaction" events; nil unless STREAM_PORT is set
+var transactionStream *api.EventStream
+
+// SetTransactionStream publishes every matched transaction to the stream as JSON, for
+// browser clients. It must be called before MonitorMempool.
+func SetTransactionStream(stream *api.EventStream) {
+	transactionStream = stream
+}
+
+// openSinks creates the sinks enabled through environment variables
+func openSinks() {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	if jsonOutput != nil {
+		sinks = append(sinks, sink.NewBuffered[decoder.DecodedTransaction](sink.NewStreamSink(jsonOutput), outputBuffer))
+	}
+
+	if dir := os.Getenv("PARQUET_DIR"); dir != "" {
+		parquetSink, err := sink.NewParquetSink(dir, parquetRotation)
+		if err != nil {
+			logging.Errorf("Failed to open Parquet sink: %v", err)
+		} else {
+			sinks = append(sinks, sink.NewBuffered[decoder.DecodedTransaction](parquetSink, parquetBuffer))
+			logging.Infof("Writing matched transactions to Parquet files in %s", dir)
+		}
+	}
+
+	if path := os.Getenv("JSONL_PATH"); path != "" {
+		jsonlSink, err := sink.NewJSONLSink[decoder.DecodedTransaction](path, jsonlRotation, jsonlFsync)
+		if err != nil {
+			logging.Errorf("Failed to open JSONL sink: %v", err)
+		} else {
+			sinks = append(sinks, sink.NewBuffered[decoder.DecodedTransaction](jsonlSink, jsonlBuffer))
+			logging.Infof("Appending matched transactions to %s", path)
+		}
+	}
+
+	if path := os.Getenv("CSV_OUTPUT"); path != "" {
+		csvSink, err := sink.NewCSVSink(path, csvHeader, csvRow)
+		if err != nil {
+			logging.Errorf("Failed to open CSV sink: %v", err)
+		} else {
+			sinks = append(sinks, sink.NewBuffered[decoder.DecodedTransaction](csvSink, csvBuffer))
+			logging.Infof("Appending matched transactions to %s", path)
+		}
+	}
+}
+
+// Columns of the CSV export
+var csvHeader = []string{"timestamp", "hash", "from", "to", "contract", "method", "value_native", "gas_price_gwei"}
+
+// csvRow renders a matched transaction as a CSV row, with the value in the chain's native currency
+// (ETH, POL, BNB and so on) and the gas price in Gwei
+func csvRow(tx decoder.DecodedTransaction) []string {
+	return []string{
+		tx.Timestamp.UTC().Format(time.RFC3339Nano),
+		tx.Hash,
+		tx.From,
+		tx.To,
+		tx.Contract,
+		tx.Method,
+		hexUnits(tx.Value, etherDecimals),
+		hexUnits(tx.GasPrice, gweiDecimals),
+	}
+}
+
+// hexUnits scales a hex wei amount down by the given decimals, leaving it empty when it can't be parsed
+func hexUnits(weiHex string, decimals int) string {
+	wei, err := hexutil.DecodeBig(weiHex)
+	if err != nil {
+		return ""
+	}
+	return decoder.FormatUnits(wei, decimals)
+}
+
+// closeSinks flushes any buffered records and closes every sink
+func closeSinks() {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	for _, s := range sinks {
+		if err := s.Close(); err != nil {
+			logging.Errorf("Failed to close sink: %v", err)
+		}
+	}
+	sinks = nil
+}
+
+// publish hands a matched transaction to every configured sink and to the stream's clients
+func publish(tx decoder.DecodedTransaction) {
+	sinksMu.RLock()
+	for _, s := range sinks {
+		if err := s.Write(tx); err != nil && err != sink.ErrClosed {
+			logging.Errorf("Failed to write to sink: %v", err)
+		}
+	}
+	sinksMu.RUnlock()
+
+	// Only encode the transaction when a browser is listening
+	if transactionStream != nil && transactionStream.HasClients() {
+		data, err := json.Marshal(tx)
+		if err != nil {
+			logging.Errorf("Failed to encode transaction for the stream: %v", err)
+			return
+		}
+		transactionStream.Publish("transaction", data)
+	}
+}
@@ -0,0 +1,98 @@
+package mempool
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// Gauges describing how saturated the processing pipeline currently is
+var (
+	inFlightTransactions int64 // Transactions currently being processed
+	inFlightRPC          int64 // RPC requests currently awaiting a response
+	busyWorkers          int64 // Workers currently processing a message
+)
+
+// pipelineQueues are the channels whose depth is reported in the stats
+type pipelineQueues struct {
+	msg       chan wsMessage
+	tx        chan string
+	txDetails chan string
+	tps       chan TPS
+}
+
+// Set when monitoring starts, which may be after the API began serving stats
+var statsQueues atomic.Pointer[pipelineQueues]
+
+// WorkerStats is how many of the processing workers are busy
+type WorkerStats struct {
+	Busy  int64 `json:"busy"`
+	Total int   `json:"total"`
+}
+
+// RateLimiterStats is the state of the RPC rate limiter
+type RateLimiterStats struct {
+	RatePerSecond float64 `json:"ratePerSecond"`
+	Burst         float64 `json:"burst"`
+	Tokens        float64 `json:"tokens"` // Requests that can be sent right away
+}
+
+// QueueStats is the current depth and capacity of a buffered channel, and how many updates were
+// dropped because it was full
+type QueueStats struct {
+	Depth    int    `json:"depth"`
+	Capacity int    `json:"capacity"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+// Stats is a point-in-time snapshot of pipeline saturation
+type Stats struct {
+	InFlightTransactions int64                 `json:"inFlightTransactions"`
+	InFlightRPC          int64                 `json:"inFlightRPC"`
+	Workers              WorkerStats           `json:"workers"`
+	RateLimiter          *RateLimiterStats     `json:"rateLimiter,omitempty"` // Set when RPC_RATE_LIMIT is set
+	Queues               map[string]QueueStats `json:"queues"`
+	Latency              LatencyStats          `json:"latency"`
+	SwapVolume           []PairVolume          `json:"swapVolume,omitempty"`     // Set when SWAP_VOLUME is enabled
+	GasPercentiles       *GasPercentiles       `json:"gasPercentiles,omitempty"` // Set when GAS_PERCENTILES is enabled
+	ContractMatches      []ContractMatches     `json:"contractMatches"`          // Since the counts were last reset
+}
+
+// CurrentStats returns a snapshot of the pipeline gauges and channel queue depths
+func CurrentStats() Stats {
+	stats := Stats{
+		InFlightTransactions: atomic.LoadInt64(&inFlightTransactions),
+		InFlightRPC:          atomic.LoadInt64(&inFlightRPC),
+		Workers:              WorkerStats{Busy: atomic.LoadInt64(&busyWorkers), Total: workerCount},
+		Queues:               make(map[string]QueueStats),
+		Latency:              processingLatency.snapshot(),
+		ContractMatches:      contractMatchesSnapshot(),
+	}
+	if trackSwapVolume {
+		stats.SwapVolume = swapVolumeSnapshot()
+	}
+	if gasHistory != nil {
+		stats.GasPercentiles = gasHistory.snapshot()
+	}
+
+	if rpcLimiter != nil {
+		stats.RateLimiter = &RateLimiterStats{RatePerSecond: rpcLimiter.rate, Burst: rpcLimiter.burst, Tokens: rpcLimiter.available()}
+	}
+
+	if queues := statsQueues.Load(); queues != nil {
+		stats.Queues["messages"] = QueueStats{Depth: len(queues.msg), Capacity: cap(queues.msg)}
+		stats.Queues["tx"] = QueueStats{Depth: len(queues.tx), Capacity: cap(queues.tx), Dropped: atomic.LoadUint64(&droppedTx)}
+		stats.Queues["txDetails"] = QueueStats{Depth: len(queues.txDetails), Capacity: cap(queues.txDetails), Dropped: atomic.LoadUint64(&droppedTxDetails)}
+		stats.Queues["tps"] = QueueStats{Depth: len(queues.tps), Capacity: cap(queues.tps), Dropped: atomic.LoadUint64(&droppedTps)}
+	}
+
+	return stats
+}
+
+// StatsHandler serves the current stats as JSON for the /stats API endpoint
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(CurrentStats())
+	})
+}
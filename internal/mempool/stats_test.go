@@ -0,0 +1,64 @@
+package mempool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The API serves stats while monitoring starts up; run with -race to catch unsynchronized state
+func TestCurrentStatsWhileMonitoringStarts(t *testing.T) {
+	t.Cleanup(func() { statsQueues.Store(nil) })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			CurrentStats()
+		}
+	}()
+	statsQueues.Store(&pipelineQueues{msg: make(chan wsMessage, 4), tx: make(chan string, 3), txDetails: make(chan string, 2), tps: make(chan TPS, 1)})
+	wg.Wait()
+
+	stats := CurrentStats()
+	if got := stats.Queues["tx"].Capacity; got != 3 {
+		t.Errorf("tx queue capacity = %d, want 3", got)
+	}
+	if stats.RateLimiter != nil {
+		t.Error("rate limiter reported without RPC_RATE_LIMIT")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(50, 2)
+	ctx := context.Background()
+
+	// The burst is available straight away
+	for i := 0; i < 2; i++ {
+		if err := limiter.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokens := limiter.available(); tokens >= 1 {
+		t.Errorf("%.2f tokens left after the burst, want less than 1", tokens)
+	}
+
+	// The next request waits for a refill, about 20ms at 50 per second
+	start := time.Now()
+	if err := limiter.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("request went through after %s, want it to wait for a token", elapsed)
+	}
+
+	// Waiting gives up with the context
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	limiter.tokens = 0
+	if err := limiter.wait(cancelled); err == nil {
+		t.Error("wait succeeded on a cancelled context without tokens")
+	}
+}
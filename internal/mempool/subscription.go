@@ -0,0 +1,136 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultSubscription returns the eth_subscribe params used when a chain doesn't configure its own
+func defaultSubscription() json.RawMessage {
+	if fullPendingTransactions {
+		return json.RawMessage(`["newPendingTransactions",true]`)
+	}
+	return json.RawMessage(`["newPendingTransactions"]`)
+}
+
+// parseSubscription checks custom eth_subscribe params (SUBSCRIPTION_PARAMS), such as
+// ["alchemy_pendingTransactions",{"toAddress":["0x..."]}], and returns them compacted. The params
+// must be a JSON array starting with the subscription name.
+func parseSubscription(raw json.RawMessage) (json.RawMessage, error) {
+	var params []json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, errors.New("must be a JSON array of eth_subscribe params")
+	}
+	var name string
+	if len(params) == 0 || json.Unmarshal(params[0], &name) != nil || name == "" {
+		return nil, errors.New(`must start with the subscription name, e.g. ["newPendingTransactions"]`)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return nil, err
+	}
+	return compact.Bytes(), nil
+}
+
+// subscriptionName returns the subscription named by eth_subscribe params, e.g. newPendingTransactions
+func subscriptionName(params json.RawMessage) string {
+	var fields []json.RawMessage
+	var name string
+	if json.Unmarshal(params, &fields) != nil || len(fields) == 0 || json.Unmarshal(fields[0], &name) != nil {
+		return string(params)
+	}
+	return name
+}
+
+// pendingSubscription returns the chain's custom subscription params, or the default ones
+func (m *Monitor) pendingSubscription() json.RawMessage {
+	if m.subscription != nil {
+		return m.subscription
+	}
+	return defaultSubscription()
+}
+
+// subscribeRequest builds the eth_subscribe request for pending transactions with the given params
+func subscribeRequest(params json.RawMessage) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"eth_subscribe","params":%s}`, pendingSubscribeID, params)
+}
+
+// awaitSubscribeResponse reads the connection until the provider responds to the pending transaction
+// subscribe request, returning the error the provider rejected it with, if any. Notifications only
+// follow a successful response, so nothing of interest is skipped.
+func awaitSubscribeResponse(conn *websocket.Conn) (*jsonRPCError, error) {
+	conn.SetReadDeadline(time.Now().Add(rpcTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subscribe response: %w", err)
+		}
+		var resp struct {
+			ID    int           `json:"id"`
+			Error *jsonRPCError `json:"error"`
+		}
+		if json.Unmarshal(message, &resp) != nil || resp.ID != pendingSubscribeID {
+			continue
+		}
+		return resp.Error, nil
+	}
+}
+
+// Fields that wrap the transaction in notifications of some custom subscriptions, e.g. bloXroute's
+// {"txHash":"0x...","txContents":{...}}
+var wrappedTransactionFields = []string{"transaction", "tx", "txContents"}
+
+// notificationItems splits the result of a pending transaction notification into the transactions
+// it announces. Besides a hash or a full transaction, custom subscriptions may send a batch of
+// either, a transaction wrapped in another object, or an object holding only the hash. Each item
+// returned is a hash string or a transaction object.
+func notificationItems(raw json.RawMessage) ([]json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("notification has no result")
+	}
+
+	switch raw[0] {
+	case '[':
+		var batch []json.RawMessage
+		if err := json.Unmarshal(raw, &batch); err != nil {
+			return nil, err
+		}
+		var items []json.RawMessage
+		for _, entry := range batch {
+			expanded, err := notificationItems(entry)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, expanded...)
+		}
+		return items, nil
+	case '{':
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+		for _, name := range wrappedTransactionFields {
+			if inner, ok := fields[name]; ok && len(bytes.TrimSpace(inner)) > 0 && bytes.TrimSpace(inner)[0] == '{' {
+				return []json.RawMessage{inner}, nil
+			}
+		}
+
+		// Without a sender the object can't be a full transaction, so only its hash is used
+		if _, ok := fields["from"]; !ok {
+			for _, name := range []string{"hash", "txHash"} {
+				if hash, ok := fields[name]; ok {
+					return []json.RawMessage{hash}, nil
+				}
+			}
+		}
+	}
+	return []json.RawMessage{raw}, nil
+}
@@ -0,0 +1,89 @@
+package mempool
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// announced is what the monitor takes from one transaction in a notification: a hash to fetch, or a
+// full transaction used as is
+type announced struct {
+	fetch string
+	hash  string
+	input string
+}
+
+func TestNotificationShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		want    []announced
+		wantErr bool
+	}{
+		{
+			name:   "hash only",
+			result: `"0xabc"`,
+			want:   []announced{{fetch: "0xabc"}},
+		},
+		{
+			name:   "full object",
+			result: `{"hash":"0xabc","from":"0x1","to":"0x2","input":"0x095ea7b3"}`,
+			want:   []announced{{hash: "0xabc", input: "0x095ea7b3"}},
+		},
+		{
+			name:   "full object with data instead of input",
+			result: `{"hash":"0xabc","from":"0x1","data":"0x095ea7b3"}`,
+			want:   []announced{{hash: "0xabc", input: "0x095ea7b3"}},
+		},
+		{
+			name:   "batch of both",
+			result: `["0xabc",{"hash":"0xdef","from":"0x1","input":"0x"}]`,
+			want:   []announced{{fetch: "0xabc"}, {hash: "0xdef", input: "0x"}},
+		},
+		{
+			name:   "wrapped transaction",
+			result: `{"txHash":"0xabc","txContents":{"hash":"0xabc","from":"0x1","input":"0x01"}}`,
+			want:   []announced{{hash: "0xabc", input: "0x01"}},
+		},
+		{
+			name:   "object holding only the hash",
+			result: `{"txHash":"0xabc"}`,
+			want:   []announced{{fetch: "0xabc"}},
+		},
+		{name: "empty", result: ``, wantErr: true},
+		{name: "unexpected shape", result: `42`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []announced
+			items, err := notificationItems(json.RawMessage(tt.result))
+			for _, item := range items {
+				result, txHash, parseErr := parseNotificationResult(item)
+				if parseErr != nil {
+					err = parseErr
+					break
+				}
+				got = append(got, announced{fetch: txHash, hash: result.Result.Hash, input: result.Result.Input})
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("item %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package mempool
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// WriteSummary writes the session's totals, for printing once monitoring has stopped. Unlike the
+// match counts in the TUI, the per-contract totals are never reset.
+func WriteSummary(w io.Writer) {
+	metricMatchedMu.Lock()
+	names := make([]string, 0, len(metricMatched))
+	counts := make(map[string]uint64, len(metricMatched))
+	var matched uint64
+	for name, count := range metricMatched {
+		names = append(names, name)
+		counts[name] = count
+		matched += count
+	}
+	metricMatchedMu.Unlock()
+
+	// Busiest contracts first
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	fmt.Fprintf(w, "Session summary:\n")
+	fmt.Fprintf(w, "  Uptime:             %s\n", time.Since(startedAt).Round(time.Second))
+	fmt.Fprintf(w, "  Transactions seen:  %d\n", atomic.LoadUint64(&metricTransactionsSeen))
+	fmt.Fprintf(w, "  Matched:            %d\n", matched)
+	for _, name := range names {
+		fmt.Fprintf(w, "    %s: %d\n", name, counts[name])
+	}
+	fmt.Fprintf(w, "  RPC errors:         %d\n", atomic.LoadUint64(&metricRPCErrors))
+	fmt.Fprintf(w, "  Reconnects:         %d\n", atomic.LoadUint64(&metricReconnects))
+}
@@ -0,0 +1,205 @@
+package mempool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Telegram delivery defaults. Telegram allows about one message per second per chat and 20 per
+// minute in groups, so notifications are sent at most every few seconds, batched.
+const (
+	defaultTelegramInterval = 3 * time.Second
+	telegramQueueSize       = 1000
+	telegramMaxMessageLen   = 4096 // Telegram's limit on a message's text
+)
+
+// Telegram settings; notifications are sent when both the bot token and chat id are set
+var (
+	telegramToken    string
+	telegramChatID   string
+	telegramInterval = defaultTelegramInterval
+	telegramMinValue *big.Int // Only notify above this value in wei (TELEGRAM_MIN_VALUE_ETH); nil notifies on every match
+	telegramQueue    chan string
+	telegramAPI      = "https://api.telegram.org" // Bot API base URL
+)
+
+// startTelegramSender starts the goroutine that delivers queued notifications, batching those that
+// arrive in a burst into one message and sending no more than one message per telegramInterval
+func startTelegramSender(ctx context.Context) {
+	if telegramToken == "" || telegramChatID == "" {
+		return
+	}
+
+	telegramQueue = make(chan string, telegramQueueSize)
+	client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{Proxy: proxyFunc}}
+	go func() {
+		ticker := time.NewTicker(telegramInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// Collect everything queued since the last message
+			var pending []string
+			for drained := false; !drained; {
+				select {
+				case text := <-telegramQueue:
+					pending = append(pending, text)
+				default:
+					drained = true
+				}
+			}
+
+			// Long bursts are split over several messages, each waiting out the rate limit
+			for i, text := range splitTelegramMessages(pending) {
+				if i > 0 {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(telegramInterval):
+					}
+				}
+				if err := sendTelegram(ctx, client, text); err != nil && ctx.Err() == nil {
+					atomic.AddUint64(&metricTelegramFailures, 1)
+					logging.Warnf("Failed to send Telegram notification: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// notifyTelegram queues a notification for a matched transaction whose value exceeds the threshold.
+// It never blocks: when the queue is full the notification is dropped.
+func notifyTelegram(chain string, result decoder.TransactionResult, contract Contract, method string) {
+	if telegramQueue == nil {
+		return
+	}
+	value, err := hexutil.DecodeBig(result.Result.Value)
+	if err != nil {
+		value = new(big.Int)
+	}
+	if telegramMinValue != nil && value.Cmp(telegramMinValue) <= 0 {
+		return
+	}
+
+	target := contract.Name
+	if chain != "" {
+		target += " (" + chain + ")"
+	}
+	text := fmt.Sprintf("%s on %s\n%s ETH from %s\nTx: %s",
+		method, target, decoder.FormatUnits(value, etherDecimals), result.Result.From, result.Result.Hash)
+	select {
+	case telegramQueue <- text:
+	default:
+		atomic.AddUint64(&metricTelegramFailures, 1)
+	}
+}
+
+// splitTelegramMessages joins notifications into as few messages as fit Telegram's length limit.
+// A notification too long for a message of its own is cut short.
+func splitTelegramMessages(pending []string) []string {
+	var messages []string
+	var current strings.Builder
+	for _, text := range pending {
+		text = truncateTelegramText(text)
+		if current.Len() > 0 && current.Len()+2+len(text) > telegramMaxMessageLen {
+			messages = append(messages, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(text)
+	}
+	if current.Len() > 0 {
+		messages = append(messages, current.String())
+	}
+	return messages
+}
+
+// truncateTelegramText cuts text that exceeds Telegram's length limit, marking the cut with an ellipsis
+func truncateTelegramText(text string) string {
+	if len(text) <= telegramMaxMessageLen {
+		return text
+	}
+	const ellipsis = "…"
+	cut := text[:telegramMaxMessageLen-len(ellipsis)]
+	for !utf8.ValidString(cut) { // Don't split a multi-byte character
+		cut = cut[:len(cut)-1]
+	}
+	return cut + ellipsis
+}
+
+// sendTelegram sends a message to the configured chat through the Bot API. When Telegram asks to
+// slow down, it waits the requested time, or telegramInterval when none is given, and tries once more.
+func sendTelegram(ctx context.Context, client *http.Client, text string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  telegramChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		url := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPI, telegramToken)
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			// The error includes the URL, which holds the bot token
+			return fmt.Errorf("request to the Telegram Bot API failed: %w", redactTelegramToken(err))
+		}
+		var reply struct {
+			OK          bool   `json:"ok"`
+			Description string `json:"description"`
+			Parameters  struct {
+				RetryAfter int `json:"retry_after"`
+			} `json:"parameters"`
+		}
+		json.NewDecoder(resp.Body).Decode(&reply)
+		resp.Body.Close()
+
+		if reply.OK {
+			return nil
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt > 0 {
+			return fmt.Errorf("Telegram returned %s: %s", resp.Status, reply.Description)
+		}
+		wait := time.Duration(reply.Parameters.RetryAfter) * time.Second
+		if wait <= 0 {
+			wait = telegramInterval
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// redactTelegramToken removes the bot token, registered as a secret in Setup, from an error message
+func redactTelegramToken(err error) error {
+	return fmt.Errorf("%s", logging.Redact(err.Error()))
+}
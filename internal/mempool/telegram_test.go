@@ -0,0 +1,86 @@
+package mempool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitTelegramMessages(t *testing.T) {
+	short := strings.Repeat("a", 100)
+	half := strings.Repeat("b", telegramMaxMessageLen/2)
+	long := strings.Repeat("é", telegramMaxMessageLen) // Two bytes per character
+
+	if got := splitTelegramMessages([]string{short, short}); len(got) != 1 || got[0] != short+"\n\n"+short {
+		t.Errorf("short notifications weren't joined into one message: %q", got)
+	}
+	if got := splitTelegramMessages([]string{half, half}); len(got) != 2 {
+		t.Errorf("notifications over the limit together gave %d messages, want 2", len(got))
+	}
+
+	got := splitTelegramMessages([]string{short, long, short})
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3", len(got))
+	}
+	for i, message := range got {
+		if len(message) > telegramMaxMessageLen || !utf8.ValidString(message) {
+			t.Errorf("message %d is %d bytes or not valid UTF-8", i, len(message))
+		}
+	}
+	if !strings.HasSuffix(got[1], "…") {
+		t.Error("cut notification isn't marked with an ellipsis")
+	}
+}
+
+// telegramServer answers sendMessage requests with the given replies in turn, repeating the last one,
+// and counts the requests
+func telegramServer(t *testing.T, replies ...string) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := replies[min(int(requests.Add(1)), len(replies))-1]
+		if !strings.Contains(reply, `"ok":true`) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+
+	savedAPI, savedInterval := telegramAPI, telegramInterval
+	telegramAPI, telegramInterval = server.URL, 50*time.Millisecond
+	t.Cleanup(func() { telegramAPI, telegramInterval = savedAPI, savedInterval })
+	return &requests
+}
+
+// Without a retry_after, a rate-limited message is retried after telegramInterval rather than at once
+func TestSendTelegramRetriesRateLimit(t *testing.T) {
+	requests := telegramServer(t, `{"ok":false,"description":"Too Many Requests","parameters":{"retry_after":0}}`, `{"ok":true}`)
+
+	start := time.Now()
+	if err := sendTelegram(context.Background(), http.DefaultClient, "hello"); err != nil {
+		t.Fatalf("sendTelegram: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < telegramInterval {
+		t.Errorf("retried after %s, want at least %s", elapsed, telegramInterval)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+// A message that is still rate limited after waiting is given up on
+func TestSendTelegramGivesUpAfterRetry(t *testing.T) {
+	requests := telegramServer(t, `{"ok":false,"description":"Too Many Requests"}`)
+
+	if err := sendTelegram(context.Background(), http.DefaultClient, "hello"); err == nil {
+		t.Error("sendTelegram succeeded while rate limited")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
@@ -0,0 +1,43 @@
+package mempool
+
+import (
+	"context"
+	"time"
+
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Default token cache location and how often it is saved while monitoring
+const (
+	defaultTokenCachePath         = "token_cache.json"
+	defaultTokenCacheSaveInterval = 5 * time.Minute
+)
+
+// Token cache persistence settings, from TOKEN_CACHE_PATH and TOKEN_CACHE_SAVE_INTERVAL
+var (
+	tokenCachePath         = defaultTokenCachePath
+	tokenCacheSaveInterval = defaultTokenCacheSaveInterval
+)
+
+// saveTokenCache writes the token cache to disk, logging rather than failing on errors
+func saveTokenCache() {
+	if err := cache.SaveTokenCache(tokenCachePath); err != nil {
+		logging.Errorf("Failed to save token cache: %v", err)
+	}
+}
+
+// saveTokenCachePeriodically saves the token cache until the context is cancelled
+func saveTokenCachePeriodically(ctx context.Context) {
+	ticker := time.NewTicker(tokenCacheSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			saveTokenCache()
+		}
+	}
+}
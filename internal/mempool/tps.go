@@ -0,0 +1,45 @@
+package mempool
+
+// Default number of one-second samples in the rolling TPS average (TPS_WINDOW)
+const defaultTPSWindow = 10
+
+// TPS is the transaction count of the last second along with the rolling average over the window
+type TPS struct {
+	Current uint64
+	Average float64
+	Window  int // Seconds covered by the average; fewer until the window has filled
+}
+
+// tpsWindow is a ring buffer of the last per-second transaction counts
+type tpsWindow struct {
+	counts []uint64
+	next   int
+	filled bool
+	sum    uint64
+}
+
+// Window length of the rolling TPS average, in seconds
+var tpsWindowSize = defaultTPSWindow
+
+func newTPSWindow(size int) *tpsWindow {
+	if size <= 0 {
+		size = defaultTPSWindow
+	}
+	return &tpsWindow{counts: make([]uint64, size)}
+}
+
+// add records the count of the last second and returns it along with the rolling average
+func (w *tpsWindow) add(count uint64) TPS {
+	w.sum += count - w.counts[w.next] // Wraps correctly even when the evicted count is larger
+	w.counts[w.next] = count
+	w.next = (w.next + 1) % len(w.counts)
+	if w.next == 0 {
+		w.filled = true
+	}
+
+	samples := w.next
+	if w.filled {
+		samples = len(w.counts)
+	}
+	return TPS{Current: count, Average: float64(w.sum) / float64(samples), Window: samples}
+}
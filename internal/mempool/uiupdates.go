@@ -0,0 +1,57 @@
+package mempool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// How often dropped UI updates are reported in the log
+const droppedReportInterval = 10 * time.Second
+
+// UI updates dropped per channel because the display wasn't keeping up with them
+var (
+	droppedTx        uint64
+	droppedTxDetails uint64
+	droppedTps       uint64
+	droppedMined     uint64
+)
+
+// sendUpdate queues an update for the UI without waiting. When the channel is full the update is
+// dropped and counted instead, so slow rendering never stalls the workers and the subscription.
+func sendUpdate[T any](ch chan<- T, update T, dropped *uint64) {
+	select {
+	case ch <- update:
+	default:
+		atomic.AddUint64(dropped, 1)
+	}
+}
+
+// droppedUpdates returns the number of UI updates dropped so far across all channels
+func droppedUpdates() uint64 {
+	return atomic.LoadUint64(&droppedTx) + atomic.LoadUint64(&droppedTxDetails) + atomic.LoadUint64(&droppedTps) + atomic.LoadUint64(&droppedMined)
+}
+
+// reportDroppedUpdates logs how many UI updates were dropped every droppedReportInterval in which
+// any were, until the context is cancelled
+func reportDroppedUpdates(ctx context.Context) {
+	ticker := time.NewTicker(droppedReportInterval)
+	defer ticker.Stop()
+
+	var lastTx, lastDetails, lastTps, lastMined uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tx, details, tps, mined := atomic.LoadUint64(&droppedTx), atomic.LoadUint64(&droppedTxDetails), atomic.LoadUint64(&droppedTps), atomic.LoadUint64(&droppedMined)
+			if total := (tx - lastTx) + (details - lastDetails) + (tps - lastTps) + (mined - lastMined); total > 0 {
+				logging.Warnf("Display is falling behind: dropped %d updates in the last %s (tx %d, details %d, tps %d, mined %d)",
+					total, droppedReportInterval, tx-lastTx, details-lastDetails, tps-lastTps, mined-lastMined)
+			}
+			lastTx, lastDetails, lastTps, lastMined = tx, details, tps, mined
+		}
+	}
+}
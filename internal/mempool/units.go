@@ -0,0 +1,79 @@
+package mempool
+
+import (
+	"math/big"
+	"strings"
+
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Decimal places of ether and gwei relative to wei
+const (
+	etherDecimals = 18
+	gweiDecimals  = 9
+)
+
+// parseEther parses a decimal ETH amount such as "0.05" into wei
+func parseEther(s string) (*big.Int, bool) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if len(frac) > etherDecimals || strings.HasPrefix(whole, "-") {
+		return nil, false
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	return new(big.Int).SetString(whole+frac+strings.Repeat("0", etherDecimals-len(frac)), 10)
+}
+
+// formatEther renders a hex wei value in the native currency, e.g. "0.1 ETH (0x16345785d8a0000)",
+// keeping the raw hex
+func formatEther(weiHex, symbol string) string {
+	wei, err := hexutil.DecodeBig(weiHex)
+	if err != nil {
+		return weiHex
+	}
+	return decoder.FormatUnits(wei, etherDecimals) + " " + symbol + " (" + weiHex + ")"
+}
+
+// formatGwei renders a hex wei gas price as Gwei, e.g. "25.3 Gwei (0x5e3ff5d00)", keeping the raw hex
+func formatGwei(weiHex string) string {
+	wei, err := hexutil.DecodeBig(weiHex)
+	if err != nil {
+		return weiHex
+	}
+	return decoder.FormatUnits(wei, gweiDecimals) + " Gwei (" + weiHex + ")"
+}
+
+// effectivePriorityFee is the tip an EIP-1559 transaction would pay at the chain's latest base fee:
+// min(maxPriorityFeePerGas, maxFeePerGas - baseFee). It needs a head with a base fee (NEW_HEADS=true),
+// so baseFee is nil before one arrives.
+func effectivePriorityFee(baseFee *big.Int, maxFeeHex, maxPriorityFeeHex string) (*big.Int, bool) {
+	maxFee, err := hexutil.DecodeBig(maxFeeHex)
+	if baseFee == nil || err != nil {
+		return nil, false
+	}
+	maxPriorityFee, err := hexutil.DecodeBig(maxPriorityFeeHex)
+	if err != nil {
+		return nil, false
+	}
+
+	tip := new(big.Int).Sub(maxFee, baseFee)
+	if tip.Cmp(maxPriorityFee) > 0 {
+		tip = maxPriorityFee
+	}
+	if tip.Sign() < 0 {
+		tip.SetInt64(0) // The transaction can't be included until the base fee drops
+	}
+	return tip, true
+}
+
+// formatQuantity renders a hex quantity such as gas or a nonce in decimal, keeping the raw hex
+func formatQuantity(quantityHex string) string {
+	quantity, err := hexutil.DecodeBig(quantityHex)
+	if err != nil {
+		return quantityHex
+	}
+	return quantity.String() + " (" + quantityHex + ")"
+}
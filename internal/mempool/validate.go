@@ -0,0 +1,97 @@
+package mempool
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"eth-mempool-monitor/internal/settings"
+)
+
+// Setting names used in validation messages, for chains read from the environment or a chains file
+var (
+	envSettingNames  = settingNames{ws: "WS_ENDPOINT", https: "HTTPS_ENDPOINT", transport: "TRANSPORT", username: "USERNAME", password: "PASSWORD", subscription: "SUBSCRIPTION_PARAMS"}
+	fileSettingNames = settingNames{ws: "wsEndpoint", https: "httpsEndpoint", transport: "transport", username: "username", password: "password", subscription: "subscription"}
+)
+
+// settingNames are the names of a chain's settings as the user wrote them
+type settingNames struct {
+	ws, https, transport, username, password, subscription string
+}
+
+// Validate checks that the endpoints needed to monitor the mempool of every chain are set and are
+// valid URLs, reporting every problem found in a single error
+func (cfg Config) Validate() error {
+	var problems []string
+	for _, chain := range cfg.Chains {
+		if cfg.ChainsPath == "" {
+			problems = append(problems, chain.validate(envSettingNames)...)
+			continue
+		}
+		for _, problem := range chain.validate(fileSettingNames) {
+			problems = append(problems, fmt.Sprintf("chain %s in %s: %s", chain.Name, cfg.ChainsPath, problem))
+		}
+	}
+
+	return settings.Problems(problems).Err()
+}
+
+// validate returns the problems with a chain's endpoints, credentials and subscription params
+func (chain ChainConfig) validate(names settingNames) []string {
+	var problems []string
+
+	// The WebSocket endpoint isn't used when polling over HTTPS
+	ws := strings.TrimSpace(chain.WSEndpoint)
+	switch {
+	case chain.Transport != "" && chain.Transport != transportWS && chain.Transport != transportPoll:
+		problems = append(problems, fmt.Sprintf("%s %q is not %q or %q", names.transport, chain.Transport, transportWS, transportPoll))
+	case chain.Transport == transportPoll:
+	case ws == "":
+		problems = append(problems, names.ws+" is not set")
+	default:
+		if err := checkEndpointURL(ws, "ws", "wss"); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", names.ws, err))
+		}
+	}
+
+	https := strings.TrimSpace(chain.HTTPSEndpoint)
+	if https == "" {
+		problems = append(problems, names.https+" is not set")
+	} else {
+		// The HTTPS endpoint may list several endpoints separated by commas
+		for _, endpoint := range strings.Split(https, ",") {
+			if err := checkEndpointURL(strings.TrimSpace(endpoint), "http", "https"); err != nil {
+				problems = append(problems, fmt.Sprintf("%s %v", names.https, err))
+			}
+		}
+	}
+
+	// Basic authentication is only used with both credentials; a lone one is almost certainly a mistake
+	if (chain.Username == "") != (chain.Password == "") {
+		problems = append(problems, fmt.Sprintf("%s and %s must be set together, or both left empty for endpoints that authenticate by URL", names.username, names.password))
+	}
+
+	if len(chain.Subscription) > 0 {
+		if _, err := parseSubscription(chain.Subscription); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", names.subscription, err))
+		}
+	}
+	return problems
+}
+
+// checkEndpointURL reports whether raw is an absolute URL with a host and one of the given schemes
+func checkEndpointURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid URL: %w", raw, err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", raw)
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q must use the %s scheme", raw, strings.Join(schemes, " or "))
+}
@@ -0,0 +1,166 @@
+package mempool
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+
+	"eth-mempool-monitor/internal/cache"
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// How multi-hop swap paths are attributed to pairs
+const (
+	volumeHopsEndpoints = "endpoints" // One pair from the first to the last token of the path
+	volumeHopsEach      = "each"      // Every hop of the path counts as a swap on its pair
+)
+
+// Swap volume aggregation settings; trackSwapVolume is enabled with SWAP_VOLUME=true
+var (
+	trackSwapVolume bool
+	volumeHops      = volumeHopsEndpoints
+)
+
+// Number of pairs shown in the stats pane
+const volumeTopPairs = 5
+
+// PairVolume is the running swap total of a token pair. AmountIn is in whole units of the input token
+// and only covers hops whose input amount is known from the calldata.
+type PairVolume struct {
+	Pair     string  `json:"pair"`
+	Swaps    uint64  `json:"swaps"`
+	AmountIn float64 `json:"amountIn"`
+	TokenIn  string  `json:"tokenIn"`
+}
+
+// Running per-pair totals for the session, keyed by pair label
+var (
+	swapVolumeMu sync.Mutex
+	swapVolume   = make(map[string]*PairVolume)
+)
+
+// swapAmountIn returns the amount a swap spends: the exact input, the maximum input for exact-output
+// swaps, or the ETH sent for ETH-input swaps
+func swapAmountIn(decoded *decoder.DecodedTransaction) *big.Int {
+	switch decoded.Method {
+	case "swapExactETHForTokens", "swapETHForExactTokens":
+		value, err := hexutil.DecodeBig(decoded.Value)
+		if err != nil {
+			return nil
+		}
+		return value
+	}
+
+	for _, name := range []string{"amountIn", "amountInMax"} {
+		for _, param := range decoded.Params {
+			if amount, ok := param.Value.(*big.Int); ok && param.Name == name {
+				return amount
+			}
+		}
+	}
+	return nil
+}
+
+// swapPath returns the token path of a swap, or nil if the transaction isn't a recognized swap
+func swapPath(decoded *decoder.DecodedTransaction) []common.Address {
+	for _, param := range decoded.Params {
+		if path, ok := param.Value.([]common.Address); ok && param.Name == "path" && len(path) >= 2 {
+			return path
+		}
+	}
+	return nil
+}
+
+// tokenSymbol names a token for pair labels, falling back to the address when details can't be fetched
+func tokenSymbol(ctx context.Context, addr common.Address) (string, uint8) {
+	info, err := cache.FetchTokenDetails(ctx, addr)
+	if err != nil || info.Symbol == "" {
+		return addr.Hex(), 18
+	}
+	return info.Symbol, info.Decimals
+}
+
+// recordSwapVolume adds a decoded swap to the per-pair totals
+func recordSwapVolume(ctx context.Context, decoded *decoder.DecodedTransaction) {
+	path := swapPath(decoded)
+	if path == nil {
+		return
+	}
+
+	// Scale the input amount by the decimals of the token being sold
+	_, decimals := tokenSymbol(ctx, path[0])
+	var amountIn float64
+	if raw := swapAmountIn(decoded); raw != nil {
+		amountIn, _ = strconv.ParseFloat(decoder.FormatUnits(raw, int(decimals)), 64)
+	}
+
+	// Only the first hop's input amount is known from the calldata
+	hops := [][2]common.Address{{path[0], path[len(path)-1]}}
+	if volumeHops == volumeHopsEach {
+		hops = hops[:0]
+		for i := 0; i+1 < len(path); i++ {
+			hops = append(hops, [2]common.Address{path[i], path[i+1]})
+		}
+	}
+
+	for i, hop := range hops {
+		from, _ := tokenSymbol(ctx, hop[0])
+		to, _ := tokenSymbol(ctx, hop[1])
+		pair := from + "/" + to
+
+		swapVolumeMu.Lock()
+		volume, exists := swapVolume[pair]
+		if !exists {
+			volume = &PairVolume{Pair: pair, TokenIn: from}
+			swapVolume[pair] = volume
+		}
+		volume.Swaps++
+		if i == 0 {
+			volume.AmountIn += amountIn
+		}
+		swapVolumeMu.Unlock()
+	}
+}
+
+// swapVolumeSnapshot returns the per-pair totals ordered by swap count, busiest first
+func swapVolumeSnapshot() []PairVolume {
+	swapVolumeMu.Lock()
+	defer swapVolumeMu.Unlock()
+
+	pairs := make([]PairVolume, 0, len(swapVolume))
+	for _, volume := range swapVolume {
+		pairs = append(pairs, *volume)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Swaps != pairs[j].Swaps {
+			return pairs[i].Swaps > pairs[j].Swaps
+		}
+		return pairs[i].Pair < pairs[j].Pair
+	})
+	return pairs
+}
+
+// FormatSwapVolume renders the busiest pairs as a single status line, e.g. "WETH/USDC: 142 swaps, ~1200 WETH in"
+func FormatSwapVolume(pairs []PairVolume) string {
+	line := "Volume:"
+	if len(pairs) == 0 {
+		return line + " no swaps yet"
+	}
+	for i, volume := range pairs {
+		if i == volumeTopPairs {
+			line += fmt.Sprintf(" | +%d more", len(pairs)-volumeTopPairs)
+			break
+		}
+		if i > 0 {
+			line += " |"
+		}
+		line += fmt.Sprintf(" %s: %d swaps, ~%.4g %s in", volume.Pair, volume.Swaps, volume.AmountIn, volume.TokenIn)
+	}
+	return line
+}
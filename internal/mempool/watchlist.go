@@ -0,0 +1,140 @@
+package mempool
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Default interval between checks of the contracts file for changes (CONTRACTS_RELOAD_INTERVAL)
+const defaultContractsReloadInterval = 5 * time.Second
+
+// How often each chain's contracts file is checked for changes
+var contractsReloadInterval = defaultContractsReloadInterval
+
+// indexContracts builds the address index used to match transactions against the loaded contracts,
+// and with BLOOM_FILTER a Bloom filter sized from the number of watched addresses, and swaps them in
+// along with the list
+func (m *Monitor) indexContracts(list []Contract) {
+	byAddress := make(map[common.Address]Contract, len(list))
+	for _, contract := range list {
+		byAddress[common.HexToAddress(contract.Address)] = contract
+	}
+
+	var filter *bloomFilter
+	if bloomPrefilter {
+		filter = newBloomFilter(len(byAddress), bloomFPRate)
+		for addr := range byAddress {
+			filter.add(addr.Bytes())
+		}
+	}
+
+	m.contractsMu.Lock()
+	m.contracts, m.contractsByAddress, m.watchedFilter = list, byAddress, filter
+	m.contractsMu.Unlock()
+}
+
+// reloadContracts re-reads the contracts file, keeping the current contracts if it is broken
+func (m *Monitor) reloadContracts() {
+	list, err := LoadContracts(m.contractsPath)
+	if err != nil {
+		m.logf(slog.LevelWarn, "Error reloading contracts, keeping the previous %d: %v", m.watchedContractCount(), err)
+		return
+	}
+	m.indexContracts(list)
+	retainWatchedABIs()
+	m.logf(slog.LevelInfo, "Reloaded %d contracts from %s", len(list), m.contractsPath)
+	m.warnIfNoContracts()
+	m.refreshServerFilter()
+}
+
+// retainWatchedABIs drops parsed ABIs that no chain watches any more from the decoder's cache, such as
+// the previous version of an ABI edited in a contracts file
+func retainWatchedABIs() {
+	abis := []string{genericABI}
+	for _, m := range monitors {
+		m.contractsMu.RLock()
+		for _, contract := range m.contracts {
+			abis = append(abis, string(contract.ABI))
+		}
+		m.contractsMu.RUnlock()
+	}
+	decoder.RetainABIs(abis)
+}
+
+// watchContracts reloads the contracts whenever their file changes
+func (m *Monitor) watchContracts(ctx context.Context) {
+	if contractsReloadInterval <= 0 {
+		return
+	}
+	if err := watchFile(ctx, m.contractsPath, m.reloadContracts); err != nil {
+		m.logf(slog.LevelWarn, "Not watching %s for changes: %v", m.contractsPath, err)
+	}
+}
+
+// watchFile calls reload whenever the file's modification time or size changes, checking every
+// contractsReloadInterval until the context is cancelled. It fails if the file can't be found at first.
+func watchFile(ctx context.Context, path string, reload func()) error {
+	last, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(contractsReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				continue // The file may be mid-replacement; check again on the next tick
+			}
+			if !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+				last = info
+				reload()
+			}
+		}
+	}
+}
+
+// watchedContractCount returns the number of loaded contracts
+func (m *Monitor) watchedContractCount() int {
+	m.contractsMu.RLock()
+	defer m.contractsMu.RUnlock()
+	return len(m.contracts)
+}
+
+// matchContract returns the watched contract a transaction is sent to, if any
+func (m *Monitor) matchContract(to string) (Contract, bool) {
+	if to == "" {
+		return Contract{}, false
+	}
+
+	addr := common.HexToAddress(to)
+	m.contractsMu.RLock()
+	defer m.contractsMu.RUnlock()
+
+	// With BLOOM_FILTER, most unwatched recipients are ruled out before the definitive lookup
+	if m.watchedFilter != nil && !m.watchedFilter.mayContain(addr.Bytes()) {
+		return Contract{}, false
+	}
+	contract, exists := m.contractsByAddress[addr]
+	return contract, exists
+}
+
+// contractABI returns the ABI of a watched contract by address, used to decode calls wrapped in
+// Safe transactions
+func (m *Monitor) contractABI(to string) (string, bool) {
+	m.contractsMu.RLock()
+	defer m.contractsMu.RUnlock()
+
+	contract, exists := m.contractsByAddress[common.HexToAddress(to)]
+	return string(contract.ABI), exists
+}
@@ -0,0 +1,147 @@
+package mempool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Webhook delivery defaults
+const (
+	defaultWebhookTimeout = 5 * time.Second
+	defaultWebhookRetries = 2
+	webhookQueueSize      = 100
+	webhookSenders        = 2
+)
+
+// Webhook settings; alerts are sent when webhookURL is set (WEBHOOK_URL)
+var (
+	webhookURL        string
+	webhookTimeout    = defaultWebhookTimeout
+	webhookRetries    = defaultWebhookRetries
+	webhookRetryDelay = 500 * time.Millisecond // Pause between attempts
+	alertMinValue     *big.Int                 // Only alert above this value in wei (ALERT_VALUE_ETH); nil alerts on every match
+)
+
+// Alerts waiting to be posted; nil until monitoring starts
+var webhookQueue chan webhookAlert
+
+// webhookAlert is the JSON payload posted for a high-value matched transaction
+type webhookAlert struct {
+	Hash     string `json:"hash"`
+	Chain    string `json:"chain,omitempty"` // Name of the chain the transaction was seen on
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Contract string `json:"contract"`
+	Value    string `json:"value"`    // In ETH
+	ValueWei string `json:"valueWei"` // Hex, as reported by the node
+	Method   string `json:"method"`
+}
+
+// startWebhookSenders starts the goroutines that post queued alerts, so a slow or failing webhook
+// never holds up transaction processing. They stop along with the context, abandoning queued alerts.
+func startWebhookSenders(ctx context.Context) {
+	if webhookURL == "" {
+		return
+	}
+
+	webhookQueue = make(chan webhookAlert, webhookQueueSize)
+	client := &http.Client{Timeout: webhookTimeout, Transport: &http.Transport{Proxy: proxyFunc}}
+	for i := 0; i < webhookSenders; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case alert := <-webhookQueue:
+					if err := postAlert(ctx, client, alert); err != nil && ctx.Err() == nil {
+						atomic.AddUint64(&metricWebhookFailures, 1)
+						logging.Warnf("Failed to send webhook alert for %s: %v", alert.Hash, err)
+					}
+				}
+			}
+		}()
+	}
+}
+
+// alertHighValue queues a webhook alert for a matched transaction whose value exceeds the threshold.
+// It never blocks: when the queue is full the alert is dropped and logged.
+func alertHighValue(chain string, result decoder.TransactionResult, contract Contract, method string) {
+	if webhookQueue == nil {
+		return
+	}
+	value, err := hexutil.DecodeBig(result.Result.Value)
+	if err != nil {
+		value = new(big.Int)
+	}
+	if alertMinValue != nil && value.Cmp(alertMinValue) <= 0 {
+		return
+	}
+
+	alert := webhookAlert{
+		Hash:     result.Result.Hash,
+		Chain:    chain,
+		From:     result.Result.From,
+		To:       result.Result.To,
+		Contract: contract.Name,
+		Value:    decoder.FormatUnits(value, etherDecimals),
+		ValueWei: result.Result.Value,
+		Method:   method,
+	}
+	select {
+	case webhookQueue <- alert:
+	default:
+		atomic.AddUint64(&metricWebhookFailures, 1)
+		logging.Warnf("Webhook queue full, dropping alert for %s", alert.Hash)
+	}
+}
+
+// postAlert posts an alert, retrying network errors and 5xx responses a few times
+func postAlert(ctx context.Context, client *http.Client, alert webhookAlert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := postOnce(ctx, client, payload)
+		if err == nil || !retry || attempt >= webhookRetries {
+			return err
+		}
+		select {
+		case <-time.After(webhookRetryDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// postOnce makes a single webhook request, reporting whether a failure is worth retrying
+func postOnce(ctx context.Context, client *http.Client, payload []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
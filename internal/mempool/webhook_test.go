@@ -0,0 +1,100 @@
+package mempool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+)
+
+// webhookServer answers webhook posts with the given statuses in turn, repeating the last one, and
+// counts the requests
+func webhookServer(t *testing.T, statuses ...int) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(server.Close)
+
+	savedURL, savedDelay := webhookURL, webhookRetryDelay
+	webhookURL, webhookRetryDelay = server.URL, time.Millisecond
+	t.Cleanup(func() { webhookURL, webhookRetryDelay = savedURL, savedDelay })
+	return &requests
+}
+
+func TestPostAlertRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      bool
+		wantRequests int32
+	}{
+		{"retried until delivered", []int{http.StatusServiceUnavailable, http.StatusOK}, false, 2},
+		{"rate limited", []int{http.StatusTooManyRequests, http.StatusNoContent}, false, 2},
+		{"client error isn't retried", []int{http.StatusBadRequest}, true, 1},
+		{"gives up after the retries", []int{http.StatusBadGateway}, true, defaultWebhookRetries + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := webhookServer(t, tt.statuses...)
+			err := postAlert(context.Background(), http.DefaultClient, webhookAlert{Hash: "0xabc"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("postAlert error = %v, want error %t", err, tt.wantErr)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("%d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+// Retries stop as soon as monitoring shuts down
+func TestPostAlertStopsOnCancel(t *testing.T) {
+	requests := webhookServer(t, http.StatusServiceUnavailable)
+	webhookRetryDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	done := make(chan error, 1)
+	go func() { done <- postAlert(ctx, http.DefaultClient, webhookAlert{Hash: "0xabc"}) }()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("postAlert returned %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("postAlert kept retrying after the context was canceled")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+// A full queue drops the alert and counts it rather than blocking the transaction
+func TestAlertHighValueDropsWhenQueueFull(t *testing.T) {
+	savedQueue := webhookQueue
+	webhookQueue = make(chan webhookAlert, 1)
+	t.Cleanup(func() { webhookQueue = savedQueue })
+	failures := atomic.LoadUint64(&metricWebhookFailures)
+
+	var result decoder.TransactionResult
+	result.Result.Value = "0x1"
+	for _, hash := range []string{"0x1", "0x2"} {
+		result.Result.Hash = hash
+		alertHighValue("", result, Contract{Name: "Router"}, "swap")
+	}
+
+	if alert := <-webhookQueue; alert.Hash != "0x1" {
+		t.Errorf("queued alert for %s, want the first one", alert.Hash)
+	}
+	if got := atomic.LoadUint64(&metricWebhookFailures) - failures; got != 1 {
+		t.Errorf("%d alerts counted as failed, want 1", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Package settings reads optional settings from the environment, collecting every invalid value so
+// that they are all reported together when the program starts
+package settings
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Problems collects invalid settings. The readers only change a setting when it is set to a valid
+// value, leaving the default otherwise.
+type Problems []string
+
+// Add records an invalid setting
+func (p *Problems) Add(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+// Err lists every problem in a single error, or returns nil without any
+func (p Problems) Err() error {
+	if len(p) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(p, "\n  - "))
+	}
+	return nil
+}
+
+// Duration reads a non-negative duration; 0 is only accepted when it turns the feature off
+func (p *Problems) Duration(name string, dst *time.Duration, allowZero bool) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	switch {
+	case err != nil || d < 0:
+		p.Add("invalid %s %q, expected a duration such as 30s", name, v)
+	case d == 0 && !allowZero:
+		p.Add("invalid %s %q, expected a positive duration such as 30s", name, v)
+	default:
+		*dst = d
+	}
+}
+
+// Int reads a whole number of at least min, which is 0 or 1
+func (p *Problems) Int(name string, dst *int, min int) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	switch {
+	case (err != nil || n < 0) && min == 0:
+		p.Add("invalid %s %q, expected 0 or a positive whole number", name, v)
+	case err != nil || n < min:
+		p.Add("invalid %s %q, expected a positive whole number", name, v)
+	default:
+		*dst = n
+	}
+}
+
+// PositiveFloat reads a number greater than 0
+func (p *Problems) PositiveFloat(name string, dst *float64) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f <= 0 {
+		p.Add("invalid %s %q, expected a positive number", name, v)
+		return
+	}
+	*dst = f
+}
+
+// Bool reads a flag such as true, false, 1 or 0
+func (p *Problems) Bool(name string, dst *bool) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.Add("invalid %s %q, expected true or false", name, v)
+		return
+	}
+	*dst = b
+}
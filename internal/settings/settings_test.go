@@ -0,0 +1,61 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadersKeepDefaultsAndReportInvalidValues(t *testing.T) {
+	t.Setenv("VALID_DURATION", "45s")
+	t.Setenv("ZERO_DURATION", "0s")
+	t.Setenv("BAD_DURATION", "soon")
+	t.Setenv("VALID_INT", "0")
+	t.Setenv("BAD_INT", "0")
+	t.Setenv("BAD_FLOAT", "-1")
+	t.Setenv("BAD_BOOL", "maybe")
+
+	var p Problems
+	interval, off := time.Second, time.Second
+	p.Duration("VALID_DURATION", &interval, false)
+	p.Duration("ZERO_DURATION", &off, true)
+	p.Duration("UNSET_DURATION", &interval, false)
+	if interval != 45*time.Second || off != 0 {
+		t.Errorf("durations = %s and %s, want 45s and 0s", interval, off)
+	}
+
+	retries, workers := 3, 4
+	p.Int("VALID_INT", &retries, 0)
+	p.Int("BAD_INT", &workers, 1)
+	if retries != 0 || workers != 4 {
+		t.Errorf("ints = %d and %d, want 0 and the default 4", retries, workers)
+	}
+
+	ratio, enabled := 1.5, true
+	p.PositiveFloat("BAD_FLOAT", &ratio)
+	p.Bool("BAD_BOOL", &enabled)
+	p.Duration("BAD_DURATION", &interval, false)
+	if ratio != 1.5 || !enabled {
+		t.Errorf("invalid values replaced the defaults: %v, %v", ratio, enabled)
+	}
+
+	err := p.Err()
+	if err == nil {
+		t.Fatal("Err = nil, want the invalid values")
+	}
+	for _, want := range []string{`BAD_INT "0"`, `BAD_FLOAT "-1"`, `BAD_BOOL "maybe"`, `BAD_DURATION "soon"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error doesn't report %s:\n%v", want, err)
+		}
+	}
+	if len(p) != 4 {
+		t.Errorf("%d problems, want 4:\n%v", len(p), err)
+	}
+}
+
+func TestNoProblems(t *testing.T) {
+	var p Problems
+	if err := p.Err(); err != nil {
+		t.Errorf("Err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,164 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// ErrClosed is returned when writing to a buffered sink that has been closed
+var ErrClosed = errors.New("sink is closed")
+
+// Buffered accumulates records in memory and writes them to the wrapped sink in batches,
+// either when BatchSize records are pending or every FlushInterval, whichever comes first. At most
+// MaxBuffered records are held; while the sink keeps failing the oldest are dropped and counted.
+type Buffered[T any] struct {
+	sink Sink[T]
+	cfg  BufferConfig
+
+	mu      sync.Mutex
+	buf     []T
+	closed  bool
+	dropped atomic.Uint64 // Records dropped to stay within MaxBuffered
+
+	flushCh chan struct{} // Signals the flush loop that a full batch is waiting
+	done    chan struct{} // Closed to stop the flush loop
+	wg      sync.WaitGroup
+}
+
+// NewBuffered wraps a sink with a batching write path and starts its background flush loop
+func NewBuffered[T any](s Sink[T], cfg BufferConfig) *Buffered[T] {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxBuffered < cfg.BatchSize {
+		cfg.MaxBuffered = max(DefaultMaxBuffered, cfg.BatchSize)
+	}
+
+	b := &Buffered[T]{
+		sink:    s,
+		cfg:     cfg,
+		buf:     make([]T, 0, cfg.BatchSize),
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.loop()
+
+	return b
+}
+
+// Write queues a record for the next batch; it never blocks on the underlying sink
+func (b *Buffered[T]) Write(record T) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.buf = append(b.buf, record)
+	b.trim()
+	full := len(b.buf) >= b.cfg.BatchSize
+	b.mu.Unlock()
+
+	if full {
+		// Wake the flush loop without blocking if a flush is already pending
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush writes all pending records to the sink immediately
+func (b *Buffered[T]) Flush() error {
+	b.mu.Lock()
+	if len(b.buf) == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	batch := b.buf
+	b.buf = make([]T, 0, b.cfg.BatchSize)
+	b.mu.Unlock()
+
+	if err := b.sink.WriteBatch(batch); err != nil {
+		// Put the batch back in front of anything queued meanwhile so the next flush retries it
+		b.mu.Lock()
+		b.buf = append(batch, b.buf...)
+		b.trim()
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// trim drops the oldest records beyond MaxBuffered; the caller holds mu
+func (b *Buffered[T]) trim() {
+	if excess := len(b.buf) - b.cfg.MaxBuffered; excess > 0 {
+		clear(b.buf[:excess]) // Let the dropped records be garbage collected
+		b.buf = b.buf[excess:]
+		b.dropped.Add(uint64(excess))
+	}
+}
+
+// Dropped returns how many records have been dropped to stay within MaxBuffered
+func (b *Buffered[T]) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// Close stops the flush loop, writes any remaining records and closes the underlying sink. A failed
+// final flush is returned along with the number of records lost, counting those dropped earlier.
+func (b *Buffered[T]) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.done)
+	b.wg.Wait()
+
+	// Final flush so no records are lost on graceful shutdown
+	var flushErr error
+	if err := b.Flush(); err != nil {
+		b.mu.Lock()
+		lost := uint64(len(b.buf)) + b.Dropped()
+		b.buf = nil
+		b.mu.Unlock()
+		flushErr = fmt.Errorf("final flush failed, %d records lost: %w", lost, err)
+	} else if dropped := b.Dropped(); dropped > 0 {
+		logging.Warnf("Sink dropped %d records that didn't fit in its buffer", dropped)
+	}
+	return errors.Join(flushErr, b.sink.Close())
+}
+
+// loop flushes on a timer and whenever a full batch is signalled
+func (b *Buffered[T]) loop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.flushCh:
+		}
+
+		if err := b.Flush(); err != nil {
+			logging.Errorf("Failed to flush sink batch (%d records dropped so far): %v", b.Dropped(), err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package sink
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("backend unavailable")
+
+// fakeSink records the batches it is given, failing while failing is set
+type fakeSink struct {
+	mu      sync.Mutex
+	failing bool
+	written []int
+	closed  bool
+}
+
+func (s *fakeSink) WriteBatch(records []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return errUnavailable
+	}
+	s.written = append(s.written, records...)
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestBufferedDropsOldestWhenFull(t *testing.T) {
+	backend := &fakeSink{failing: true}
+	b := NewBuffered[int](backend, BufferConfig{BatchSize: 2, FlushInterval: time.Hour, MaxBuffered: 5})
+
+	for i := 1; i <= 8; i++ {
+		if err := b.Write(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Flush(); !errors.Is(err, errUnavailable) {
+		t.Fatalf("Flush = %v, want %v", err, errUnavailable)
+	}
+	if got := b.Dropped(); got != 3 {
+		t.Errorf("dropped %d records, want 3", got)
+	}
+
+	// Once the backend recovers the newest records are written in order
+	backend.mu.Lock()
+	backend.failing = false
+	backend.mu.Unlock()
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{4, 5, 6, 7, 8}; !slices.Equal(backend.written, want) {
+		t.Errorf("wrote %v, want %v", backend.written, want)
+	}
+}
+
+func TestBufferedCloseReportsLostRecords(t *testing.T) {
+	backend := &fakeSink{failing: true}
+	b := NewBuffered[int](backend, BufferConfig{BatchSize: 10, FlushInterval: time.Hour, MaxBuffered: 10})
+	for i := 0; i < 12; i++ {
+		b.Write(i)
+	}
+
+	err := b.Close()
+	if !errors.Is(err, errUnavailable) {
+		t.Fatalf("Close = %v, want the final flush error", err)
+	}
+	if !strings.Contains(err.Error(), "12 records lost") {
+		t.Errorf("Close = %q, want it to count the 12 records lost", err)
+	}
+	if !backend.closed {
+		t.Error("underlying sink not closed after a failed flush")
+	}
+	if err := b.Write(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write after Close = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,81 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CSVSink appends records as CSV rows to a file for spreadsheet analysis. The header row is written
+// once, when the file is new or empty, so restarts keep appending to the same table.
+type CSVSink[T any] struct {
+	file  *os.File
+	buf   *bufio.Writer
+	csv   *csv.Writer
+	toRow func(T) []string
+}
+
+// NewCSVSink creates a CSV sink appending to path, creating its directory if needed. toRow turns a
+// record into the columns named by header.
+func NewCSVSink[T any](path string, header []string, toRow func(T) []string) (*CSVSink[T], error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create CSV output directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat CSV file: %w", err)
+	}
+
+	buf := bufio.NewWriter(file)
+	s := &CSVSink[T]{file: file, buf: buf, csv: csv.NewWriter(buf), toRow: toRow}
+	if info.Size() == 0 {
+		if err := s.write([][]string{header}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// WriteBatch appends a row per record and flushes them to the file
+func (s *CSVSink[T]) WriteBatch(records []T) error {
+	if len(records) == 0 {
+		return nil
+	}
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		rows[i] = s.toRow(record)
+	}
+	return s.write(rows)
+}
+
+// write writes rows through the CSV and buffered writers and flushes both
+func (s *CSVSink[T]) write(rows [][]string) error {
+	if err := s.csv.WriteAll(rows); err != nil { // WriteAll flushes the CSV writer
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+	if err := s.buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return nil
+}
+
+// Close flushes any buffered output and closes the file
+func (s *CSVSink[T]) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.buf.Flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
@@ -0,0 +1,187 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"eth-mempool-monitor/internal/logging"
+)
+
+// jsonlFile is the file a JSONL sink appends to; an *os.File outside of tests
+type jsonlFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// JSONLSink appends records, such as matched transactions, as JSON lines to a file. When the rotation
+// policy triggers, the file is renamed with a timestamp suffix and a fresh file is started at the
+// same path, which suits log shippers that follow a fixed path.
+type JSONLSink[T any] struct {
+	path   string
+	policy RotationPolicy
+	fsync  bool // Sync the file to disk after every batch
+
+	file     jsonlFile
+	size     int64
+	openedAt time.Time
+	seq      int
+}
+
+// NewJSONLSink creates a JSONL sink appending to path, creating its directory if needed
+func NewJSONLSink[T any](path string, policy RotationPolicy, fsync bool) (*JSONLSink[T], error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create JSONL output directory: %w", err)
+	}
+	s := &JSONLSink[T]{path: path, policy: policy, fsync: fsync}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WriteBatch encodes the whole batch before writing it in a single call, then rotates if required
+func (s *JSONLSink[T]) WriteBatch(records []T) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return fmt.Errorf("failed to encode record %d of the batch: %w", i, err)
+		}
+	}
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	start := s.size
+	n, err := s.file.Write(buf.Bytes())
+	s.size += int64(n)
+	if err != nil {
+		s.rollback(start)
+		return fmt.Errorf("failed to write JSONL file: %w", err)
+	}
+	if s.fsync {
+		if err := s.file.Sync(); err != nil {
+			s.rollback(start)
+			return fmt.Errorf("failed to sync JSONL file: %w", err)
+		}
+	}
+
+	// The batch is already written, so a failed rotation is only logged; returning an error would
+	// make the buffer retry and duplicate the records
+	if s.shouldRotate() {
+		if err := s.rotate(); err != nil {
+			logging.Warnf("Failed to rotate JSONL file, continuing with the current file: %v", err)
+		}
+	}
+	return nil
+}
+
+// rollback truncates the file back to offset, the size before a failed batch, so the buffer's retry
+// doesn't append the lines that did reach the file a second time. The file is opened for appending,
+// so later writes land at the new end without seeking. If the file can't be truncated it is closed,
+// and the next batch reopens it.
+func (s *JSONLSink[T]) rollback(offset int64) {
+	s.size = offset
+	if err := s.file.Truncate(offset); err != nil {
+		logging.Errorf("Failed to roll back a partly written JSONL batch, reopening the file: %v", err)
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+// Close syncs and closes the current file
+func (s *JSONLSink[T]) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
+
+func (s *JSONLSink[T]) shouldRotate() bool {
+	if s.policy.MaxBytes > 0 && s.size >= s.policy.MaxBytes {
+		return true
+	}
+	return s.policy.MaxAge > 0 && time.Since(s.openedAt) >= s.policy.MaxAge
+}
+
+// open opens the file for appending, continuing an existing file left by a previous run
+func (s *JSONLSink[T]) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open JSONL file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat JSONL file: %w", err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	s.openedAt = time.Now()
+	return nil
+}
+
+// rotate closes the current file, moves it aside with a timestamp suffix and starts a new one
+func (s *JSONLSink[T]) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+
+	s.seq++
+	ext := filepath.Ext(s.path)
+	// Zero-pad the sequence so files rotated within the same second still sort in order
+	rotated := fmt.Sprintf("%s-%s-%06d%s", strings.TrimSuffix(s.path, ext), time.Now().UTC().Format("20060102T150405"), s.seq, ext)
+	renameErr := os.Rename(s.path, rotated)
+
+	// Reopen even if the rename failed so later batches still have somewhere to go
+	if err := s.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return fmt.Errorf("failed to rotate JSONL file: %w", renameErr)
+	}
+	return s.prune(strings.TrimSuffix(s.path, ext) + "-*" + ext)
+}
+
+// prune deletes the oldest rotated files beyond the policy's MaxFiles
+func (s *JSONLSink[T]) prune(pattern string) error {
+	if s.policy.MaxFiles <= 0 {
+		return nil
+	}
+
+	// The timestamp and sequence suffix sorts rotated files oldest first
+	rotated, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to list rotated JSONL files: %w", err)
+	}
+	sort.Strings(rotated)
+	for len(rotated) > s.policy.MaxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			return fmt.Errorf("failed to remove old JSONL file: %w", err)
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
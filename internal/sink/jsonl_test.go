@@ -0,0 +1,109 @@
+package sink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Pruning keeps the newest rotated files even once the sequence number reaches two digits
+func TestJSONLSinkPrunesOldestRotations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+	// Every batch exceeds one byte, so each one is rotated into its own file
+	s, err := NewJSONLSink[int](path, RotationPolicy{MaxBytes: 1, MaxFiles: 3}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 12; i++ {
+		if err := s.WriteBatch([]int{i}); err != nil {
+			t.Fatalf("WriteBatch %d: %v", i, err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := filepath.Glob(filepath.Join(filepath.Dir(path), "transactions-*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []string
+	for _, file := range rotated {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kept = append(kept, strings.TrimSpace(string(data)))
+	}
+	if got := strings.Join(kept, ","); got != "10,11,12" {
+		t.Errorf("kept rotated batches %s, want 10,11,12", got)
+	}
+}
+
+// failingJSONLFile fails its next write, after writing half of it, or its next sync once armed
+type failingJSONLFile struct {
+	*os.File
+	failWrite, failSync bool
+}
+
+func (f *failingJSONLFile) Write(b []byte) (int, error) {
+	if !f.failWrite {
+		return f.File.Write(b)
+	}
+	f.failWrite = false
+	n, _ := f.File.Write(b[:len(b)/2])
+	return n, errors.New("disk full")
+}
+
+func (f *failingJSONLFile) Sync() error {
+	if !f.failSync {
+		return f.File.Sync()
+	}
+	f.failSync = false
+	return errors.New("sync failed")
+}
+
+// A batch that fails part way, or whose sync fails, is removed again so its retry writes each line once
+func TestJSONLSinkRetriesFailedBatch(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file failingJSONLFile
+	}{
+		{"partial write", failingJSONLFile{failWrite: true}},
+		{"failed sync", failingJSONLFile{failSync: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transactions.jsonl")
+			s, err := NewJSONLSink[int](path, RotationPolicy{}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.WriteBatch([]int{1}); err != nil {
+				t.Fatalf("WriteBatch: %v", err)
+			}
+
+			file := tt.file
+			file.File = s.file.(*os.File)
+			s.file = &file
+			if err := s.WriteBatch([]int{2, 3}); err == nil {
+				t.Fatal("WriteBatch succeeded despite the failure")
+			}
+			if err := s.WriteBatch([]int{2, 3}); err != nil {
+				t.Fatalf("retried WriteBatch: %v", err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data); got != "1\n2\n3\n" {
+				t.Errorf("file holds %q, want each line once", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+	"eth-mempool-monitor/internal/logging"
+)
+
+// Parquet physical types, converted types and enums used by the writer
+const (
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+
+	parquetRequired     = 0
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetDataPage     = 0
+	parquetUncompressed = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetColumn describes one column of the matched transaction schema
+type parquetColumn struct {
+	name          string
+	physicalType  int32
+	convertedType int32
+	value         func(tx *decoder.DecodedTransaction) interface{} // Returns int64 or string
+}
+
+// parquetSchema is the columnar layout of matched transactions written by the Parquet sink
+var parquetSchema = []parquetColumn{
+	{"hash", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} { return tx.Hash }},
+	{"timestamp", parquetInt64, parquetTimestampMillis, func(tx *decoder.DecodedTransaction) interface{} { return tx.Timestamp.UnixMilli() }},
+	{"contract", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} { return tx.Contract }},
+	{"from", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} { return tx.From }},
+	{"to", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} { return tx.To }},
+	{"value", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} { return tx.Value }},
+	{"gas", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} { return tx.Gas }},
+	{"method", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} { return tx.Method }},
+	{"params_json", parquetByteArray, parquetUTF8, func(tx *decoder.DecodedTransaction) interface{} {
+		params, err := json.Marshal(tx.Params)
+		if err != nil {
+			return "[]"
+		}
+		return string(params)
+	}},
+}
+
+// parquetColumnChunk records where a column chunk was written, for the file footer
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// parquetRowGroup is the footer metadata of a row group already written to the file
+type parquetRowGroup struct {
+	numRows int64
+	columns []parquetColumnChunk
+}
+
+// parquetFile is the file a Parquet sink writes to; an *os.File outside of tests
+type parquetFile interface {
+	io.WriteSeeker
+	Truncate(size int64) error
+	Close() error
+}
+
+// ParquetSink writes matched transactions to rotating Parquet files. Each batch becomes one
+// row group; the footer is written when the file is rotated or the sink is closed.
+type ParquetSink struct {
+	dir    string
+	policy RotationPolicy
+
+	create    func(name string) (parquetFile, error)
+	file      parquetFile
+	w         *bufio.Writer
+	offset    int64
+	openedAt  time.Time
+	numRows   int64
+	rowGroups []parquetRowGroup
+	seq       int
+}
+
+// NewParquetSink creates a Parquet sink writing files into dir, creating it if needed
+func NewParquetSink(dir string, policy RotationPolicy) (*ParquetSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create parquet output directory: %w", err)
+	}
+	create := func(name string) (parquetFile, error) { return os.Create(name) }
+	return &ParquetSink{dir: dir, policy: policy, create: create}, nil
+}
+
+// WriteBatch appends the records as a single row group, rotating the file afterwards if required. A
+// row group that fails part way is removed again, so the batch can be retried.
+func (p *ParquetSink) WriteBatch(records []decoder.DecodedTransaction) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	if p.file == nil {
+		if err := p.open(); err != nil {
+			return err
+		}
+	}
+
+	start := p.offset
+	group := parquetRowGroup{numRows: int64(len(records))}
+	for _, col := range parquetSchema {
+		chunk, err := p.writeColumn(col, records)
+		if err != nil {
+			p.rollback(start)
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+	}
+	// Flush so everything up to the offset is in the file, and a later failure can be rolled back to it
+	if err := p.w.Flush(); err != nil {
+		p.rollback(start)
+		return fmt.Errorf("failed to write parquet file: %w", err)
+	}
+	p.rowGroups = append(p.rowGroups, group)
+	p.numRows += group.numRows
+
+	if p.shouldRotate() {
+		return p.finish()
+	}
+	return nil
+}
+
+// Close writes the footer of the current file, leaving a complete Parquet file behind
+func (p *ParquetSink) Close() error {
+	if p.file == nil {
+		return nil
+	}
+	return p.finish()
+}
+
+func (p *ParquetSink) shouldRotate() bool {
+	if p.policy.MaxBytes > 0 && p.offset >= p.policy.MaxBytes {
+		return true
+	}
+	return p.policy.MaxAge > 0 && time.Since(p.openedAt) >= p.policy.MaxAge
+}
+
+// open starts a new Parquet file named after the current time
+func (p *ParquetSink) open() error {
+	p.seq++
+	name := fmt.Sprintf("transactions-%s-%d.parquet", time.Now().UTC().Format("20060102T150405"), p.seq)
+
+	file, err := p.create(filepath.Join(p.dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to create parquet file: %w", err)
+	}
+
+	p.file = file
+	p.w = bufio.NewWriter(file)
+	p.offset = 0
+	p.openedAt = time.Now()
+	p.numRows = 0
+	p.rowGroups = nil
+
+	// Flush the magic so a failed first row group can be rolled back to just after it
+	err = p.write(parquetMagic)
+	if err == nil {
+		if flushErr := p.w.Flush(); flushErr != nil {
+			err = fmt.Errorf("failed to write parquet file: %w", flushErr)
+		}
+	}
+	if err != nil {
+		file.Close()
+		p.file = nil
+		p.w = nil
+	}
+	return err
+}
+
+// rollback truncates the file back to offset, the end of the last complete row group, discarding
+// whatever part of a failed row group reached it. Otherwise a retried batch would be written after
+// bytes the footer doesn't account for. If the file can't be truncated it is abandoned, and the next
+// batch starts a new file.
+func (p *ParquetSink) rollback(offset int64) {
+	p.offset = offset
+	p.w.Reset(p.file) // Drop buffered bytes and the writer's sticky error
+
+	err := p.file.Truncate(offset)
+	if err == nil {
+		_, err = p.file.Seek(offset, io.SeekStart)
+	}
+	if err == nil {
+		return
+	}
+
+	logging.Errorf("Failed to roll back a partly written parquet row group, abandoning the file: %v", err)
+	p.file.Close()
+	p.file = nil
+	p.w = nil
+}
+
+// writeColumn writes one column chunk consisting of a single PLAIN-encoded data page
+func (p *ParquetSink) writeColumn(col parquetColumn, records []decoder.DecodedTransaction) (parquetColumnChunk, error) {
+	var values []byte
+	for i := range records {
+		switch v := col.value(&records[i]).(type) {
+		case int64:
+			values = binary.LittleEndian.AppendUint64(values, uint64(v))
+		case string:
+			values = binary.LittleEndian.AppendUint32(values, uint32(len(v)))
+			values = append(values, v...)
+		}
+	}
+
+	// Page header
+	var hdr thriftWriter
+	hdr.structBegin()
+	hdr.i32Field(1, parquetDataPage)
+	hdr.i32Field(2, int32(len(values)))
+	hdr.i32Field(3, int32(len(values)))
+	hdr.fieldHeader(5, thriftStruct)
+	hdr.structBegin()
+	hdr.i32Field(1, int32(len(records)))
+	hdr.i32Field(2, parquetPlain)
+	hdr.i32Field(3, parquetRLE)
+	hdr.i32Field(4, parquetRLE)
+	hdr.structEnd()
+	hdr.structEnd()
+
+	chunk := parquetColumnChunk{
+		offset: p.offset,
+		size:   int64(hdr.buf.Len() + len(values)),
+		values: int64(len(records)),
+	}
+
+	if err := p.write(hdr.buf.Bytes()); err != nil {
+		return chunk, err
+	}
+	return chunk, p.write(values)
+}
+
+// finish writes the file metadata footer and closes the file
+func (p *ParquetSink) finish() error {
+	var meta thriftWriter
+	meta.structBegin()
+	meta.i32Field(1, 1) // version
+
+	// Schema: a root element followed by one leaf per column
+	meta.fieldHeader(2, thriftList)
+	meta.listHeader(len(parquetSchema)+1, thriftStruct)
+	meta.structBegin()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(parquetSchema)))
+	meta.structEnd()
+	for _, col := range parquetSchema {
+		meta.structBegin()
+		meta.i32Field(1, col.physicalType)
+		meta.i32Field(3, parquetRequired)
+		meta.stringField(4, col.name)
+		meta.i32Field(6, col.convertedType)
+		meta.structEnd()
+	}
+
+	meta.i64Field(3, p.numRows)
+
+	meta.fieldHeader(4, thriftList)
+	meta.listHeader(len(p.rowGroups), thriftStruct)
+	for _, group := range p.rowGroups {
+		var totalSize int64
+		meta.structBegin()
+		meta.fieldHeader(1, thriftList)
+		meta.listHeader(len(group.columns), thriftStruct)
+		for i, chunk := range group.columns {
+			col := parquetSchema[i]
+			totalSize += chunk.size
+
+			meta.structBegin()
+			meta.i64Field(2, chunk.offset)
+			meta.fieldHeader(3, thriftStruct)
+			meta.structBegin()
+			meta.i32Field(1, col.physicalType)
+			meta.fieldHeader(2, thriftList)
+			meta.listHeader(1, thriftI32)
+			meta.varint(parquetPlain)
+			meta.fieldHeader(3, thriftList)
+			meta.listHeader(1, thriftBinary)
+			meta.str(col.name)
+			meta.i32Field(4, parquetUncompressed)
+			meta.i64Field(5, chunk.values)
+			meta.i64Field(6, chunk.size)
+			meta.i64Field(7, chunk.size)
+			meta.i64Field(9, chunk.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.i64Field(2, totalSize)
+		meta.i64Field(3, group.numRows)
+		meta.structEnd()
+	}
+
+	meta.stringField(6, "eth-mempool-monitor")
+	meta.structEnd()
+
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(meta.buf.Len()))
+
+	err := p.write(meta.buf.Bytes())
+	if err == nil {
+		err = p.write(footerLen[:])
+	}
+	if err == nil {
+		err = p.write(parquetMagic)
+	}
+	if err == nil {
+		err = p.w.Flush()
+	}
+	if closeErr := p.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	p.file = nil
+	p.w = nil
+	return err
+}
+
+func (p *ParquetSink) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write parquet file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package sink
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"eth-mempool-monitor/internal/decoder"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testdata/transactions.parquet was checked by decoding its footer and page headers with
+// github.com/parquet-go/parquet-go's format and thrift packages, which read back the schema,
+// both row groups and every value below. Regenerate it with -update after changing the writer,
+// and check it with a standard reader again.
+const parquetGolden = "testdata/transactions.parquet"
+
+func TestParquetSinkMatchesGolden(t *testing.T) {
+	records := []decoder.DecodedTransaction{
+		{Hash: "0xaa", Timestamp: time.UnixMilli(1700000000123), Contract: "Router", From: "0x1", To: "0x2", Value: "0", Gas: "21000", Method: "swap"},
+		{Hash: "0xbb", Timestamp: time.UnixMilli(1700000000456), Method: "approve"},
+	}
+
+	dir := t.TempDir()
+	sink, err := NewParquetSink(dir, RotationPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two batches make two row groups
+	if err := sink.WriteBatch(records); err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	if err := sink.WriteBatch(records[:1]); err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.parquet"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one parquet file, got %v (%v)", files, err)
+	}
+	got, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err := os.WriteFile(parquetGolden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(parquetGolden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("parquet output differs from %s; check it with a Parquet reader and rerun with -update", parquetGolden)
+	}
+	if !bytes.HasPrefix(got, parquetMagic) || !bytes.HasSuffix(got, parquetMagic) {
+		t.Error("file doesn't start and end with the PAR1 magic")
+	}
+}
+
+// failingFile fails its next write once armed, after writing only the first half of it
+type failingFile struct {
+	*os.File
+	armed bool
+}
+
+func (f *failingFile) Write(b []byte) (int, error) {
+	if !f.armed {
+		return f.File.Write(b)
+	}
+	f.armed = false
+	n, _ := f.File.Write(b[:len(b)/2])
+	return n, errors.New("disk full")
+}
+
+// A row group that fails part way is rolled back, so retrying the batch writes the same file as a
+// batch that never failed
+func TestParquetSinkRetriesPartialWrite(t *testing.T) {
+	records := []decoder.DecodedTransaction{
+		{Hash: "0xaa", Timestamp: time.UnixMilli(1700000000123), Contract: "Router", From: "0x1", To: "0x2", Value: "0", Gas: "21000", Method: "swap"},
+		{Hash: "0xbb", Timestamp: time.UnixMilli(1700000000456), Method: "approve"},
+	}
+
+	writeFile := func(fail bool) []byte {
+		dir := t.TempDir()
+		sink, err := NewParquetSink(dir, RotationPolicy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var file *failingFile
+		sink.create = func(name string) (parquetFile, error) {
+			f, err := os.Create(name)
+			file = &failingFile{File: f}
+			return file, err
+		}
+
+		if err := sink.WriteBatch(records); err != nil {
+			t.Fatalf("WriteBatch: %v", err)
+		}
+		if fail {
+			file.armed = true
+			if err := sink.WriteBatch(records); err == nil {
+				t.Fatal("WriteBatch succeeded despite the failing write")
+			}
+		}
+		if err := sink.WriteBatch(records); err != nil {
+			t.Fatalf("WriteBatch: %v", err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.parquet"))
+		if err != nil || len(files) != 1 {
+			t.Fatalf("expected one parquet file, got %v (%v)", files, err)
+		}
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	want := writeFile(false)
+	if got := writeFile(true); !bytes.Equal(got, want) {
+		t.Errorf("retried batch wrote %d bytes, want the %d bytes of an uninterrupted write", len(got), len(want))
+	}
+}
@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"time"
+
+	"eth-mempool-monitor/internal/settings"
+)
+
+// Sink persists batches of records to a storage backend (file, database, broker...)
+type Sink[T any] interface {
+	// WriteBatch writes all records in one go; implementations should be all-or-nothing where possible
+	WriteBatch(records []T) error
+	// Close releases any resources held by the sink
+	Close() error
+}
+
+// Default buffering parameters used when a sink has no explicit configuration
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 500 * time.Millisecond
+	DefaultMaxBuffered   = 10000
+)
+
+// BufferConfig controls how records are accumulated before being flushed to a sink
+type BufferConfig struct {
+	BatchSize     int           // Flush as soon as this many records are buffered
+	FlushInterval time.Duration // Flush pending records at least this often
+	MaxBuffered   int           // Drop the oldest records beyond this many, e.g. while the sink keeps failing
+}
+
+// BufferConfigFromEnv reads <PREFIX>_BATCH_SIZE, <PREFIX>_FLUSH_MS and <PREFIX>_MAX_BUFFERED, falling
+// back to the defaults. Invalid values are added to the problems.
+func BufferConfigFromEnv(prefix string, p *settings.Problems) BufferConfig {
+	cfg := BufferConfig{
+		BatchSize:     DefaultBatchSize,
+		FlushInterval: DefaultFlushInterval,
+		MaxBuffered:   DefaultMaxBuffered,
+	}
+
+	p.Int(prefix+"_BATCH_SIZE", &cfg.BatchSize, 1)
+	flushMs := 0
+	p.Int(prefix+"_FLUSH_MS", &flushMs, 1)
+	if flushMs > 0 {
+		cfg.FlushInterval = time.Duration(flushMs) * time.Millisecond
+	}
+	p.Int(prefix+"_MAX_BUFFERED", &cfg.MaxBuffered, 1)
+
+	return cfg
+}
+
+// RotationPolicy decides when the current output file is closed and a new one started.
+// A zero value for either field disables that trigger.
+type RotationPolicy struct {
+	MaxBytes int64         // Rotate once the file reaches this size
+	MaxAge   time.Duration // Rotate once the file has been open this long
+	MaxFiles int           // Keep at most this many rotated files, deleting the oldest (JSONL only; 0 keeps all)
+}
+
+// RotationPolicyFromEnv reads <PREFIX>_ROTATE_MB, <PREFIX>_ROTATE_INTERVAL (a Go duration such as "1h")
+// and <PREFIX>_MAX_FILES.
+// Unset or zero values leave the corresponding trigger disabled; invalid values are added to the problems.
+func RotationPolicyFromEnv(prefix string, p *settings.Problems) RotationPolicy {
+	var policy RotationPolicy
+
+	rotateMB := 0
+	p.Int(prefix+"_ROTATE_MB", &rotateMB, 0)
+	policy.MaxBytes = int64(rotateMB) << 20
+	p.Duration(prefix+"_ROTATE_INTERVAL", &policy.MaxAge, true)
+	p.Int(prefix+"_MAX_FILES", &policy.MaxFiles, 0)
+
+	return policy
+}
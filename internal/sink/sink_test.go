@@ -0,0 +1,36 @@
+package sink
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"eth-mempool-monitor/internal/settings"
+)
+
+func TestFromEnvReportsInvalidValues(t *testing.T) {
+	t.Setenv("TEST_BATCH_SIZE", "0")
+	t.Setenv("TEST_FLUSH_MS", "250")
+	t.Setenv("TEST_MAX_BUFFERED", "lots")
+	t.Setenv("TEST_ROTATE_MB", "10")
+	t.Setenv("TEST_ROTATE_INTERVAL", "daily")
+
+	var p settings.Problems
+	cfg := BufferConfigFromEnv("TEST", &p)
+	policy := RotationPolicyFromEnv("TEST", &p)
+
+	want := BufferConfig{BatchSize: DefaultBatchSize, FlushInterval: 250 * time.Millisecond, MaxBuffered: DefaultMaxBuffered}
+	if cfg != want {
+		t.Errorf("BufferConfigFromEnv = %+v, want %+v", cfg, want)
+	}
+	if policy != (RotationPolicy{MaxBytes: 10 << 20}) {
+		t.Errorf("RotationPolicyFromEnv = %+v, want only 10 MB", policy)
+	}
+
+	err := p.Err()
+	for _, name := range []string{"TEST_BATCH_SIZE", "TEST_MAX_BUFFERED", "TEST_ROTATE_INTERVAL"} {
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s isn't reported: %v", name, err)
+		}
+	}
+}
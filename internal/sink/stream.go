@@ -0,0 +1,41 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"eth-mempool-monitor/internal/decoder"
+)
+
+// StreamSink writes matched transactions as newline-delimited JSON to a stream such as stdout
+type StreamSink struct {
+	w io.Writer
+}
+
+// NewStreamSink creates a sink writing JSON lines to w. Closing the sink does not close w.
+func NewStreamSink(w io.Writer) *StreamSink {
+	return &StreamSink{w: w}
+}
+
+// WriteBatch encodes the whole batch before writing it so lines from a batch are never interleaved
+func (s *StreamSink) WriteBatch(records []decoder.DecodedTransaction) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return fmt.Errorf("failed to encode transaction %s: %w", records[i].Hash, err)
+		}
+	}
+
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write JSON output: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op since the stream is owned by the caller
+func (s *StreamSink) Close() error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol type identifiers used by the Parquet metadata structures
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter is a minimal Thrift compact protocol encoder, just enough to serialize
+// Parquet page headers and file metadata without pulling in a Thrift library
+type thriftWriter struct {
+	buf    bytes.Buffer
+	fields []int16 // Last written field id for each open struct
+}
+
+// structBegin opens a nested struct; field ids restart from zero inside it
+func (w *thriftWriter) structBegin() {
+	w.fields = append(w.fields, 0)
+}
+
+// structEnd writes the stop marker and closes the innermost struct
+func (w *thriftWriter) structEnd() {
+	w.buf.WriteByte(0)
+	w.fields = w.fields[:len(w.fields)-1]
+}
+
+// fieldHeader writes a field header, using the short delta form when possible
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &w.fields[len(w.fields)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(int64(id))
+	}
+	*last = id
+}
+
+// listHeader writes the header of a list with the given size and element type
+func (w *thriftWriter) listHeader(size int, elemType byte) {
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	w.buf.WriteByte(0xf0 | elemType)
+	w.uvarint(uint64(size))
+}
+
+func (w *thriftWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) stringField(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.str(v)
+}
+
+func (w *thriftWriter) str(v string) {
+	w.uvarint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+// varint writes a zigzag-encoded signed integer
+func (w *thriftWriter) varint(v int64) {
+	w.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf.Write(tmp[:n])
+}